@@ -1,5 +1,49 @@
 package config
 
+import (
+	"os"
+	"time"
+)
+
 const (
 	ApiListenParamName = "API_LISTEN_ENDPOINT"
+
+	TrashRetentionParamName     = "TRASH_RETENTION"
+	TrashPurgeIntervalParamName = "TRASH_PURGE_INTERVAL"
+
+	SummaryStaleTimeoutParamName  = "SUMMARY_STALE_TIMEOUT"
+	SummarySweepIntervalParamName = "SUMMARY_SWEEP_INTERVAL"
+
+	// SummaryStreamMaxDurationParamName must be shorter than server's write timeout
+	SummaryStreamMaxDurationParamName = "SUMMARY_STREAM_MAX_DURATION"
+
+	// SummaryBrokerParamName selects summary events broker: "memory" (default) or "redis",
+	// the latter is required to stream summaries from several API replicas
+	SummaryBrokerParamName = "SUMMARY_BROKER"
+)
+
+const (
+	MemorySummaryBroker = "memory"
+	RedisSummaryBroker  = "redis"
+)
+
+const (
+	DefaultTrashRetention     = 30 * 24 * time.Hour
+	DefaultTrashPurgeInterval = time.Hour
+
+	DefaultSummaryStaleTimeout  = 30 * time.Minute
+	DefaultSummarySweepInterval = time.Minute
+
+	DefaultSummaryStreamMaxDuration = 9 * time.Second
 )
+
+// GetDuration parses duration (e.g. "720h") from environment variable,
+// returns defaultValue if variable is not set or invalid
+func GetDuration(paramName string, defaultValue time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(paramName))
+	if err != nil || d <= 0 {
+		return defaultValue
+	}
+
+	return d
+}
@@ -1,13 +1,21 @@
 package init
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"os"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/yarikTri/archipelago-notes-api/internal/clients/invitations/email"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/jobs"
 
 	"github.com/go-park-mail-ru/2023_1_Technokaif/pkg/logger"
+	"github.com/yarikTri/archipelago-notes-api/cmd/api/init/config"
 	"github.com/yarikTri/archipelago-notes-api/cmd/api/init/router"
+	"github.com/yarikTri/archipelago-notes-api/cmd/common/init/db/redis"
+
+	auditRepository "github.com/yarikTri/archipelago-notes-api/internal/pkg/audit/repository/postgresql"
 
 	dirsHandler "github.com/yarikTri/archipelago-notes-api/internal/pkg/dirs/delivery/http"
 	dirsRepository "github.com/yarikTri/archipelago-notes-api/internal/pkg/dirs/repository/postgresql"
@@ -21,33 +29,128 @@ import (
 	usersRepository "github.com/yarikTri/archipelago-notes-api/internal/pkg/users/repository/postgresql"
 	usersUsecase "github.com/yarikTri/archipelago-notes-api/internal/pkg/users/usecase"
 
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/summary"
+	memorySummaryBroker "github.com/yarikTri/archipelago-notes-api/internal/pkg/summary/broker/memory"
+	redisSummaryBroker "github.com/yarikTri/archipelago-notes-api/internal/pkg/summary/broker/redis"
 	summaryHandler "github.com/yarikTri/archipelago-notes-api/internal/pkg/summary/delivery/http"
 	summaryRepository "github.com/yarikTri/archipelago-notes-api/internal/pkg/summary/repository/postgresql"
 	summaryUsecase "github.com/yarikTri/archipelago-notes-api/internal/pkg/summary/usecase"
+
+	searchHandler "github.com/yarikTri/archipelago-notes-api/internal/pkg/search/delivery/http"
+	searchRepository "github.com/yarikTri/archipelago-notes-api/internal/pkg/search/repository/postgresql"
+	searchUsecase "github.com/yarikTri/archipelago-notes-api/internal/pkg/search/usecase"
+
+	trashHandler "github.com/yarikTri/archipelago-notes-api/internal/pkg/trash/delivery/http"
+	trashRepository "github.com/yarikTri/archipelago-notes-api/internal/pkg/trash/repository/postgresql"
+	trashUsecase "github.com/yarikTri/archipelago-notes-api/internal/pkg/trash/usecase"
+
+	groupsHandler "github.com/yarikTri/archipelago-notes-api/internal/pkg/groups/delivery/http"
+	groupsRepository "github.com/yarikTri/archipelago-notes-api/internal/pkg/groups/repository/postgresql"
+	groupsUsecase "github.com/yarikTri/archipelago-notes-api/internal/pkg/groups/usecase"
+
+	tasksHandler "github.com/yarikTri/archipelago-notes-api/internal/pkg/tasks/delivery/http"
+	tasksRepository "github.com/yarikTri/archipelago-notes-api/internal/pkg/tasks/repository/postgresql"
+	tasksUsecase "github.com/yarikTri/archipelago-notes-api/internal/pkg/tasks/usecase"
+
+	servicesRepository "github.com/yarikTri/archipelago-notes-api/internal/pkg/services/repository/postgresql"
+	servicesUsecase "github.com/yarikTri/archipelago-notes-api/internal/pkg/services/usecase"
 )
 
-func Init(sqlDBClient *sqlx.DB, logger logger.Logger) (http.Handler, error) {
+// Init builds api handler and launches background jobs, which are stopped with ctx
+func Init(ctx context.Context, sqlDBClient *sqlx.DB, logger logger.Logger) (http.Handler, error) {
 	emailClient := email.NewEmailClient()
 
 	notesRepo := notesRepository.NewPostgreSQL(sqlDBClient)
 	dirsRepo := dirsRepository.NewPostgreSQL(sqlDBClient)
 	usersRepo := usersRepository.NewPostgreSQL(sqlDBClient)
 	summRepo := summaryRepository.NewPostgreSQL(sqlDBClient)
+	searchRepo := searchRepository.NewPostgreSQL(sqlDBClient)
+	trashRepo := trashRepository.NewPostgreSQL(sqlDBClient)
+	groupsRepo := groupsRepository.NewPostgreSQL(sqlDBClient)
+	auditRepo := auditRepository.NewPostgreSQL(sqlDBClient)
+	servicesRepo := servicesRepository.NewPostgreSQL(sqlDBClient)
+	tasksRepo := tasksRepository.NewPostgreSQL(sqlDBClient)
+
+	summBroker, err := initSummaryBroker(ctx, logger)
+	if err != nil {
+		return nil, err
+	}
 
-	notesUsecase := notesUsecase.NewUsecase(notesRepo, usersRepo, emailClient)
-	dirsUsecase := dirsUsecase.NewUsecase(dirsRepo, notesRepo)
+	notesUsecase := notesUsecase.NewUsecase(notesRepo, usersRepo, auditRepo, emailClient, logger)
+	dirsUsecase := dirsUsecase.NewUsecase(dirsRepo, notesRepo, usersRepo, auditRepo, emailClient, logger)
 	usersUsecase := usersUsecase.NewUsecase(usersRepo, emailClient)
-	summaryUsecase := summaryUsecase.NewUsecase(summRepo)
+	summaryUsecase := summaryUsecase.NewUsecase(summRepo, summBroker, notesUsecase, dirsUsecase, logger)
+	searchUsecase := searchUsecase.NewUsecase(searchRepo)
+	trashUsecase := trashUsecase.NewUsecase(trashRepo)
+	groupsUsecase := groupsUsecase.NewUsecase(groupsRepo)
+	servicesUsecase := servicesUsecase.NewUsecase(servicesRepo)
+	tasksUsecase := tasksUsecase.NewUsecase(tasksRepo, notesUsecase, summaryUsecase)
 
 	notesHandler := notesHandler.NewHandler(notesUsecase, logger)
 	dirsHandler := dirsHandler.NewHandler(dirsUsecase, logger)
-	usersHandler := usersHandler.NewHandler(usersUsecase, logger)
-	summaryHandler := summaryHandler.NewHandler(summaryUsecase, logger)
+	usersHandler := usersHandler.NewHandler(usersUsecase, dirsUsecase, logger)
+	summaryStreamMaxDuration := config.GetDuration(config.SummaryStreamMaxDurationParamName, config.DefaultSummaryStreamMaxDuration)
+	summaryHandler := summaryHandler.NewHandler(summaryUsecase, summaryStreamMaxDuration, logger)
+	searchHandler := searchHandler.NewHandler(searchUsecase, logger)
+	trashHandler := trashHandler.NewHandler(trashUsecase, logger)
+	groupsHandler := groupsHandler.NewHandler(groupsUsecase, logger)
+	tasksHandler := tasksHandler.NewHandler(tasksUsecase, logger)
+
+	trashRetention := config.GetDuration(config.TrashRetentionParamName, config.DefaultTrashRetention)
+	trashPurgeInterval := config.GetDuration(config.TrashPurgeIntervalParamName, config.DefaultTrashPurgeInterval)
+	go jobs.RunPeriodically(ctx, trashPurgeInterval, func() {
+		purged, err := trashUsecase.PurgeExpired(trashRetention)
+		if err != nil {
+			logger.Errorf("error while purging trash: %v", err)
+			return
+		}
+		if purged > 0 {
+			logger.Infof("purged %d items from trash", purged)
+		}
+	})
+
+	summaryStaleTimeout := config.GetDuration(config.SummaryStaleTimeoutParamName, config.DefaultSummaryStaleTimeout)
+	summarySweepInterval := config.GetDuration(config.SummarySweepIntervalParamName, config.DefaultSummarySweepInterval)
+	go jobs.RunPeriodically(ctx, summarySweepInterval, func() {
+		abandoned, err := summaryUsecase.AbandonStale(summaryStaleTimeout)
+		if err != nil {
+			logger.Errorf("error while finishing stale summaries: %v", err)
+			return
+		}
+		if abandoned > 0 {
+			logger.Infof("finished %d stale summaries", abandoned)
+		}
+	})
 
 	return router.InitRoutes(
 		notesHandler,
 		dirsHandler,
 		usersHandler,
 		summaryHandler,
+		searchHandler,
+		trashHandler,
+		groupsHandler,
+		tasksHandler,
+		servicesUsecase.Authenticate,
 	), nil
 }
+
+// initSummaryBroker chooses summary events broker by config, redis broker listens to its channel until ctx is done
+func initSummaryBroker(ctx context.Context, logger logger.Logger) (summary.Broker, error) {
+	switch brokerType := os.Getenv(config.SummaryBrokerParamName); brokerType {
+	case "", config.MemorySummaryBroker:
+		return memorySummaryBroker.NewBroker(), nil
+	case config.RedisSummaryBroker:
+		redisDB, err := redis.InitRedisDB()
+		if err != nil {
+			return nil, fmt.Errorf("error while connecting to redis: %v", err)
+		}
+
+		broker := redisSummaryBroker.NewBroker(redisDB, logger)
+		go broker.Run(ctx)
+
+		return broker, nil
+	default:
+		return nil, fmt.Errorf("unknown summary broker: %s", brokerType)
+	}
+}
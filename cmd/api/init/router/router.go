@@ -8,9 +8,14 @@ import (
 	swaggerFiles "github.com/swaggo/files" // swagger embed files
 	swagger "github.com/swaggo/gin-swagger"
 	"github.com/yarikTri/archipelago-notes-api/internal/common/http/middleware"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
 	dirsDelivery "github.com/yarikTri/archipelago-notes-api/internal/pkg/dirs/delivery/http"
+	groupsDelivery "github.com/yarikTri/archipelago-notes-api/internal/pkg/groups/delivery/http"
 	notesDelivery "github.com/yarikTri/archipelago-notes-api/internal/pkg/notes/delivery/http"
+	searchDelivery "github.com/yarikTri/archipelago-notes-api/internal/pkg/search/delivery/http"
 	summaryDelivery "github.com/yarikTri/archipelago-notes-api/internal/pkg/summary/delivery/http"
+	tasksDelivery "github.com/yarikTri/archipelago-notes-api/internal/pkg/tasks/delivery/http"
+	trashDelivery "github.com/yarikTri/archipelago-notes-api/internal/pkg/trash/delivery/http"
 	usersDelivery "github.com/yarikTri/archipelago-notes-api/internal/pkg/users/delivery/http"
 )
 
@@ -19,6 +24,11 @@ func InitRoutes(
 	dirsHandler *dirsDelivery.Handler,
 	usersHandler *usersDelivery.Handler,
 	summaryHandler *summaryDelivery.Handler,
+	searchHandler *searchDelivery.Handler,
+	trashHandler *trashDelivery.Handler,
+	groupsHandler *groupsDelivery.Handler,
+	tasksHandler *tasksDelivery.Handler,
+	authenticateService func(key string) (*models.ServiceClient, error),
 ) *gin.Engine {
 	r := gin.Default()
 
@@ -33,11 +43,22 @@ func InitRoutes(
 	notes.POST("", notesHandler.Create)
 	notes.POST("/:id", notesHandler.Update)
 	notes.DELETE("/:id", notesHandler.Delete)
+	notes.GET("/:id/access", notesHandler.ListAccess)
 	notes.POST("/:id/access/:userID", notesHandler.SetAccess)
+	notes.DELETE("/:id/access/:userID", notesHandler.RemoveAccess)
+	notes.POST("/:id/transfer_ownership/:userID", notesHandler.TransferOwnership)
+	notes.POST("/:id/group_access/:groupID", notesHandler.SetGroupAccess)
+	notes.DELETE("/:id/group_access/:groupID", notesHandler.RemoveGroupAccess)
 	notes.GET("/:id/is_owner/:userID", notesHandler.CheckOwner)
 	notes.POST("/:id/attach_summ/:summID", notesHandler.AttachNoteToSummary)
 	notes.POST("/:id/detach_summ/:summID", notesHandler.DetachNoteFromSummary)
 	notes.GET("/:id/summary_list", notesHandler.GetSummaryListByNote)
+	notes.GET("/:id/history", notesHandler.GetHistory)
+	notes.POST("/:id/history/:revision/revert", notesHandler.Revert)
+	notes.GET("/:id/share_links", notesHandler.ListShareLinks)
+	notes.POST("/:id/share_links", notesHandler.CreateShareLink)
+	notes.DELETE("/:id/share_links/:linkID", notesHandler.RevokeShareLink)
+	notes.GET("/:id/audit", notesHandler.GetAuditLog)
 
 	dirs := api.Group("/dirs")
 	dirs.GET("/:id", dirsHandler.Get)
@@ -45,21 +66,64 @@ func InitRoutes(
 	dirs.POST("", dirsHandler.Create)
 	dirs.POST("/:id", dirsHandler.Update)
 	dirs.DELETE("/:id", dirsHandler.Delete)
+	dirs.GET("/:id/access", dirsHandler.GetAccess)
+	dirs.POST("/:id/access/:userID", dirsHandler.SetAccess)
 
 	users := api.Group("/users")
 	users.GET("/:id", usersHandler.Get)
 	users.GET("", usersHandler.Search)
 	users.POST("/:userID/root_dir/:rootDirID", usersHandler.SetRootDirID)
 	users.POST("/:userID/send_email_confirmation", usersHandler.SendEmailConfirmation)
-	users.POST("/:userID/confirm_email", usersHandler.ConfirmEmail)
+	users.POST("/confirm_email", usersHandler.ConfirmEmail)
+
+	serviceAuth := func(scope string) gin.HandlerFunc {
+		return middleware.ServiceAuthMiddleware(authenticateService, scope)
+	}
 
 	summary := api.Group("/summary")
+	summary.GET("", summaryHandler.List)
 	summary.GET("/get/:id", summaryHandler.GetSummary)
-	summary.GET("/finish/:id", summaryHandler.FinishSummary)
-	summary.POST("/save", summaryHandler.SaveSummaryText)
-	summary.POST("/update_text_role", summaryHandler.UpdateSummaryTextRole)
-	summary.GET("/active", summaryHandler.GetActiveSummaries)
+	summary.GET("/mine", summaryHandler.GetMine)
+	summary.GET("/platforms", summaryHandler.ListPlatforms)
+	summary.GET("/:id/stream", summaryHandler.Stream)
+	summary.GET("/:id/versions", summaryHandler.ListVersions)
+	summary.GET("/:id/versions/diff", summaryHandler.DiffVersions)
+	summary.GET("/:id/versions/:version", summaryHandler.GetVersion)
+	summary.POST("/:id/to_note", summaryHandler.ToNote)
+	summary.GET("/finish/:id", serviceAuth(models.SummaryWriteScope), summaryHandler.FinishSummary)
+	summary.POST("/save", serviceAuth(models.SummaryWriteScope), summaryHandler.SaveSummaryText)
+	summary.POST("/update_text_role", serviceAuth(models.SummaryWriteScope), summaryHandler.UpdateSummaryTextRole)
+	summary.POST("/:id/chunks", serviceAuth(models.SummaryWriteScope), summaryHandler.AppendChunk)
+	summary.GET("/active", serviceAuth(models.SummaryReadActiveScope), summaryHandler.GetActiveSummaries)
 	summary.POST("/update_name", summaryHandler.UpdateName)
+	summary.DELETE("/:id", summaryHandler.Delete)
+
+	api.GET("/search", searchHandler.Search)
+
+	trash := api.Group("/trash")
+	trash.GET("", trashHandler.List)
+	trash.POST("/notes/:id/restore", trashHandler.RestoreNote)
+	trash.POST("/dirs/:id/restore", trashHandler.RestoreDir)
+	trash.DELETE("/notes/:id", trashHandler.PurgeNote)
+	trash.DELETE("/dirs/:id", trashHandler.PurgeDir)
+
+	groups := api.Group("/groups")
+	groups.GET("", groupsHandler.List)
+	groups.POST("", groupsHandler.Create)
+	groups.GET("/:id", groupsHandler.Get)
+	groups.POST("/:id", groupsHandler.Update)
+	groups.DELETE("/:id", groupsHandler.Delete)
+	groups.GET("/:id/members", groupsHandler.ListMembers)
+	groups.POST("/:id/members/:userID", groupsHandler.SetMember)
+	groups.DELETE("/:id/members/:userID", groupsHandler.RemoveMember)
+
+	tasks := api.Group("/tasks")
+	tasks.GET("/mine", tasksHandler.ListMine)
+	tasks.GET("/proposals/:summID", tasksHandler.ExtractFromSummary)
+	tasks.POST("", tasksHandler.Create)
+	tasks.GET("/:id", tasksHandler.Get)
+	tasks.POST("/:id", tasksHandler.Update)
+	tasks.DELETE("/:id", tasksHandler.Delete)
 
 	r.GET("/swagger/*any", swagger.WrapHandler(swaggerFiles.Handler))
 
@@ -44,7 +44,7 @@ func main() {
 		return
 	}
 
-	router, err := app.Init(db, flogger)
+	router, err := app.Init(ctx, db, flogger)
 	if err != nil {
 		flogger.Errorf("error while launching routes: %v", err)
 		return
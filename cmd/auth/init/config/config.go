@@ -1,5 +1,86 @@
 package config
 
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
 const (
 	AuthListenParamName = "AUTH_LISTEN_ENDPOINT"
+
+	// SessionIdleTTLParamName is time unused session expires after
+	SessionIdleTTLParamName = "SESSION_IDLE_TTL"
+
+	// TrustedProxiesParamName is comma separated list of proxies' ips or CIDRs, client ip is taken
+	// from X-Forwarded-For only behind them. If it's not set, no proxy is trusted and peer address is used
+	TrustedProxiesParamName = "TRUSTED_PROXIES"
+
+	// LimiterParamName selects attempts limiter: "redis" (default) or "memory",
+	// the latter keeps limits of every replica separately
+	LimiterParamName = "AUTH_LIMITER"
+
+	LoginFreeAttemptsParamName     = "LOGIN_FREE_ATTEMPTS"
+	LoginIPFreeAttemptsParamName   = "LOGIN_IP_FREE_ATTEMPTS"
+	SignUpIPFreeAttemptsParamName  = "SIGNUP_IP_FREE_ATTEMPTS"
+	TwoFactorFreeAttemptsParamName = "TWO_FACTOR_FREE_ATTEMPTS"
+	LimiterBaseDelayParamName      = "LIMITER_BASE_DELAY"
+	LimiterMaxDelayParamName       = "LIMITER_MAX_DELAY"
+	LimiterWindowParamName         = "LIMITER_WINDOW"
+	LockoutAttemptsParamName       = "LOCKOUT_ATTEMPTS"
+	LockoutDurationParamName       = "LOCKOUT_DURATION"
 )
+
+const (
+	RedisLimiter  = "redis"
+	MemoryLimiter = "memory"
+)
+
+const (
+	DefaultSessionIdleTTL = 14 * 24 * time.Hour
+
+	DefaultLoginFreeAttempts     = 5
+	DefaultLoginIPFreeAttempts   = 20
+	DefaultSignUpIPFreeAttempts  = 5
+	DefaultTwoFactorFreeAttempts = 5
+	DefaultLimiterBaseDelay      = time.Second
+	DefaultLimiterMaxDelay       = 15 * time.Minute
+	DefaultLimiterWindow         = time.Hour
+	DefaultLockoutAttempts       = 10
+	DefaultLockoutDuration       = 30 * time.Minute
+)
+
+// GetDuration parses duration (e.g. "336h") from environment variable,
+// returns defaultValue if variable is not set or invalid
+func GetDuration(paramName string, defaultValue time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(paramName))
+	if err != nil || d <= 0 {
+		return defaultValue
+	}
+
+	return d
+}
+
+// GetInt parses positive integer from environment variable,
+// returns defaultValue if variable is not set or invalid
+func GetInt(paramName string, defaultValue int) int {
+	i, err := strconv.Atoi(os.Getenv(paramName))
+	if err != nil || i <= 0 {
+		return defaultValue
+	}
+
+	return i
+}
+
+// GetList parses comma separated list from environment variable, returns nil if variable is not set
+func GetList(paramName string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(paramName), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
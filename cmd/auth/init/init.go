@@ -2,16 +2,27 @@ package init
 
 import (
 	"fmt"
-	"github.com/yarikTri/archipelago-notes-api/cmd/auth/init/db/redis"
 	"github.com/yarikTri/archipelago-notes-api/cmd/common/init/db/postgresql"
+	"github.com/yarikTri/archipelago-notes-api/cmd/common/init/db/redis"
 	"net/http"
+	"os"
 
 	"github.com/go-park-mail-ru/2023_1_Technokaif/pkg/logger"
+	goRedis "github.com/redis/go-redis/v9"
+	"github.com/yarikTri/archipelago-notes-api/cmd/auth/init/config"
 	"github.com/yarikTri/archipelago-notes-api/cmd/auth/init/router"
+	"github.com/yarikTri/archipelago-notes-api/internal/clients/invitations/email"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	auditRepository "github.com/yarikTri/archipelago-notes-api/internal/pkg/audit/repository/postgresql"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/auth"
 	authDelivery "github.com/yarikTri/archipelago-notes-api/internal/pkg/auth/delivery/http"
+	memoryLimiter "github.com/yarikTri/archipelago-notes-api/internal/pkg/auth/limiter/memory"
+	redisLimiter "github.com/yarikTri/archipelago-notes-api/internal/pkg/auth/limiter/redis"
 	usersRepository "github.com/yarikTri/archipelago-notes-api/internal/pkg/auth/repository/postgresql"
 	sessionsRepository "github.com/yarikTri/archipelago-notes-api/internal/pkg/auth/repository/redis"
 	authUsecase "github.com/yarikTri/archipelago-notes-api/internal/pkg/auth/usecase"
+	usersPkgRepository "github.com/yarikTri/archipelago-notes-api/internal/pkg/users/repository/postgresql"
+	usersUsecase "github.com/yarikTri/archipelago-notes-api/internal/pkg/users/usecase"
 )
 
 func Init(logger logger.Logger) (http.Handler, error) {
@@ -25,12 +36,70 @@ func Init(logger logger.Logger) (http.Handler, error) {
 		return nil, fmt.Errorf("error while connecting to redis: %v", err)
 	}
 
+	emailClient := email.NewEmailClient()
+
+	limiters, err := initLimiters(redisDB)
+	if err != nil {
+		return nil, err
+	}
+
 	usersRepo := usersRepository.NewUsersRepository(postgresqlDB)
+	usersPkgRepo := usersPkgRepository.NewPostgreSQL(postgresqlDB)
 	sessionsRepo := sessionsRepository.NewSessionsRepository(redisDB)
+	auditRepo := auditRepository.NewPostgreSQL(postgresqlDB)
 
-	authUsecase := authUsecase.NewUsecase(sessionsRepo, usersRepo)
+	usersUsecase := usersUsecase.NewUsecase(usersPkgRepo, emailClient)
+	authUsecase := authUsecase.NewUsecase(sessionsRepo, usersRepo, auditRepo, usersUsecase, emailClient, emailClient,
+		limiters, config.GetDuration(config.SessionIdleTTLParamName, config.DefaultSessionIdleTTL), logger)
 
 	authDelivery := authDelivery.NewHandler(authUsecase, logger)
 
-	return router.InitRoutes(authDelivery), nil
+	r, err := router.InitRoutes(authDelivery, config.GetList(config.TrustedProxiesParamName))
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// initLimiters chooses limiters by config, policies are shared by all of them
+func initLimiters(redisDB *goRedis.Client) (auth.Limiters, error) {
+	basePolicy := models.LimitPolicy{
+		BaseDelay: config.GetDuration(config.LimiterBaseDelayParamName, config.DefaultLimiterBaseDelay),
+		MaxDelay:  config.GetDuration(config.LimiterMaxDelayParamName, config.DefaultLimiterMaxDelay),
+		Window:    config.GetDuration(config.LimiterWindowParamName, config.DefaultLimiterWindow),
+	}
+
+	loginByEmailPolicy := basePolicy
+	loginByEmailPolicy.FreeAttempts = config.GetInt(config.LoginFreeAttemptsParamName, config.DefaultLoginFreeAttempts)
+	loginByEmailPolicy.LockoutAttempts = config.GetInt(config.LockoutAttemptsParamName, config.DefaultLockoutAttempts)
+	loginByEmailPolicy.LockoutDuration = config.GetDuration(config.LockoutDurationParamName, config.DefaultLockoutDuration)
+
+	loginByIPPolicy := basePolicy
+	loginByIPPolicy.FreeAttempts = config.GetInt(config.LoginIPFreeAttemptsParamName, config.DefaultLoginIPFreeAttempts)
+
+	signUpByIPPolicy := basePolicy
+	signUpByIPPolicy.FreeAttempts = config.GetInt(config.SignUpIPFreeAttemptsParamName, config.DefaultSignUpIPFreeAttempts)
+
+	twoFactorByUserPolicy := basePolicy
+	twoFactorByUserPolicy.FreeAttempts = config.GetInt(config.TwoFactorFreeAttemptsParamName, config.DefaultTwoFactorFreeAttempts)
+
+	switch limiterType := os.Getenv(config.LimiterParamName); limiterType {
+	case "", config.RedisLimiter:
+		return auth.Limiters{
+			LoginByIP:       redisLimiter.NewLimiter(redisDB, "login_ip", loginByIPPolicy),
+			LoginByEmail:    redisLimiter.NewLimiter(redisDB, "login_email", loginByEmailPolicy),
+			SignUpByIP:      redisLimiter.NewLimiter(redisDB, "signup_ip", signUpByIPPolicy),
+			TwoFactorByUser: redisLimiter.NewLimiter(redisDB, "two_factor_user", twoFactorByUserPolicy),
+		}, nil
+	case config.MemoryLimiter:
+		return auth.Limiters{
+			LoginByIP:       memoryLimiter.NewLimiter(loginByIPPolicy),
+			LoginByEmail:    memoryLimiter.NewLimiter(loginByEmailPolicy),
+			SignUpByIP:      memoryLimiter.NewLimiter(signUpByIPPolicy),
+			TwoFactorByUser: memoryLimiter.NewLimiter(twoFactorByUserPolicy),
+		}, nil
+	default:
+		return auth.Limiters{}, fmt.Errorf("unknown auth limiter: %s", limiterType)
+	}
 }
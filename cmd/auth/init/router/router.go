@@ -1,24 +1,43 @@
 package router
 
 import (
+	"fmt"
+
 	"github.com/gin-gonic/gin"
 
 	"github.com/yarikTri/archipelago-notes-api/internal/common/http/middleware"
 	authDelivery "github.com/yarikTri/archipelago-notes-api/internal/pkg/auth/delivery/http"
 )
 
+// InitRoutes trusts X-Forwarded-For only from trustedProxies, so clients can't spoof ip attempts are limited by
 func InitRoutes(
 	authHandler *authDelivery.Handler,
-) *gin.Engine {
+	trustedProxies []string,
+) (*gin.Engine, error) {
 	r := gin.Default()
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
 
 	r.Use(middleware.CORSMiddleware())
 
 	auth := r.Group("/auth-service")
 	auth.GET("/login", authHandler.CheckSession)
 	auth.POST("/login", authHandler.Login)
+	auth.POST("/login/2fa", authHandler.LoginTwoFactor)
 	auth.POST("/logout", authHandler.Logout)
 	auth.POST("/registration", authHandler.SignUp)
+	auth.POST("/password/forgot", authHandler.ForgotPassword)
+	auth.POST("/password/reset", authHandler.ResetPassword)
+	auth.POST("/password/change", authHandler.ChangePassword)
+	auth.GET("/sessions", authHandler.ListSessions)
+	auth.DELETE("/sessions", authHandler.RevokeOtherSessions)
+	auth.DELETE("/sessions/:id", authHandler.RevokeSession)
+	auth.GET("/2fa", authHandler.GetTwoFactorStatus)
+	auth.POST("/2fa/totp", authHandler.EnrollTOTP)
+	auth.POST("/2fa/totp/confirm", authHandler.ConfirmTOTP)
+	auth.POST("/2fa/recovery_codes", authHandler.RegenerateRecoveryCodes)
+	auth.POST("/2fa/totp/disable", authHandler.DisableTOTP)
 
-	return r
+	return r, nil
 }
@@ -0,0 +1,146 @@
+-- Dirs ownership & default access
+ALTER TABLE dir ADD COLUMN IF NOT EXISTS creator_id UUID REFERENCES "user" (id) ON DELETE SET NULL;
+ALTER TABLE dir ADD COLUMN IF NOT EXISTS default_access VARCHAR(2) DEFAULT 'e' NOT NULL;
+
+-- empty, read, write
+ALTER TABLE dir ADD CONSTRAINT dir_default_access_check CHECK (default_access IN ('e', 'r', 'w'));
+
+-- Users' accesses to dirs table
+CREATE TABLE IF NOT EXISTS dir_access (
+    dir_id  INT         REFERENCES dir (id) ON DELETE CASCADE NOT NULL,
+    user_id UUID        REFERENCES "user" (id) ON DELETE CASCADE NOT NULL,
+    access  VARCHAR(2)  DEFAULT 'r' NOT NULL,
+
+    UNIQUE(dir_id, user_id),
+    -- empty (for black list), read, write, modify, manage access
+    CHECK (access IN ('e', 'r', 'w', 'm', 'ma'))
+);
+
+-- Set default_access of new dir as parent's one
+CREATE OR REPLACE FUNCTION dir_before_insert_inherit_default_access()
+    RETURNS TRIGGER AS $dir_before_insert_inherit_default_access$
+DECLARE
+    parentAccess VARCHAR(2);
+BEGIN
+    -- ! Триггер срабатывает до dir_before_update_insert_check_path, поэтому `path` - путь родителя
+    IF (NEW.path != '') THEN
+        parentAccess := (SELECT default_access FROM dir WHERE path = NEW.path);
+        IF (parentAccess IS NOT NULL) THEN
+            NEW.default_access := parentAccess;
+        END IF;
+    END IF;
+
+    RETURN NEW;
+END;
+$dir_before_insert_inherit_default_access$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tr_dir_before_insert_inherit_default_access
+    BEFORE INSERT ON dir
+    FOR EACH ROW
+    EXECUTE FUNCTION dir_before_insert_inherit_default_access();
+
+-- Set same default_access to all children
+CREATE OR REPLACE FUNCTION dir_after_update_set_children_default_access()
+    RETURNS TRIGGER AS $dir_after_update_set_children_default_access$
+BEGIN
+    IF (NEW.default_access != OLD.default_access) THEN
+        UPDATE dir
+        SET default_access = NEW.default_access
+        WHERE path <@ NEW.path AND id != NEW.id AND default_access != NEW.default_access;
+    END IF;
+
+    RETURN NULL;
+END;
+$dir_after_update_set_children_default_access$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tr_update_children_dir_default_access
+    AFTER UPDATE OF default_access ON dir
+    FOR EACH ROW
+    EXECUTE FUNCTION dir_after_update_set_children_default_access();
+
+-- Accesses ordering: e < r < w < m < ma
+CREATE OR REPLACE FUNCTION access_rank(access VARCHAR(2))
+    RETURNS INT AS $access_rank$
+BEGIN
+    RETURN CASE access
+        WHEN 'e' THEN 1
+        WHEN 'r' THEN 2
+        WHEN 'w' THEN 3
+        WHEN 'm' THEN 4
+        WHEN 'ma' THEN 5
+        ELSE 0
+    END;
+END;
+$access_rank$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Effective user's access to dir:
+--  1. creator of dir or any of its ancestors - manage access
+--  2. access given to user on the nearest dir up the path
+--  3. dir's default access
+CREATE OR REPLACE FUNCTION dir_user_access(dirID INT, userID UUID)
+    RETURNS VARCHAR(2) AS $dir_user_access$
+DECLARE
+    dirPath ltree;
+    dirDefaultAccess VARCHAR(2);
+    userAccess VARCHAR(2);
+BEGIN
+    SELECT path, default_access INTO dirPath, dirDefaultAccess FROM dir WHERE id = dirID;
+    IF (dirPath IS NULL) THEN
+        RETURN NULL;
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM dir WHERE path @> dirPath AND creator_id = userID) THEN
+        RETURN 'ma';
+    END IF;
+
+    userAccess := (
+        SELECT da.access
+        FROM dir_access da INNER JOIN dir d ON da.dir_id = d.id
+        WHERE da.user_id = userID AND d.path @> dirPath
+        ORDER BY nlevel(d.path) DESC
+        LIMIT 1
+    );
+    IF (userAccess IS NOT NULL) THEN
+        RETURN userAccess;
+    END IF;
+
+    RETURN dirDefaultAccess;
+END;
+$dir_user_access$ LANGUAGE plpgsql STABLE;
+
+-- Effective user's access to note:
+--  1. creator of note - manage access
+--  2. access given to user on note
+--  3. the highest of inherited dir access and note's default access
+CREATE OR REPLACE FUNCTION note_user_access(noteID UUID, userID UUID)
+    RETURNS VARCHAR(2) AS $note_user_access$
+DECLARE
+    noteCreatorID UUID;
+    noteDirID INT;
+    noteDefaultAccess VARCHAR(2);
+    userAccess VARCHAR(2);
+    dirAccess VARCHAR(2);
+BEGIN
+    SELECT creator_id, dir_id, default_access INTO noteCreatorID, noteDirID, noteDefaultAccess
+    FROM note WHERE id = noteID;
+    IF (noteCreatorID IS NULL) THEN
+        RETURN NULL;
+    END IF;
+
+    IF (noteCreatorID = userID) THEN
+        RETURN 'ma';
+    END IF;
+
+    userAccess := (SELECT access FROM note_access WHERE note_id = noteID AND user_id = userID);
+    IF (userAccess IS NOT NULL) THEN
+        RETURN userAccess;
+    END IF;
+
+    dirAccess := dir_user_access(noteDirID, userID);
+    IF (access_rank(dirAccess) > access_rank(noteDefaultAccess)) THEN
+        RETURN dirAccess;
+    END IF;
+
+    RETURN noteDefaultAccess;
+END;
+$note_user_access$ LANGUAGE plpgsql STABLE;
@@ -0,0 +1,19 @@
+-- Full-text search vectors, russian & english configurations
+
+ALTER TABLE note ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('russian', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(title, '')), 'A')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS note_search_vector_idx ON note USING gin (search_vector);
+
+ALTER TABLE summ ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('russian', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('russian', coalesce(text, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(text, '')), 'B') ||
+    setweight(to_tsvector('russian', coalesce(text_with_role, '')), 'C') ||
+    setweight(to_tsvector('english', coalesce(text_with_role, '')), 'C')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS summ_search_vector_idx ON summ USING gin (search_vector);
@@ -0,0 +1,14 @@
+-- Soft deletion of notes & dirs
+
+ALTER TABLE note ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+ALTER TABLE note ADD COLUMN IF NOT EXISTS deleted_by UUID REFERENCES "user" (id) ON DELETE SET NULL DEFAULT NULL;
+
+ALTER TABLE dir ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+ALTER TABLE dir ADD COLUMN IF NOT EXISTS deleted_by UUID REFERENCES "user" (id) ON DELETE SET NULL DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS note_deleted_at_idx ON note (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS dir_deleted_at_idx ON dir (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Accesses are purged with note
+ALTER TABLE note_access DROP CONSTRAINT IF EXISTS note_access_note_id_fkey;
+ALTER TABLE note_access ADD CONSTRAINT note_access_note_id_fkey FOREIGN KEY (note_id) REFERENCES note (id) ON DELETE CASCADE;
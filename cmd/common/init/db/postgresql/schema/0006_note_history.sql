@@ -0,0 +1,17 @@
+-- Notes timestamps
+ALTER TABLE note ADD COLUMN IF NOT EXISTS created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL;
+ALTER TABLE note ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL;
+
+-- Notes' revisions: snapshot of note after each create/update
+CREATE TABLE IF NOT EXISTS note_revision (
+    note_id         UUID                        REFERENCES note (id) ON DELETE CASCADE NOT NULL,
+    revision        INT                         NOT NULL,
+    title           VARCHAR(64)                 NOT NULL,
+    dir_id          INT                         NOT NULL,
+    automerge_url   VARCHAR(128)                NOT NULL,
+    default_access  VARCHAR(2)                  NOT NULL,
+    changed_by      UUID                        REFERENCES "user" (id) ON DELETE SET NULL,
+    changed_at      TIMESTAMP WITH TIME ZONE    DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (note_id, revision)
+);
@@ -0,0 +1,17 @@
+-- Capability links to notes
+CREATE TABLE IF NOT EXISTS note_share_link (
+    id              UUID                        PRIMARY KEY DEFAULT uuid_generate_v4(),
+    note_id         UUID                        REFERENCES note (id) ON DELETE CASCADE NOT NULL,
+    token_hash      VARCHAR(64)                 UNIQUE NOT NULL,
+    access          VARCHAR(2)                  DEFAULT 'r' NOT NULL,
+    password_hash   VARCHAR(128)                DEFAULT NULL,
+    expires_at      TIMESTAMP WITH TIME ZONE    DEFAULT NULL,
+    created_by      UUID                        REFERENCES "user" (id) ON DELETE SET NULL,
+    created_at      TIMESTAMP WITH TIME ZONE    DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    revoked_at      TIMESTAMP WITH TIME ZONE    DEFAULT NULL,
+
+    -- read, write
+    CHECK (access IN ('r', 'w'))
+);
+
+CREATE INDEX IF NOT EXISTS note_share_link_note_id_idx ON note_share_link (note_id);
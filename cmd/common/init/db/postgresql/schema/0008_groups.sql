@@ -0,0 +1,80 @@
+-- Users' groups (teams)
+CREATE TABLE IF NOT EXISTS user_group (
+    id          UUID                        PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name        VARCHAR(64)                 NOT NULL,
+    creator_id  UUID                        REFERENCES "user" (id) ON DELETE SET NULL,
+    created_at  TIMESTAMP WITH TIME ZONE    DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS user_group_member (
+    group_id    UUID                        REFERENCES user_group (id) ON DELETE CASCADE NOT NULL,
+    user_id     UUID                        REFERENCES "user" (id) ON DELETE CASCADE NOT NULL,
+    role        VARCHAR(8)                  DEFAULT 'member' NOT NULL,
+    added_at    TIMESTAMP WITH TIME ZONE    DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    UNIQUE (group_id, user_id),
+    -- admin manages group & members
+    CHECK (role IN ('member', 'admin'))
+);
+
+CREATE INDEX IF NOT EXISTS user_group_member_user_id_idx ON user_group_member (user_id);
+
+-- Accesses to notes given to groups
+ALTER TABLE note_access ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE note_access ADD COLUMN IF NOT EXISTS group_id UUID REFERENCES user_group (id) ON DELETE CASCADE DEFAULT NULL;
+ALTER TABLE note_access ADD CONSTRAINT note_access_note_id_group_id_key UNIQUE (note_id, group_id);
+ALTER TABLE note_access ADD CONSTRAINT note_access_principal_check CHECK ((user_id IS NULL) != (group_id IS NULL));
+
+-- Effective user's access to note:
+--  1. creator of note - manage access
+--  2. access given to user on note, if it's empty - user is black listed
+--  3. the highest of accesses given to user and to groups he is member of
+--  4. the highest of inherited dir access and note's default access
+CREATE OR REPLACE FUNCTION note_user_access(noteID UUID, userID UUID)
+    RETURNS VARCHAR(2) AS $note_user_access$
+DECLARE
+    noteCreatorID UUID;
+    noteDirID INT;
+    noteDefaultAccess VARCHAR(2);
+    userAccess VARCHAR(2);
+    groupAccess VARCHAR(2);
+    dirAccess VARCHAR(2);
+BEGIN
+    SELECT creator_id, dir_id, default_access INTO noteCreatorID, noteDirID, noteDefaultAccess
+    FROM note WHERE id = noteID;
+    IF (noteCreatorID IS NULL) THEN
+        RETURN NULL;
+    END IF;
+
+    IF (noteCreatorID = userID) THEN
+        RETURN 'ma';
+    END IF;
+
+    userAccess := (SELECT access FROM note_access WHERE note_id = noteID AND user_id = userID);
+    IF (userAccess = 'e') THEN
+        RETURN userAccess;
+    END IF;
+
+    groupAccess := (
+        SELECT na.access
+        FROM note_access na INNER JOIN user_group_member gm ON na.group_id = gm.group_id
+        WHERE na.note_id = noteID AND gm.user_id = userID
+        ORDER BY access_rank(na.access) DESC
+        LIMIT 1
+    );
+    IF (access_rank(groupAccess) > access_rank(userAccess)) THEN
+        userAccess := groupAccess;
+    END IF;
+
+    IF (userAccess IS NOT NULL) THEN
+        RETURN userAccess;
+    END IF;
+
+    dirAccess := dir_user_access(noteDirID, userID);
+    IF (access_rank(dirAccess) > access_rank(noteDefaultAccess)) THEN
+        RETURN dirAccess;
+    END IF;
+
+    RETURN noteDefaultAccess;
+END;
+$note_user_access$ LANGUAGE plpgsql STABLE;
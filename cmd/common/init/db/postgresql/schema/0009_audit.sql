@@ -0,0 +1,38 @@
+-- Append-only log of changes to notes, dirs, their accesses and users' authentication.
+-- There are no foreign keys: events must outlive their actors and targets
+CREATE TABLE IF NOT EXISTS audit_event (
+    id              BIGSERIAL                   PRIMARY KEY,
+    -- NULL for anonymous users and system
+    actor_id        UUID                        DEFAULT NULL,
+    target_type     VARCHAR(8)                  NOT NULL,
+    target_id       VARCHAR(64)                 NOT NULL,
+    action          VARCHAR(32)                 NOT NULL,
+    -- user, group or share link whose access has been changed
+    principal_type  VARCHAR(8)                  DEFAULT NULL,
+    principal_id    VARCHAR(64)                 DEFAULT NULL,
+    old_value       TEXT                        DEFAULT NULL,
+    new_value       TEXT                        DEFAULT NULL,
+    created_at      TIMESTAMP WITH TIME ZONE    DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    CHECK (target_type IN ('note', 'dir', 'user')),
+    CHECK (principal_type IS NULL OR principal_type IN ('user', 'group', 'link'))
+);
+
+CREATE INDEX IF NOT EXISTS audit_event_target_idx ON audit_event (target_type, target_id, created_at);
+
+CREATE OR REPLACE FUNCTION audit_event_forbid_change()
+    RETURNS TRIGGER AS $audit_event_forbid_change$
+BEGIN
+    RAISE EXCEPTION 'audit_event is append-only';
+END;
+$audit_event_forbid_change$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tr_audit_event_before_update_delete
+    BEFORE UPDATE OR DELETE ON audit_event
+    FOR EACH ROW
+    EXECUTE FUNCTION audit_event_forbid_change();
+
+CREATE TRIGGER tr_audit_event_before_truncate
+    BEFORE TRUNCATE ON audit_event
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION audit_event_forbid_change();
@@ -0,0 +1,3 @@
+-- Who and when gave access to note
+ALTER TABLE note_access ADD COLUMN IF NOT EXISTS granted_by UUID REFERENCES "user" (id) ON DELETE SET NULL DEFAULT NULL;
+ALTER TABLE note_access ADD COLUMN IF NOT EXISTS granted_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL;
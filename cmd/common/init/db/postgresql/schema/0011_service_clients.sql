@@ -0,0 +1,22 @@
+-- Microservices (ML, Telegram bot) calling api
+CREATE TABLE IF NOT EXISTS service_client (
+    id          UUID                        PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name        VARCHAR(32)                 UNIQUE NOT NULL,
+    -- e.g. summary:write, summary:read_active
+    scopes      VARCHAR(32)[]               DEFAULT '{}' NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE    DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    disabled_at TIMESTAMP WITH TIME ZONE    DEFAULT NULL
+);
+
+-- API keys of service clients, only sha256 hash of key's secret is stored.
+-- Client may have several valid keys during rotation
+CREATE TABLE IF NOT EXISTS service_key (
+    id          UUID                        PRIMARY KEY DEFAULT uuid_generate_v4(),
+    client_id   UUID                        REFERENCES service_client (id) ON DELETE CASCADE NOT NULL,
+    key_hash    VARCHAR(64)                 NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE    DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    expires_at  TIMESTAMP WITH TIME ZONE    DEFAULT NULL,
+    revoked_at  TIMESTAMP WITH TIME ZONE    DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS service_key_client_id_idx ON service_key (client_id);
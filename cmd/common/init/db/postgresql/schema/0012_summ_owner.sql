@@ -0,0 +1,34 @@
+-- User who recorded summary, NULL for summaries recorded before owners were introduced
+ALTER TABLE summ ADD COLUMN IF NOT EXISTS creator_id UUID REFERENCES "user" (id) ON DELETE SET NULL DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS summ_creator_id_idx ON summ (creator_id);
+
+-- Effective user's access to summary:
+--  1. creator of summary - manage access
+--  2. the highest of user's accesses to notes summary is attached to
+CREATE OR REPLACE FUNCTION summ_user_access(summID UUID, userID UUID)
+    RETURNS VARCHAR(2) AS $summ_user_access$
+DECLARE
+    summCreatorID UUID;
+    noteAccess VARCHAR(2);
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM summ WHERE id = summID) THEN
+        RETURN NULL;
+    END IF;
+
+    SELECT creator_id INTO summCreatorID FROM summ WHERE id = summID;
+    IF (summCreatorID = userID) THEN
+        RETURN 'ma';
+    END IF;
+
+    noteAccess := (
+        SELECT note_user_access(n.id, userID) AS access
+        FROM summ_to_note sn INNER JOIN note n ON sn.note_id = n.id
+        WHERE sn.summ_id = summID AND n.deleted_at IS NULL
+        ORDER BY access_rank(note_user_access(n.id, userID)) DESC
+        LIMIT 1
+    );
+
+    RETURN COALESCE(noteAccess, 'e');
+END;
+$summ_user_access$ LANGUAGE plpgsql STABLE;
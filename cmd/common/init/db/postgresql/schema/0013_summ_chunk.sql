@@ -0,0 +1,24 @@
+-- Append-only transcript chunks of summary, summ.text holds them assembled in seq order
+CREATE TABLE IF NOT EXISTS summ_chunk (
+    summ_id     UUID                        REFERENCES summ (id) ON DELETE CASCADE NOT NULL,
+    -- Sequence number of chunk starting from 1, chunks are accepted only in order without gaps
+    seq         INT                         NOT NULL CHECK (seq > 0),
+    text        TEXT                        NOT NULL,
+    -- Time chunk was recorded at, set by client
+    recorded_at TIMESTAMP WITH TIME ZONE    DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE    DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (summ_id, seq)
+);
+
+-- Chunks are deleted only with their summary
+CREATE OR REPLACE FUNCTION summ_chunk_forbid_update()
+    RETURNS TRIGGER AS $summ_chunk_forbid_update$
+BEGIN
+    RAISE EXCEPTION 'summ_chunk is append-only';
+END;
+$summ_chunk_forbid_update$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tr_summ_chunk_before_update
+    BEFORE UPDATE ON summ_chunk
+    FOR EACH ROW
+    EXECUTE FUNCTION summ_chunk_forbid_update();
@@ -0,0 +1,71 @@
+-- Renditions of summary: base text per detalization (kept up to date while recording)
+-- and every role-specific rewrite of it
+CREATE TABLE IF NOT EXISTS summ_version (
+    summ_id         UUID                        REFERENCES summ (id) ON DELETE CASCADE NOT NULL,
+    version         INT                         NOT NULL,
+    kind            VARCHAR(4)                  NOT NULL CHECK (kind IN ('base', 'role')),
+    detalization    INT,
+    -- Empty for base versions
+    role            VARCHAR(30)                 DEFAULT '' NOT NULL,
+    text            TEXT                        DEFAULT '' NOT NULL,
+    created_at      TIMESTAMP WITH TIME ZONE    DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at      TIMESTAMP WITH TIME ZONE    DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (summ_id, version)
+);
+
+CREATE OR REPLACE FUNCTION next_summ_version(summID UUID)
+    RETURNS INT AS $next_summ_version$
+BEGIN
+    RETURN (SELECT COALESCE(MAX(version), 0) + 1 FROM summ_version WHERE summ_id = summID);
+END;
+$next_summ_version$ LANGUAGE plpgsql;
+
+-- Keeps summ_version in sync with summ. Summary row is locked by the triggering statement,
+-- so versions of one summary are numbered without races
+CREATE OR REPLACE FUNCTION summ_save_version()
+    RETURNS TRIGGER AS $summ_save_version$
+BEGIN
+    IF (TG_OP = 'INSERT' OR
+        NEW.text IS DISTINCT FROM OLD.text OR
+        NEW.detalization IS DISTINCT FROM OLD.detalization) THEN
+
+        UPDATE summ_version
+            SET text = COALESCE(NEW.text, ''), updated_at = CURRENT_TIMESTAMP
+            WHERE summ_id = NEW.id AND kind = 'base' AND detalization IS NOT DISTINCT FROM NEW.detalization;
+
+        IF NOT FOUND THEN
+            INSERT INTO summ_version (summ_id, version, kind, detalization, text)
+                VALUES (NEW.id, next_summ_version(NEW.id), 'base', NEW.detalization, COALESCE(NEW.text, ''));
+        END IF;
+    END IF;
+
+    IF (COALESCE(NEW.text_with_role, '') <> '' AND
+        (TG_OP = 'INSERT' OR
+         NEW.text_with_role IS DISTINCT FROM OLD.text_with_role OR
+         NEW.role IS DISTINCT FROM OLD.role)) THEN
+
+        INSERT INTO summ_version (summ_id, version, kind, detalization, role, text)
+            VALUES (NEW.id, next_summ_version(NEW.id), 'role', NEW.detalization, COALESCE(NEW.role, ''), NEW.text_with_role);
+    END IF;
+
+    RETURN NEW;
+END;
+$summ_save_version$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tr_summ_after_insert_update
+    AFTER INSERT OR UPDATE ON summ
+    FOR EACH ROW
+    EXECUTE FUNCTION summ_save_version();
+
+-- Versions of summaries recorded before
+INSERT INTO summ_version (summ_id, version, kind, detalization, text, created_at, updated_at)
+    SELECT id, 1, 'base', detalization, COALESCE(text, ''), started_at, started_at
+    FROM summ
+ON CONFLICT DO NOTHING;
+
+INSERT INTO summ_version (summ_id, version, kind, detalization, role, text, created_at, updated_at)
+    SELECT id, 2, 'role', detalization, COALESCE(role, ''), text_with_role, started_at, started_at
+    FROM summ
+    WHERE COALESCE(text_with_role, '') <> ''
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,43 @@
+-- Summary lifecycle:
+--   recording - recorder sends text
+--   finishing - recording is stopped, final text is being prepared
+--   done      - finished by recorder
+--   failed    - recorder reported failure
+--   abandoned - finished by sweeper after no updates for too long
+ALTER TABLE summ ADD COLUMN IF NOT EXISTS status VARCHAR(16) DEFAULT 'recording' NOT NULL
+    CHECK (status IN ('recording', 'finishing', 'done', 'failed', 'abandoned'));
+ALTER TABLE summ ADD COLUMN IF NOT EXISTS finish_reason TEXT DEFAULT NULL;
+ALTER TABLE summ ADD COLUMN IF NOT EXISTS finished_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+ALTER TABLE summ ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL;
+
+-- Active summaries count as updated now, so sweeper doesn't abandon them right after migration
+UPDATE summ
+    SET status = CASE WHEN active THEN 'recording' ELSE 'done' END,
+        finished_at = CASE WHEN active THEN NULL ELSE started_at END,
+        updated_at = CASE WHEN active THEN CURRENT_TIMESTAMP ELSE started_at END;
+
+ALTER TABLE summ DROP COLUMN IF EXISTS active;
+
+CREATE INDEX IF NOT EXISTS summ_active_updated_at_idx ON summ (updated_at)
+    WHERE status IN ('recording', 'finishing');
+
+-- Any change of summary's text is an update, sweeper relies on it
+CREATE OR REPLACE FUNCTION summ_touch_updated_at()
+    RETURNS TRIGGER AS $summ_touch_updated_at$
+BEGIN
+    IF (NEW.text IS DISTINCT FROM OLD.text OR
+        NEW.text_with_role IS DISTINCT FROM OLD.text_with_role OR
+        NEW.role IS DISTINCT FROM OLD.role OR
+        NEW.status IS DISTINCT FROM OLD.status) THEN
+
+        NEW.updated_at := CURRENT_TIMESTAMP;
+    END IF;
+
+    RETURN NEW;
+END;
+$summ_touch_updated_at$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tr_summ_before_update
+    BEFORE UPDATE ON summ
+    FOR EACH ROW
+    EXECUTE FUNCTION summ_touch_updated_at();
@@ -0,0 +1,58 @@
+-- Action items of meetings, may be extracted from summary text
+CREATE TABLE IF NOT EXISTS task (
+    id              UUID                        PRIMARY KEY DEFAULT uuid_generate_v4(),
+    title           VARCHAR(256)                NOT NULL,
+    description     TEXT                        DEFAULT '' NOT NULL,
+    status          VARCHAR(16)                 DEFAULT 'open' NOT NULL
+        CHECK (status IN ('open', 'in_progress', 'done', 'cancelled')),
+    assignee_id     UUID                        REFERENCES "user" (id) ON DELETE SET NULL DEFAULT NULL,
+    due_date        DATE                        DEFAULT NULL,
+    -- Source summary and note of task
+    summ_id         UUID                        REFERENCES summ (id) ON DELETE SET NULL DEFAULT NULL,
+    note_id         UUID                        REFERENCES note (id) ON DELETE SET NULL DEFAULT NULL,
+    creator_id      UUID                        REFERENCES "user" (id) ON DELETE SET NULL DEFAULT NULL,
+    created_at      TIMESTAMP WITH TIME ZONE    DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at      TIMESTAMP WITH TIME ZONE    DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS task_open_assignee_id_idx ON task (assignee_id) WHERE status IN ('open', 'in_progress');
+CREATE INDEX IF NOT EXISTS task_summ_id_idx ON task (summ_id);
+CREATE INDEX IF NOT EXISTS task_note_id_idx ON task (note_id);
+
+-- Effective user's access to task:
+--  1. creator of task - manage access
+--  2. the highest of: write for assignee, access to source note, access to source summary
+CREATE OR REPLACE FUNCTION task_user_access(taskID UUID, userID UUID)
+    RETURNS VARCHAR(2) AS $task_user_access$
+DECLARE
+    taskCreatorID UUID;
+    taskAssigneeID UUID;
+    taskNoteID UUID;
+    taskSummID UUID;
+    accesses VARCHAR(2)[] := ARRAY['e'];
+BEGIN
+    SELECT creator_id, assignee_id, note_id, summ_id INTO taskCreatorID, taskAssigneeID, taskNoteID, taskSummID
+    FROM task WHERE id = taskID;
+    IF NOT FOUND THEN
+        RETURN NULL;
+    END IF;
+
+    IF (taskCreatorID = userID) THEN
+        RETURN 'ma';
+    END IF;
+
+    IF (taskAssigneeID = userID) THEN
+        accesses := accesses || 'w'::VARCHAR(2);
+    END IF;
+
+    IF (taskNoteID IS NOT NULL AND EXISTS (SELECT 1 FROM note WHERE id = taskNoteID AND deleted_at IS NULL)) THEN
+        accesses := accesses || note_user_access(taskNoteID, userID);
+    END IF;
+
+    IF (taskSummID IS NOT NULL) THEN
+        accesses := accesses || summ_user_access(taskSummID, userID);
+    END IF;
+
+    RETURN (SELECT a FROM unnest(accesses) AS a ORDER BY access_rank(a) DESC LIMIT 1);
+END;
+$task_user_access$ LANGUAGE plpgsql STABLE;
@@ -0,0 +1,31 @@
+-- Platforms are canonical codes of registry, see models.Platform.
+-- Spellings used before registry are converted, unknown ones are treated as uploads
+UPDATE summ SET platform = CASE lower(trim(COALESCE(platform, '')))
+    WHEN 'zoom' THEN 'zoom'
+    WHEN 'google meet' THEN 'google_meet'
+    WHEN 'google_meet' THEN 'google_meet'
+    WHEN 'googlemeet' THEN 'google_meet'
+    WHEN 'meet' THEN 'google_meet'
+    WHEN 'gmeet' THEN 'google_meet'
+    WHEN 'telemost' THEN 'telemost'
+    WHEN 'yandex telemost' THEN 'telemost'
+    WHEN 'телемост' THEN 'telemost'
+    WHEN 'яндекс телемост' THEN 'telemost'
+    WHEN 'telegram' THEN 'telegram'
+    WHEN 'tg' THEN 'telegram'
+    WHEN 'телеграм' THEN 'telegram'
+    ELSE 'upload'
+END;
+
+ALTER TABLE summ ALTER COLUMN platform SET DEFAULT 'upload';
+ALTER TABLE summ ALTER COLUMN platform SET NOT NULL;
+ALTER TABLE summ ADD CONSTRAINT summ_platform_check
+    CHECK (platform IN ('zoom', 'google_meet', 'telemost', 'telegram', 'upload'));
+
+-- Platform's metadata of meeting
+ALTER TABLE summ ADD COLUMN IF NOT EXISTS meeting_url TEXT DEFAULT NULL;
+ALTER TABLE summ ADD COLUMN IF NOT EXISTS external_meeting_id VARCHAR(128) DEFAULT NULL;
+ALTER TABLE summ ADD COLUMN IF NOT EXISTS participants TEXT[] DEFAULT '{}' NOT NULL;
+ALTER TABLE summ ADD COLUMN IF NOT EXISTS duration_seconds INT DEFAULT NULL CHECK (duration_seconds >= 0);
+
+CREATE INDEX IF NOT EXISTS summ_platform_idx ON summ (platform);
@@ -0,0 +1,18 @@
+-- Links of deleted summaries and notes were never cleaned up
+DELETE FROM summ_to_note
+WHERE summ_id IS NULL OR note_id IS NULL
+    OR summ_id NOT IN (SELECT id FROM summ)
+    OR note_id NOT IN (SELECT id FROM note);
+
+ALTER TABLE summ_to_note ALTER COLUMN summ_id SET NOT NULL;
+ALTER TABLE summ_to_note ALTER COLUMN note_id SET NOT NULL;
+
+ALTER TABLE summ_to_note ADD CONSTRAINT summ_to_note_summ_id_fkey
+    FOREIGN KEY (summ_id) REFERENCES summ (id) ON DELETE CASCADE;
+ALTER TABLE summ_to_note ADD CONSTRAINT summ_to_note_note_id_fkey
+    FOREIGN KEY (note_id) REFERENCES note (id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS summ_to_note_note_id_idx ON summ_to_note (note_id);
+
+-- Summaries are listed by start time
+CREATE INDEX IF NOT EXISTS summ_started_at_idx ON summ (started_at DESC, id DESC);
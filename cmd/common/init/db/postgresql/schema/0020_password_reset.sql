@@ -0,0 +1,11 @@
+-- Password reset tokens, only sha256 of token sent by email is stored
+CREATE TABLE IF NOT EXISTS password_reset (
+    id              UUID                        PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id         UUID                        REFERENCES "user" (id) ON DELETE CASCADE NOT NULL,
+    token_hash      VARCHAR(64)                 UNIQUE NOT NULL,
+    created_at      TIMESTAMP WITH TIME ZONE    DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    expires_at      TIMESTAMP WITH TIME ZONE    NOT NULL,
+    used_at         TIMESTAMP WITH TIME ZONE    DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS password_reset_user_id_idx ON password_reset (user_id);
@@ -0,0 +1,19 @@
+-- TOTP second factor, secret is pending until user confirms it with the first code.
+-- last_used_step keeps accepted code from being used twice
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id         UUID                        PRIMARY KEY REFERENCES "user" (id) ON DELETE CASCADE,
+    secret          VARCHAR(64)                 NOT NULL,
+    created_at      TIMESTAMP WITH TIME ZONE    DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    enabled_at      TIMESTAMP WITH TIME ZONE    DEFAULT NULL,
+    last_used_step  BIGINT                      DEFAULT 0 NOT NULL
+);
+
+-- Single-use recovery codes, only sha256 of code shown to user is stored
+CREATE TABLE IF NOT EXISTS totp_recovery_code (
+    id              UUID                        PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id         UUID                        REFERENCES "user" (id) ON DELETE CASCADE NOT NULL,
+    code_hash       VARCHAR(64)                 NOT NULL,
+    created_at      TIMESTAMP WITH TIME ZONE    DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    used_at         TIMESTAMP WITH TIME ZONE    DEFAULT NULL,
+    UNIQUE (user_id, code_hash)
+);
@@ -0,0 +1,54 @@
+-- Effective user's access to note:
+--  1. creator of note - manage access
+--  2. access given to user on note, if it's empty - user is black listed
+--  3. the highest of accesses given to user and to groups he is member of,
+--     inherited dir access and note's default access, so grant on note never lowers access from dir
+CREATE OR REPLACE FUNCTION note_user_access(noteID UUID, userID UUID)
+    RETURNS VARCHAR(2) AS $note_user_access$
+DECLARE
+    noteCreatorID UUID;
+    noteDirID INT;
+    noteDefaultAccess VARCHAR(2);
+    userAccess VARCHAR(2);
+    groupAccess VARCHAR(2);
+    dirAccess VARCHAR(2);
+    access VARCHAR(2);
+BEGIN
+    SELECT creator_id, dir_id, default_access INTO noteCreatorID, noteDirID, noteDefaultAccess
+    FROM note WHERE id = noteID;
+    IF (noteCreatorID IS NULL) THEN
+        RETURN NULL;
+    END IF;
+
+    IF (noteCreatorID = userID) THEN
+        RETURN 'ma';
+    END IF;
+
+    userAccess := (SELECT na.access FROM note_access na WHERE na.note_id = noteID AND na.user_id = userID);
+    IF (userAccess = 'e') THEN
+        RETURN userAccess;
+    END IF;
+
+    groupAccess := (
+        SELECT na.access
+        FROM note_access na INNER JOIN user_group_member gm ON na.group_id = gm.group_id
+        WHERE na.note_id = noteID AND gm.user_id = userID
+        ORDER BY access_rank(na.access) DESC
+        LIMIT 1
+    );
+    dirAccess := dir_user_access(noteDirID, userID);
+
+    access := noteDefaultAccess;
+    IF (access_rank(dirAccess) > access_rank(access)) THEN
+        access := dirAccess;
+    END IF;
+    IF (access_rank(groupAccess) > access_rank(access)) THEN
+        access := groupAccess;
+    END IF;
+    IF (access_rank(userAccess) > access_rank(access)) THEN
+        access := userAccess;
+    END IF;
+
+    RETURN access;
+END;
+$note_user_access$ LANGUAGE plpgsql STABLE;
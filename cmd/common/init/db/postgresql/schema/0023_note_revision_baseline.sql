@@ -0,0 +1,5 @@
+-- Baseline revisions of notes created before history was kept, so they can be reverted to the original state
+INSERT INTO note_revision (note_id, revision, title, dir_id, automerge_url, default_access, changed_by, changed_at)
+SELECT n.id, 1, n.title, n.dir_id, n.automerge_url, n.default_access, n.creator_id, n.updated_at
+FROM note n
+WHERE NOT EXISTS (SELECT 1 FROM note_revision r WHERE r.note_id = n.id);
@@ -0,0 +1,11 @@
+-- Summaries recorded before owners were introduced are owned by creator of the earliest note they are attached to.
+-- Summaries not attached to any note are left without owner, so nobody can attach them
+UPDATE summ s
+SET creator_id = (
+    SELECT n.creator_id
+    FROM summ_to_note sn INNER JOIN note n ON sn.note_id = n.id
+    WHERE sn.summ_id = s.id
+    ORDER BY n.created_at
+    LIMIT 1
+)
+WHERE s.creator_id IS NULL;
@@ -0,0 +1,138 @@
+// Command servicekeys manages credentials of services (ML, Telegram bot) calling api:
+//
+//	servicekeys create -name ml -scopes summary:write,summary:read_active
+//	servicekeys list
+//	servicekeys keys -name ml
+//	servicekeys rotate -name ml -grace 24h
+//	servicekeys revoke -key <key id>
+//	servicekeys scopes -name ml -scopes summary:write
+//	servicekeys disable -name ml
+//
+// API keys are printed only once, services pass them in X-Service-Key header
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/joho/godotenv" // load environment
+
+	"github.com/yarikTri/archipelago-notes-api/cmd/common/init/db/postgresql"
+	servicesRepository "github.com/yarikTri/archipelago-notes-api/internal/pkg/services/repository/postgresql"
+	servicesUsecase "github.com/yarikTri/archipelago-notes-api/internal/pkg/services/usecase"
+)
+
+const defaultGracePeriod = 24 * time.Hour
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatalf("usage: servicekeys create|list|keys|rotate|revoke|scopes|disable [flags]")
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	name := flags.String("name", "", "service client name")
+	scopes := flags.String("scopes", "", "comma separated scopes")
+	grace := flags.Duration("grace", defaultGracePeriod, "validity period of previous keys after rotation")
+	keyID := flags.String("key", "", "key id")
+	if err := flags.Parse(os.Args[2:]); err != nil {
+		log.Fatalf("invalid flags: %v", err)
+	}
+
+	db, err := postgresql.InitPostgresDB()
+	if err != nil {
+		log.Fatalf("error while connecting to database: %v", err)
+	}
+
+	usecase := servicesUsecase.NewUsecase(servicesRepository.NewPostgreSQL(db))
+
+	switch os.Args[1] {
+	case "create":
+		client, key, err := usecase.CreateClient(*name, splitScopes(*scopes))
+		if err != nil {
+			log.Fatalf("error while creating service client: %v", err)
+		}
+		fmt.Printf("client %s (%s) created with scopes %v\nkey: %s\n", client.Name, client.ID, client.Scopes, key)
+
+	case "list":
+		clients, err := usecase.ListClients()
+		if err != nil {
+			log.Fatalf("error while listing service clients: %v", err)
+		}
+		for _, client := range clients {
+			status := "active"
+			if client.DisabledAt != nil {
+				status = "disabled"
+			}
+			fmt.Printf("%s\t%s\t%s\t%s\n", client.ID, client.Name, strings.Join(client.Scopes, ","), status)
+		}
+
+	case "keys":
+		keys, err := usecase.ListKeys(*name)
+		if err != nil {
+			log.Fatalf("error while listing keys: %v", err)
+		}
+		now := time.Now()
+		for _, key := range keys {
+			expiresAt := "never"
+			if key.ExpiresAt != nil {
+				expiresAt = key.ExpiresAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%s\tcreated %s\texpires %s\tactive %t\n",
+				key.ID, key.CreatedAt.Format(time.RFC3339), expiresAt, key.IsActive(now))
+		}
+
+	case "rotate":
+		key, err := usecase.RotateKey(*name, *grace)
+		if err != nil {
+			log.Fatalf("error while rotating key: %v", err)
+		}
+		fmt.Printf("previous keys of %s expire in %s\nkey: %s\n", *name, grace.String(), key)
+
+	case "revoke":
+		id, err := uuid.FromString(*keyID)
+		if err != nil {
+			log.Fatalf("invalid key id '%s': %v", *keyID, err)
+		}
+		if err := usecase.RevokeKey(id); err != nil {
+			log.Fatalf("error while revoking key: %v", err)
+		}
+		fmt.Printf("key %s revoked\n", id)
+
+	case "scopes":
+		if err := usecase.SetClientScopes(*name, splitScopes(*scopes)); err != nil {
+			log.Fatalf("error while setting scopes: %v", err)
+		}
+		fmt.Printf("scopes of %s set\n", *name)
+
+	case "disable":
+		if err := usecase.DisableClient(*name); err != nil {
+			log.Fatalf("error while disabling service client: %v", err)
+		}
+		fmt.Printf("client %s disabled\n", *name)
+
+	default:
+		log.Fatalf("unknown command %s", os.Args[1])
+	}
+}
+
+func splitScopes(scopes string) []string {
+	result := make([]string, 0)
+	for _, scope := range strings.Split(scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			result = append(result, scope)
+		}
+	}
+
+	return result
+}
+
+func init() {
+	if err := godotenv.Load(); err != nil {
+		log.Fatalf("error while loading environment: %v", err)
+	}
+}
@@ -3,7 +3,9 @@ package email
 import (
 	"fmt"
 	"net/smtp"
+	"net/url"
 	"os"
+	"time"
 )
 
 type IEmailInvitationClient interface {
@@ -11,7 +13,15 @@ type IEmailInvitationClient interface {
 }
 
 type IEmailConfirmationClient interface {
-	SendConfirmation(to string, userID string) error
+	SendConfirmation(to string, token string) error
+}
+
+type IEmailPasswordResetClient interface {
+	SendPasswordReset(to string, token string) error
+}
+
+type IEmailLockoutNoticeClient interface {
+	SendLockoutNotice(to string, lockout time.Duration) error
 }
 
 type EmailClient struct {
@@ -66,8 +76,12 @@ func (vt *InvitationType) toLink(resoureID string) string {
 	return fmt.Sprintf("%s/%s/%s", os.Getenv("SCHEME_AND_HOST"), subpath, resoureID)
 }
 
-func toConfirmationLink(userID string) string {
-	return fmt.Sprintf("%s?confirm_email_user_id=%s", os.Getenv("SCHEME_AND_HOST"), userID)
+func toConfirmationLink(token string) string {
+	return fmt.Sprintf("%s?confirm_email_token=%s", os.Getenv("SCHEME_AND_HOST"), url.QueryEscape(token))
+}
+
+func toPasswordResetLink(token string) string {
+	return fmt.Sprintf("%s?reset_password_token=%s", os.Getenv("SCHEME_AND_HOST"), url.QueryEscape(token))
 }
 
 func (s *EmailClient) SendInvitation(to string, visitType InvitationType, resourceID string) error {
@@ -85,7 +99,7 @@ func (s *EmailClient) SendInvitation(to string, visitType InvitationType, resour
 	return smtp.SendMail(s.Endpoint, s.Auth, s.From, []string{to}, []byte(msg))
 }
 
-func (s *EmailClient) SendConfirmation(to string, userID string) error {
+func (s *EmailClient) SendConfirmation(to string, token string) error {
 	msg := fmt.Sprintf(
 		"Subject: Подтверждение почты\n"+
 			"MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"+
@@ -96,7 +110,40 @@ func (s *EmailClient) SendConfirmation(to string, userID string) error {
 			"<a href=\"%s\">Ссылка для подтверждения почты</a>"+
 			"<br/><br/>"+
 			"Есть вопросы? Свяжись с нами в телеграме: @yarik_tri или @rbeketov",
-		toConfirmationLink(userID),
+		toConfirmationLink(token),
+	)
+
+	return smtp.SendMail(s.Endpoint, s.Auth, s.From, []string{to}, []byte(msg))
+}
+
+func (s *EmailClient) SendPasswordReset(to string, token string) error {
+	msg := fmt.Sprintf(
+		"Subject: Восстановление пароля\n"+
+			"MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"+
+			"Привет! Это команда Archipelago!<br/>"+
+			"Кто-то запросил восстановление пароля от твоего аккаунта<br/>"+
+			"Чтобы задать новый пароль, перейди по ссылке снизу:"+
+			"<br/><br/>"+
+			"<a href=\"%s\">Ссылка для восстановления пароля</a>"+
+			"<br/><br/>"+
+			"Если это был не ты, просто проигнорируй это письмо<br/>"+
+			"Есть вопросы? Свяжись с нами в телеграме: @yarik_tri или @rbeketov",
+		toPasswordResetLink(token),
+	)
+
+	return smtp.SendMail(s.Endpoint, s.Auth, s.From, []string{to}, []byte(msg))
+}
+
+func (s *EmailClient) SendLockoutNotice(to string, lockout time.Duration) error {
+	msg := fmt.Sprintf(
+		"Subject: Вход в аккаунт заблокирован\n"+
+			"MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"+
+			"Привет! Это команда Archipelago!<br/>"+
+			"Кто-то много раз ввёл неверный пароль от твоего аккаунта, поэтому вход заблокирован на %d мин.<br/>"+
+			"Если это был не ты, рекомендуем восстановить пароль после разблокировки<br/>"+
+			"<br/>"+
+			"Есть вопросы? Свяжись с нами в телеграме: @yarik_tri или @rbeketov",
+		int(lockout.Minutes()),
 	)
 
 	return smtp.SendMail(s.Endpoint, s.Auth, s.From, []string{to}, []byte(msg))
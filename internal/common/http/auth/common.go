@@ -13,3 +13,9 @@ func GetSessionID(c *gin.Context) (string, error) {
 func GetUserId(c *gin.Context) (uuid.UUID, error) {
 	return uuid.FromString(c.GetHeader(commonHttp.UserIdHeader))
 }
+
+// GetShareToken returns note share link token and its password from headers,
+// token isn't accepted in query, so it doesn't get to access logs and Referer
+func GetShareToken(c *gin.Context) (string, string) {
+	return c.GetHeader(commonHttp.ShareTokenHeader), c.GetHeader(commonHttp.SharePasswordHeader)
+}
@@ -2,3 +2,8 @@ package http
 
 const SessionIdCookieName = "auth_token"
 const UserIdHeader = "X-User-Id"
+
+const ShareTokenHeader = "X-Share-Token"
+const SharePasswordHeader = "X-Share-Password"
+
+const ServiceKeyHeader = "X-Service-Key"
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	commonHttp "github.com/yarikTri/archipelago-notes-api/internal/common/http/constants"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
+
+// ServiceAuthMiddleware lets through only requests of service clients allowed the scope.
+// API key is taken from X-Service-Key header
+func ServiceAuthMiddleware(authenticate func(key string) (*models.ServiceClient, error), scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(commonHttp.ServiceKeyHeader)
+		if key == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, "")
+			return
+		}
+
+		client, err := authenticate(key)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, "")
+			return
+		}
+
+		if !client.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, "Forbidden")
+			return
+		}
+
+		c.Next()
+	}
+}
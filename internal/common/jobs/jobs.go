@@ -0,0 +1,21 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// RunPeriodically calls job every interval until ctx is done
+func RunPeriodically(ctx context.Context, interval time.Duration, job func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			job()
+		}
+	}
+}
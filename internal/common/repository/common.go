@@ -4,6 +4,9 @@ import (
 	"fmt"
 )
 
+// ForeignKeyViolationCode is pq error code of foreign key violation
+const ForeignKeyViolationCode = "23503"
+
 type NotFoundError struct {
 	ID any
 }
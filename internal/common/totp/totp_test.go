@@ -0,0 +1,121 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is base32 of ASCII "12345678901234567890", the SHA-1 key of RFC 6238 Appendix B
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	// RFC lists 8 digits codes, 6 digits code is their suffix
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+		{unix: 20000000000, code: "353130"},
+	}
+
+	for _, tt := range tests {
+		code, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code at %d: unexpected error: %v", tt.unix, err)
+		}
+		if code != tt.code {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, code, tt.code)
+		}
+	}
+}
+
+func TestCodeLowercaseSecret(t *testing.T) {
+	code, err := Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", Step(time.Unix(59, 0)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != "287082" {
+		t.Errorf("Code = %s, want 287082", code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	codeAt := func(step int64) string {
+		code, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatalf("Code: unexpected error: %v", err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		wantOK   bool
+		wantStep int64
+	}{
+		{name: "current step", code: codeAt(current), skew: 0, wantOK: true, wantStep: current},
+		{name: "surrounding spaces", code: " " + codeAt(current) + " ", skew: 0, wantOK: true, wantStep: current},
+		{name: "previous step within skew", code: codeAt(current - 1), skew: 1, wantOK: true, wantStep: current - 1},
+		{name: "next step within skew", code: codeAt(current + 1), skew: 1, wantOK: true, wantStep: current + 1},
+		{name: "previous step without skew", code: codeAt(current - 1), skew: 0, wantOK: false},
+		{name: "step beyond skew", code: codeAt(current - 2), skew: 1, wantOK: false},
+		{name: "wrong code", code: "000000", skew: 1, wantOK: false},
+		{name: "short code", code: codeAt(current)[:5], skew: 1, wantOK: false},
+		{name: "long code", code: codeAt(current) + "0", skew: 1, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok, err := Validate(rfcSecret, tt.code, now, tt.skew)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && step != tt.wantStep {
+				t.Errorf("step = %d, want %d", step, tt.wantStep)
+			}
+		})
+	}
+}
+
+// Code is still valid on the next step within skew, so Validate must return step it was issued for:
+// caller rejects reuse by comparing it with the last used step
+func TestValidateReuseReturnsIssuedStep(t *testing.T) {
+	issuedAt := time.Unix(1234567890, 0)
+	issued := Step(issuedAt)
+
+	code, err := Code(rfcSecret, issued)
+	if err != nil {
+		t.Fatalf("Code: unexpected error: %v", err)
+	}
+
+	for _, at := range []time.Time{issuedAt, issuedAt.Add(stepDuration)} {
+		step, ok, err := Validate(rfcSecret, code, at, 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !ok {
+			t.Fatalf("code issued at step %d isn't accepted at step %d", issued, Step(at))
+		}
+		if step != issued {
+			t.Errorf("step = %d, want %d", step, issued)
+		}
+	}
+}
+
+func TestValidateInvalidSecret(t *testing.T) {
+	if _, _, err := Validate("not base32!", "123456", time.Now(), 1); err == nil {
+		t.Error("expected error for invalid secret")
+	}
+}
@@ -0,0 +1,110 @@
+package utils
+
+import "strings"
+
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = "equal"
+	DiffInsert DiffOp = "insert"
+	DiffDelete DiffOp = "delete"
+)
+
+type DiffLine struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
+// maxDiffCells limits memory and time of LCS table, which is quadratic in number of changed lines
+const maxDiffCells = 4_000_000
+
+// DiffLines returns line-by-line diff turning a into b, based on longest common subsequence.
+// Common prefix and suffix are matched first, if changed middle part is still too large
+// it's diffed as deleted and inserted entirely, so the diff stays correct though not minimal
+func DiffLines(a, b string) []DiffLine {
+	aLines := splitLines(a)
+	bLines := splitLines(b)
+
+	prefix := 0
+	for prefix < len(aLines) && prefix < len(bLines) && aLines[prefix] == bLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(aLines)-prefix && suffix < len(bLines)-prefix &&
+		aLines[len(aLines)-1-suffix] == bLines[len(bLines)-1-suffix] {
+		suffix++
+	}
+
+	diff := make([]DiffLine, 0, len(aLines)+len(bLines)-prefix-suffix)
+	for _, line := range aLines[:prefix] {
+		diff = append(diff, DiffLine{Op: DiffEqual, Text: line})
+	}
+	diff = append(diff, diffMiddle(aLines[prefix:len(aLines)-suffix], bLines[prefix:len(bLines)-suffix])...)
+	for _, line := range aLines[len(aLines)-suffix:] {
+		diff = append(diff, DiffLine{Op: DiffEqual, Text: line})
+	}
+
+	return diff
+}
+
+func diffMiddle(aLines, bLines []string) []DiffLine {
+	diff := make([]DiffLine, 0, len(aLines)+len(bLines))
+	if (len(aLines)+1)*(len(bLines)+1) > maxDiffCells {
+		for _, line := range aLines {
+			diff = append(diff, DiffLine{Op: DiffDelete, Text: line})
+		}
+		for _, line := range bLines {
+			diff = append(diff, DiffLine{Op: DiffInsert, Text: line})
+		}
+		return diff
+	}
+
+	// lcs[i][j] is length of LCS of aLines[i:] and bLines[j:]
+	lcs := make([][]int, len(aLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bLines)+1)
+	}
+	for i := len(aLines) - 1; i >= 0; i-- {
+		for j := len(bLines) - 1; j >= 0; j-- {
+			if aLines[i] == bLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(aLines) && j < len(bLines) {
+		switch {
+		case aLines[i] == bLines[j]:
+			diff = append(diff, DiffLine{Op: DiffEqual, Text: aLines[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Op: DiffDelete, Text: aLines[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: DiffInsert, Text: bLines[j]})
+			j++
+		}
+	}
+	for ; i < len(aLines); i++ {
+		diff = append(diff, DiffLine{Op: DiffDelete, Text: aLines[i]})
+	}
+	for ; j < len(bLines); j++ {
+		diff = append(diff, DiffLine{Op: DiffInsert, Text: bLines[j]})
+	}
+
+	return diff
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
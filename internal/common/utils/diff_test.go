@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	eq := func(text string) DiffLine { return DiffLine{Op: DiffEqual, Text: text} }
+	ins := func(text string) DiffLine { return DiffLine{Op: DiffInsert, Text: text} }
+	del := func(text string) DiffLine { return DiffLine{Op: DiffDelete, Text: text} }
+
+	tests := []struct {
+		name string
+		a, b string
+		want []DiffLine
+	}{
+		{name: "both empty", a: "", b: "", want: []DiffLine{}},
+		{name: "identical", a: "a\nb", b: "a\nb", want: []DiffLine{eq("a"), eq("b")}},
+		{name: "insert into empty", a: "", b: "a\nb", want: []DiffLine{ins("a"), ins("b")}},
+		{name: "delete everything", a: "a\nb", b: "", want: []DiffLine{del("a"), del("b")}},
+		{name: "insert in the middle", a: "a\nc", b: "a\nb\nc", want: []DiffLine{eq("a"), ins("b"), eq("c")}},
+		{name: "delete in the middle", a: "a\nb\nc", b: "a\nc", want: []DiffLine{eq("a"), del("b"), eq("c")}},
+		{name: "replace line", a: "a\nb\nc", b: "a\nx\nc", want: []DiffLine{eq("a"), del("b"), ins("x"), eq("c")}},
+		{
+			name: "change between changes",
+			a:    "a\nb\nc\nd",
+			b:    "b\nc\nx\nd",
+			want: []DiffLine{del("a"), eq("b"), eq("c"), ins("x"), eq("d")},
+		},
+		{name: "trailing newline added", a: "a\nb", b: "a\nb\n", want: []DiffLine{eq("a"), eq("b"), ins("")}},
+		{name: "trailing newline removed", a: "a\nb\n", b: "a\nb", want: []DiffLine{eq("a"), eq("b"), del("")}},
+		{name: "crlf equals lf", a: "a\r\nb", b: "a\nb", want: []DiffLine{eq("a"), eq("b")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffLines(tt.a, tt.b)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffLines(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+			checkDiffApplies(t, got, tt.a, tt.b)
+		})
+	}
+}
+
+// Too large changed part is diffed as deleted and inserted entirely
+func TestDiffLinesLargeChange(t *testing.T) {
+	aLines := make([]string, 0, 2100)
+	bLines := make([]string, 0, 2100)
+	for i := 0; i < 2100; i++ {
+		aLines = append(aLines, fmt.Sprintf("a%d", i))
+		bLines = append(bLines, fmt.Sprintf("b%d", i))
+	}
+	a := "head\n" + strings.Join(aLines, "\n") + "\ntail"
+	b := "head\n" + strings.Join(bLines, "\n") + "\ntail"
+
+	diff := DiffLines(a, b)
+	if len(diff) != 2+len(aLines)+len(bLines) {
+		t.Fatalf("len(diff) = %d, want %d", len(diff), 2+len(aLines)+len(bLines))
+	}
+	if diff[0] != (DiffLine{Op: DiffEqual, Text: "head"}) || diff[len(diff)-1] != (DiffLine{Op: DiffEqual, Text: "tail"}) {
+		t.Errorf("common prefix and suffix aren't matched: %v ... %v", diff[0], diff[len(diff)-1])
+	}
+	for i, line := range diff[1 : 1+len(aLines)] {
+		if line.Op != DiffDelete {
+			t.Fatalf("line %d = %v, want deleted", i+1, line)
+		}
+	}
+	checkDiffApplies(t, diff, a, b)
+}
+
+// checkDiffApplies checks that equal and deleted lines make a, equal and inserted lines make b
+func checkDiffApplies(t *testing.T, diff []DiffLine, a, b string) {
+	t.Helper()
+
+	var gotA, gotB []string
+	for _, line := range diff {
+		if line.Op != DiffInsert {
+			gotA = append(gotA, line.Text)
+		}
+		if line.Op != DiffDelete {
+			gotB = append(gotB, line.Text)
+		}
+	}
+
+	if !reflect.DeepEqual(gotA, splitLines(a)) {
+		t.Errorf("diff doesn't turn back into a: %q", gotA)
+	}
+	if !reflect.DeepEqual(gotB, splitLines(b)) {
+		t.Errorf("diff doesn't turn into b: %q", gotB)
+	}
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+const (
+	NoteAuditTarget = "note"
+	DirAuditTarget  = "dir"
+	UserAuditTarget = "user"
+)
+
+const (
+	UserAuditPrincipal  = "user"
+	GroupAuditPrincipal = "group"
+	LinkAuditPrincipal  = "link"
+)
+
+const (
+	CreatedAuditAction              = "created"
+	RenamedAuditAction              = "renamed"
+	MovedAuditAction                = "moved"
+	DefaultAccessChangedAuditAction = "default_access_changed"
+	DeletedAuditAction              = "deleted"
+	AccessSetAuditAction            = "access_set"
+	AccessRemovedAuditAction        = "access_removed"
+	OwnerTransferredAuditAction     = "owner_transferred"
+	ShareLinkCreatedAuditAction     = "share_link_created"
+	ShareLinkRevokedAuditAction     = "share_link_revoked"
+	SignedUpAuditAction             = "signed_up"
+	LoggedInAuditAction             = "logged_in"
+	LoginFailedAuditAction          = "login_failed"
+	LoggedOutAuditAction            = "logged_out"
+	PasswordResetAuditAction        = "password_reset"
+	PasswordChangedAuditAction      = "password_changed"
+	SessionRevokedAuditAction       = "session_revoked"
+	LockedOutAuditAction            = "locked_out"
+	TwoFactorEnabledAuditAction     = "two_factor_enabled"
+	TwoFactorDisabledAuditAction    = "two_factor_disabled"
+	RecoveryCodeUsedAuditAction     = "recovery_code_used"
+)
+
+type AuditEvent struct {
+	ID            int64      `db:"id"`
+	ActorID       *uuid.UUID `db:"actor_id"`
+	TargetType    string     `db:"target_type"`
+	TargetID      string     `db:"target_id"`
+	Action        string     `db:"action"`
+	PrincipalType *string    `db:"principal_type"`
+	PrincipalID   *string    `db:"principal_id"`
+	OldValue      *string    `db:"old_value"`
+	NewValue      *string    `db:"new_value"`
+	CreatedAt     time.Time  `db:"created_at"`
+}
+
+// NewAuditEvent creates event of action on target, uuid.Nil actor means anonymous user
+func NewAuditEvent(actorID uuid.UUID, targetType, targetID, action string) *AuditEvent {
+	event := &AuditEvent{
+		TargetType: targetType,
+		TargetID:   targetID,
+		Action:     action,
+	}
+	if actorID != uuid.Nil {
+		event.ActorID = &actorID
+	}
+
+	return event
+}
+
+func (ae *AuditEvent) WithPrincipal(principalType, principalID string) *AuditEvent {
+	ae.PrincipalType = &principalType
+	ae.PrincipalID = &principalID
+	return ae
+}
+
+// WithValues sets old and new values, empty value is stored as NULL
+func (ae *AuditEvent) WithValues(oldValue, newValue string) *AuditEvent {
+	if oldValue != "" {
+		ae.OldValue = &oldValue
+	}
+	if newValue != "" {
+		ae.NewValue = &newValue
+	}
+	return ae
+}
+
+func (ae *AuditEvent) ToTransfer() *AuditEventTransfer {
+	var actorID *string
+	if ae.ActorID != nil {
+		id := ae.ActorID.String()
+		actorID = &id
+	}
+
+	return &AuditEventTransfer{
+		ID:            ae.ID,
+		ActorID:       actorID,
+		TargetType:    ae.TargetType,
+		TargetID:      ae.TargetID,
+		Action:        ae.Action,
+		PrincipalType: ae.PrincipalType,
+		PrincipalID:   ae.PrincipalID,
+		OldValue:      ae.OldValue,
+		NewValue:      ae.NewValue,
+		CreatedAt:     ae.CreatedAt,
+	}
+}
+
+type AuditEventTransfer struct {
+	ID            int64     `json:"id"`
+	ActorID       *string   `json:"actor_id"`
+	TargetType    string    `json:"target_type"`
+	TargetID      string    `json:"target_id"`
+	Action        string    `json:"action"`
+	PrincipalType *string   `json:"principal_type"`
+	PrincipalID   *string   `json:"principal_id"`
+	OldValue      *string   `json:"old_value"`
+	NewValue      *string   `json:"new_value"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -2,12 +2,19 @@ package models
 
 import (
 	"strconv"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
 )
 
 type Dir struct {
-	ID   int    `json:"id" db:"id"`
-	Name string `json:"name" db:"name"`
-	Path string `json:"subpath" db:"subpath"`
+	ID            int        `json:"id" db:"id"`
+	Name          string     `json:"name" db:"name"`
+	Path          string     `json:"subpath" db:"subpath"`
+	CreatorID     *uuid.UUID `json:"creator_id" db:"creator_id"`
+	DefaultAccess string     `json:"default_access" db:"default_access"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	DeletedBy     *uuid.UUID `json:"deleted_by,omitempty" db:"deleted_by"`
 }
 
 type DirTree struct {
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+type GroupRole uint8
+
+const (
+	UndefinedGroupRole GroupRole = iota
+	MemberGroupRole
+	AdminGroupRole
+)
+
+func GroupRoleFromString(role string) GroupRole {
+	switch role {
+	case "member":
+		return MemberGroupRole
+	case "admin":
+		return AdminGroupRole
+	}
+
+	return UndefinedGroupRole
+}
+
+func (gr *GroupRole) String() string {
+	switch *gr {
+	case MemberGroupRole:
+		return "member"
+	case AdminGroupRole:
+		return "admin"
+	}
+
+	return ""
+}
+
+type Group struct {
+	ID        uuid.UUID  `db:"id"`
+	Name      string     `db:"name"`
+	CreatorID *uuid.UUID `db:"creator_id"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+func (g *Group) ToTransfer() *GroupTransfer {
+	var creatorID *string
+	if g.CreatorID != nil {
+		id := g.CreatorID.String()
+		creatorID = &id
+	}
+
+	return &GroupTransfer{
+		ID:        g.ID.String(),
+		Name:      g.Name,
+		CreatorID: creatorID,
+		CreatedAt: g.CreatedAt,
+	}
+}
+
+type GroupTransfer struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatorID *string   `json:"creator_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GroupMember struct {
+	GroupID uuid.UUID `db:"group_id"`
+	UserID  uuid.UUID `db:"user_id"`
+	Email   string    `db:"email"`
+	Name    string    `db:"name"`
+	Role    string    `db:"role"`
+	AddedAt time.Time `db:"added_at"`
+}
+
+func (gm *GroupMember) ToTransfer() *GroupMemberTransfer {
+	return &GroupMemberTransfer{
+		GroupID: gm.GroupID.String(),
+		UserID:  gm.UserID.String(),
+		Email:   gm.Email,
+		Name:    gm.Name,
+		Role:    gm.Role,
+		AddedAt: gm.AddedAt,
+	}
+}
+
+type GroupMemberTransfer struct {
+	GroupID string    `json:"group_id"`
+	UserID  string    `json:"user_id"`
+	Email   string    `json:"email"`
+	Name    string    `json:"name"`
+	Role    string    `json:"role"`
+	AddedAt time.Time `json:"added_at"`
+}
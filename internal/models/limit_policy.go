@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// LimitPolicy describes how attempts of one key are throttled:
+// after free attempts every next one is delayed twice longer than previous,
+// and after lockout attempts key is locked out for lockout duration
+type LimitPolicy struct {
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	// LockoutAttempts is number of attempts key is locked out after, zero disables lockout
+	LockoutAttempts int
+	LockoutDuration time.Duration
+	// Window is time without attempts after which attempts are forgotten
+	Window time.Duration
+}
+
+// Delay returns time key is blocked for after its attempts
+func (p LimitPolicy) Delay(attempts int) time.Duration {
+	if p.LockoutAttempts > 0 && attempts >= p.LockoutAttempts {
+		return p.LockoutDuration
+	}
+	if attempts <= p.FreeAttempts {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	return delay
+}
+
+// IsLockout reports whether attempt has just locked key out
+func (p LimitPolicy) IsLockout(attempts int) bool {
+	return p.LockoutAttempts > 0 && attempts == p.LockoutAttempts
+}
+
+// LimitHit is result of registered attempt.
+// RetryAfter of blocked attempt is time left until block ends, otherwise it's delay before the next attempt
+type LimitHit struct {
+	Attempts   int
+	RetryAfter time.Duration
+	LockedOut  bool
+	// Blocked attempt is made while key is blocked, it isn't counted and must be rejected
+	Blocked bool
+}
+
+func NewLimitHit(policy LimitPolicy, attempts int) LimitHit {
+	return LimitHit{
+		Attempts:   attempts,
+		RetryAfter: policy.Delay(attempts),
+		LockedOut:  policy.IsLockout(attempts),
+	}
+}
+
+func NewBlockedLimitHit(attempts int, retryAfter time.Duration) LimitHit {
+	return LimitHit{
+		Attempts:   attempts,
+		RetryAfter: retryAfter,
+		Blocked:    true,
+	}
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimitPolicyDelay(t *testing.T) {
+	policy := LimitPolicy{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        8 * time.Second,
+		LockoutAttempts: 10,
+		LockoutDuration: 30 * time.Minute,
+	}
+	noLockout := policy
+	noLockout.LockoutAttempts = 0
+	unevenMax := policy
+	unevenMax.MaxDelay = 5 * time.Second
+
+	tests := []struct {
+		name     string
+		policy   LimitPolicy
+		attempts int
+		want     time.Duration
+	}{
+		{"no attempts", policy, 0, 0},
+		{"last free attempt", policy, 3, 0},
+		{"first delayed attempt", policy, 4, time.Second},
+		{"delay doubles", policy, 5, 2 * time.Second},
+		{"delay doubles again", policy, 6, 4 * time.Second},
+		{"delay reaches max", policy, 7, 8 * time.Second},
+		{"delay is capped", policy, 9, 8 * time.Second},
+		{"lockout", policy, 10, 30 * time.Minute},
+		{"after lockout", policy, 11, 30 * time.Minute},
+		{"lockout disabled", noLockout, 100, 8 * time.Second},
+		{"max isn't power of two", unevenMax, 7, 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Delay(tt.attempts); got != tt.want {
+				t.Errorf("Delay(%d) = %s, want %s", tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitPolicyIsLockout(t *testing.T) {
+	policy := LimitPolicy{LockoutAttempts: 10, LockoutDuration: time.Minute}
+
+	tests := []struct {
+		name     string
+		policy   LimitPolicy
+		attempts int
+		want     bool
+	}{
+		{"before lockout", policy, 9, false},
+		{"locking attempt", policy, 10, true},
+		{"after lockout", policy, 11, false},
+		{"lockout disabled", LimitPolicy{}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.IsLockout(tt.attempts); got != tt.want {
+				t.Errorf("IsLockout(%d) = %v, want %v", tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
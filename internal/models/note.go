@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"github.com/gofrs/uuid/v5"
 )
 
@@ -50,13 +52,17 @@ func (na *NoteAccess) String() string {
 }
 
 type Note struct {
-	ID            uuid.UUID `db:"id"`
-	DirID         int       `db:"dir_id"`
-	Title         string    `db:"title"`
-	AutomergeURL  string    `db:"automerge_url"`
-	CreatorID     uuid.UUID `db:"creator_id"`
-	DefaultAccess string    `db:"default_access"`
-	Access        *string   `db:"access"`
+	ID            uuid.UUID  `db:"id"`
+	DirID         int        `db:"dir_id"`
+	Title         string     `db:"title"`
+	AutomergeURL  string     `db:"automerge_url"`
+	CreatorID     uuid.UUID  `db:"creator_id"`
+	DefaultAccess string     `db:"default_access"`
+	Access        *string    `db:"access"`
+	CreatedAt     time.Time  `db:"created_at"`
+	UpdatedAt     time.Time  `db:"updated_at"`
+	DeletedAt     *time.Time `db:"deleted_at"`
+	DeletedBy     *uuid.UUID `db:"deleted_by"`
 }
 
 func (n *Note) ToTransfer(allowedMethods []string) *NoteTransfer {
@@ -67,18 +73,24 @@ func (n *Note) ToTransfer(allowedMethods []string) *NoteTransfer {
 		Title:         n.Title,
 		CreatorID:     n.CreatorID.String(),
 		DefaultAccess: n.DefaultAccess,
+		CreatedAt:     n.CreatedAt,
+		UpdatedAt:     n.UpdatedAt,
+		DeletedAt:     n.DeletedAt,
 
 		AllowedMethods: allowedMethods,
 	}
 }
 
 type NoteTransfer struct {
-	ID            string `json:"id"`
-	DirID         int    `json:"dir_id"`
-	Title         string `json:"title"`
-	AutomergeURL  string `json:"automerge_url"`
-	CreatorID     string `json:"creator_id"`
-	DefaultAccess string `json:"default_access"`
+	ID            string     `json:"id"`
+	DirID         int        `json:"dir_id"`
+	Title         string     `json:"title"`
+	AutomergeURL  string     `json:"automerge_url"`
+	CreatorID     string     `json:"creator_id"`
+	DefaultAccess string     `json:"default_access"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
 
 	AllowedMethods []string `json:"allowed_methods"`
 }
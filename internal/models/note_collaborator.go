@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// NoteCollaborator is user with access given directly to him on note
+type NoteCollaborator struct {
+	User
+	Access    string     `db:"access"`
+	GrantedBy *uuid.UUID `db:"granted_by"`
+	GrantedAt time.Time  `db:"granted_at"`
+}
+
+func (nc *NoteCollaborator) ToTransfer() *NoteCollaboratorTransfer {
+	return &NoteCollaboratorTransfer{
+		User:      nc.User.ToTransfer(),
+		Access:    nc.Access,
+		GrantedBy: uuidToNullableString(nc.GrantedBy),
+		GrantedAt: nc.GrantedAt,
+	}
+}
+
+type NoteCollaboratorTransfer struct {
+	User      *UserTransfer `json:"user"`
+	Access    string        `json:"access"`
+	GrantedBy *string       `json:"granted_by"`
+	GrantedAt time.Time     `json:"granted_at"`
+}
+
+// NoteGroupCollaborator is group with access given to it on note
+type NoteGroupCollaborator struct {
+	Group
+	Access    string     `db:"access"`
+	GrantedBy *uuid.UUID `db:"granted_by"`
+	GrantedAt time.Time  `db:"granted_at"`
+}
+
+func (ngc *NoteGroupCollaborator) ToTransfer() *NoteGroupCollaboratorTransfer {
+	return &NoteGroupCollaboratorTransfer{
+		Group:     ngc.Group.ToTransfer(),
+		Access:    ngc.Access,
+		GrantedBy: uuidToNullableString(ngc.GrantedBy),
+		GrantedAt: ngc.GrantedAt,
+	}
+}
+
+type NoteGroupCollaboratorTransfer struct {
+	Group     *GroupTransfer `json:"group"`
+	Access    string         `json:"access"`
+	GrantedBy *string        `json:"granted_by"`
+	GrantedAt time.Time      `json:"granted_at"`
+}
+
+func uuidToNullableString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+
+	idStr := id.String()
+	return &idStr
+}
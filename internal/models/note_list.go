@@ -0,0 +1,119 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+type NoteSortField uint8
+
+const (
+	UpdatedNoteSortField NoteSortField = iota
+	CreatedNoteSortField
+	TitleNoteSortField
+)
+
+func NoteSortFieldFromString(field string) (NoteSortField, error) {
+	switch field {
+	case "", "updated":
+		return UpdatedNoteSortField, nil
+	case "created":
+		return CreatedNoteSortField, nil
+	case "title":
+		return TitleNoteSortField, nil
+	}
+
+	return UpdatedNoteSortField, errors.New("invalid sort field " + field)
+}
+
+func (f NoteSortField) String() string {
+	switch f {
+	case UpdatedNoteSortField:
+		return "updated"
+	case CreatedNoteSortField:
+		return "created"
+	case TitleNoteSortField:
+		return "title"
+	}
+
+	return "updated"
+}
+
+type NoteOwnership uint8
+
+const (
+	AnyNoteOwnership NoteOwnership = iota
+	OwnedNoteOwnership
+	SharedNoteOwnership
+)
+
+func NoteOwnershipFromString(ownership string) (NoteOwnership, error) {
+	switch ownership {
+	case "":
+		return AnyNoteOwnership, nil
+	case "owned":
+		return OwnedNoteOwnership, nil
+	case "shared":
+		return SharedNoteOwnership, nil
+	}
+
+	return AnyNoteOwnership, errors.New("invalid ownership " + ownership)
+}
+
+// NoteListCursor points to the last note of page
+type NoteListCursor struct {
+	SortField NoteSortField `json:"f"`
+	Title     string        `json:"t,omitempty"`
+	Time      time.Time     `json:"ts,omitempty"`
+	ID        uuid.UUID     `json:"id"`
+}
+
+func NewNoteListCursor(sortField NoteSortField, lastNote *Note) *NoteListCursor {
+	cursor := NoteListCursor{SortField: sortField, ID: lastNote.ID}
+
+	switch sortField {
+	case TitleNoteSortField:
+		cursor.Title = lastNote.Title
+	case CreatedNoteSortField:
+		cursor.Time = lastNote.CreatedAt
+	case UpdatedNoteSortField:
+		cursor.Time = lastNote.UpdatedAt
+	}
+
+	return &cursor
+}
+
+func (c *NoteListCursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeNoteListCursor(encoded string) (*NoteListCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var cursor NoteListCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &cursor, nil
+}
+
+type NoteListOptions struct {
+	SortField NoteSortField
+	SortDesc  bool
+	Limit     int
+	Cursor    *NoteListCursor
+
+	DirID      *int
+	CreatorID  *uuid.UUID
+	Ownership  NoteOwnership
+	HasSummary *bool
+}
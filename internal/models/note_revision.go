@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+type NoteRevision struct {
+	NoteID        uuid.UUID  `db:"note_id"`
+	Revision      int        `db:"revision"`
+	Title         string     `db:"title"`
+	DirID         int        `db:"dir_id"`
+	AutomergeURL  string     `db:"automerge_url"`
+	DefaultAccess string     `db:"default_access"`
+	ChangedBy     *uuid.UUID `db:"changed_by"`
+	ChangedAt     time.Time  `db:"changed_at"`
+}
+
+func (nr *NoteRevision) ToTransfer() *NoteRevisionTransfer {
+	var changedBy *string
+	if nr.ChangedBy != nil {
+		id := nr.ChangedBy.String()
+		changedBy = &id
+	}
+
+	return &NoteRevisionTransfer{
+		NoteID:        nr.NoteID.String(),
+		Revision:      nr.Revision,
+		Title:         nr.Title,
+		DirID:         nr.DirID,
+		AutomergeURL:  nr.AutomergeURL,
+		DefaultAccess: nr.DefaultAccess,
+		ChangedBy:     changedBy,
+		ChangedAt:     nr.ChangedAt,
+	}
+}
+
+type NoteRevisionTransfer struct {
+	NoteID        string    `json:"note_id"`
+	Revision      int       `json:"revision"`
+	Title         string    `json:"title"`
+	DirID         int       `json:"dir_id"`
+	AutomergeURL  string    `json:"automerge_url"`
+	DefaultAccess string    `json:"default_access"`
+	ChangedBy     *string   `json:"changed_by"`
+	ChangedAt     time.Time `json:"changed_at"`
+}
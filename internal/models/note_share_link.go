@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+type NoteShareLink struct {
+	ID           uuid.UUID  `db:"id"`
+	NoteID       uuid.UUID  `db:"note_id"`
+	Access       string     `db:"access"`
+	PasswordHash *string    `db:"password_hash"`
+	ExpiresAt    *time.Time `db:"expires_at"`
+	CreatedBy    *uuid.UUID `db:"created_by"`
+	CreatedAt    time.Time  `db:"created_at"`
+	RevokedAt    *time.Time `db:"revoked_at"`
+}
+
+// IsActive reports whether link is neither revoked nor expired
+func (l *NoteShareLink) IsActive(now time.Time) bool {
+	return l.RevokedAt == nil && (l.ExpiresAt == nil || now.Before(*l.ExpiresAt))
+}
+
+func (l *NoteShareLink) ToTransfer() *NoteShareLinkTransfer {
+	var createdBy *string
+	if l.CreatedBy != nil {
+		id := l.CreatedBy.String()
+		createdBy = &id
+	}
+
+	return &NoteShareLinkTransfer{
+		ID:          l.ID.String(),
+		NoteID:      l.NoteID.String(),
+		Access:      l.Access,
+		HasPassword: l.PasswordHash != nil,
+		ExpiresAt:   l.ExpiresAt,
+		CreatedBy:   createdBy,
+		CreatedAt:   l.CreatedAt,
+		RevokedAt:   l.RevokedAt,
+	}
+}
+
+type NoteShareLinkTransfer struct {
+	ID          string     `json:"id"`
+	NoteID      string     `json:"note_id"`
+	Token       string     `json:"token,omitempty"`
+	Access      string     `json:"access"`
+	HasPassword bool       `json:"has_password"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CreatedBy   *string    `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+}
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Platform is canonical code of platform summary was recorded on
+type Platform string
+
+const (
+	ZoomPlatform       Platform = "zoom"
+	GoogleMeetPlatform Platform = "google_meet"
+	TelemostPlatform   Platform = "telemost"
+	TelegramPlatform   Platform = "telegram"
+	UploadPlatform     Platform = "upload"
+)
+
+const (
+	externalMeetingIDMaxLength = 128
+	participantsMaxCount       = 500
+	participantMaxLength       = 256
+)
+
+type platformInfo struct {
+	title string
+	// meetingHosts are hosts (with subdomains) of meeting links, platform without them has no links
+	meetingHosts []string
+	// aliases are spellings clients used before registry, compared in lower case
+	aliases []string
+}
+
+var platformsRegistry = map[Platform]platformInfo{
+	ZoomPlatform: {
+		title:        "Zoom",
+		meetingHosts: []string{"zoom.us", "zoom.com"},
+		aliases:      []string{"zoom"},
+	},
+	GoogleMeetPlatform: {
+		title:        "Google Meet",
+		meetingHosts: []string{"meet.google.com"},
+		aliases:      []string{"google meet", "google_meet", "googlemeet", "meet", "gmeet"},
+	},
+	TelemostPlatform: {
+		title:        "Телемост",
+		meetingHosts: []string{"telemost.yandex.ru", "telemost.yandex.com"},
+		aliases:      []string{"telemost", "yandex telemost", "телемост", "яндекс телемост"},
+	},
+	TelegramPlatform: {
+		title:        "Telegram",
+		meetingHosts: []string{"t.me", "telegram.me"},
+		aliases:      []string{"telegram", "tg", "телеграм"},
+	},
+	UploadPlatform: {
+		title:   "Загрузка файла",
+		aliases: []string{"upload", "file", "загрузка"},
+	},
+}
+
+// PlatformFromString resolves canonical platform by its code or any known spelling
+func PlatformFromString(platform string) (Platform, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(platform))
+	for code, info := range platformsRegistry {
+		if normalized == string(code) {
+			return code, true
+		}
+		for _, alias := range info.aliases {
+			if normalized == alias {
+				return code, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func (p Platform) Title() string {
+	return platformsRegistry[p].title
+}
+
+// PlatformMeta is metadata of meeting summary was recorded on
+type PlatformMeta struct {
+	MeetingURL        *string        `db:"meeting_url"`
+	ExternalMeetingID *string        `db:"external_meeting_id"`
+	Participants      pq.StringArray `db:"participants"`
+	DurationSeconds   *int           `db:"duration_seconds"`
+}
+
+// Validate checks metadata against platform's rules
+func (p Platform) Validate(meta PlatformMeta) error {
+	info, ok := platformsRegistry[p]
+	if !ok {
+		return fmt.Errorf("unknown platform %q", p)
+	}
+
+	if meta.MeetingURL != nil {
+		if len(info.meetingHosts) == 0 {
+			return fmt.Errorf("platform %s has no meeting links", p)
+		}
+		if !isMeetingURL(*meta.MeetingURL, info.meetingHosts) {
+			return fmt.Errorf("invalid %s meeting link %q", info.title, *meta.MeetingURL)
+		}
+	}
+
+	if meta.ExternalMeetingID != nil && len(*meta.ExternalMeetingID) > externalMeetingIDMaxLength {
+		return fmt.Errorf("external meeting id is longer than %d", externalMeetingIDMaxLength)
+	}
+
+	if len(meta.Participants) > participantsMaxCount {
+		return fmt.Errorf("more than %d participants", participantsMaxCount)
+	}
+	for _, participant := range meta.Participants {
+		if strings.TrimSpace(participant) == "" || len(participant) > participantMaxLength {
+			return fmt.Errorf("participant must be non-empty and not longer than %d", participantMaxLength)
+		}
+	}
+
+	if meta.DurationSeconds != nil && *meta.DurationSeconds < 0 {
+		return errors.New("duration can't be negative")
+	}
+
+	return nil
+}
+
+func isMeetingURL(rawURL string, hosts []string) bool {
+	meetingURL, err := url.Parse(rawURL)
+	if err != nil || (meetingURL.Scheme != "https" && meetingURL.Scheme != "http") {
+		return false
+	}
+
+	host := strings.ToLower(meetingURL.Hostname())
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type PlatformTransfer struct {
+	Code          string `json:"code"`
+	Title         string `json:"title"`
+	HasMeetingURL bool   `json:"has_meeting_url"`
+}
+
+// ListPlatforms returns registry for clients, ordered by code
+func ListPlatforms() []PlatformTransfer {
+	platforms := make([]PlatformTransfer, 0, len(platformsRegistry))
+	for _, code := range []Platform{GoogleMeetPlatform, TelegramPlatform, TelemostPlatform, UploadPlatform, ZoomPlatform} {
+		platforms = append(platforms, PlatformTransfer{
+			Code:          string(code),
+			Title:         platformsRegistry[code].title,
+			HasMeetingURL: len(platformsRegistry[code].meetingHosts) > 0,
+		})
+	}
+
+	return platforms
+}
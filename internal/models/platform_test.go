@@ -0,0 +1,97 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPlatformFromString(t *testing.T) {
+	tests := []struct {
+		platform string
+		want     Platform
+		wantOK   bool
+	}{
+		{platform: "zoom", want: ZoomPlatform, wantOK: true},
+		{platform: "  Zoom ", want: ZoomPlatform, wantOK: true},
+		{platform: "google_meet", want: GoogleMeetPlatform, wantOK: true},
+		{platform: "Google Meet", want: GoogleMeetPlatform, wantOK: true},
+		{platform: "GMEET", want: GoogleMeetPlatform, wantOK: true},
+		{platform: "meet", want: GoogleMeetPlatform, wantOK: true},
+		{platform: "Яндекс Телемост", want: TelemostPlatform, wantOK: true},
+		{platform: "telemost", want: TelemostPlatform, wantOK: true},
+		{platform: "tg", want: TelegramPlatform, wantOK: true},
+		{platform: "Телеграм", want: TelegramPlatform, wantOK: true},
+		{platform: "file", want: UploadPlatform, wantOK: true},
+		{platform: "загрузка", want: UploadPlatform, wantOK: true},
+		{platform: "", wantOK: false},
+		{platform: "skype", wantOK: false},
+		{platform: "google", wantOK: false},
+		{platform: "zoom.us", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := PlatformFromString(tt.platform)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("PlatformFromString(%q) = %q, %v, want %q, %v", tt.platform, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestPlatformValidateMeetingURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		platform Platform
+		url      string
+		wantErr  bool
+	}{
+		{name: "host", platform: ZoomPlatform, url: "https://zoom.us/j/123", wantErr: false},
+		{name: "subdomain", platform: ZoomPlatform, url: "https://x.zoom.us/j/123", wantErr: false},
+		{name: "upper case host", platform: ZoomPlatform, url: "https://US02WEB.ZOOM.US/j/123", wantErr: false},
+		{name: "http", platform: ZoomPlatform, url: "http://zoom.us/j/123", wantErr: false},
+		{name: "host suffix without dot", platform: ZoomPlatform, url: "https://evilzoom.us/j/123", wantErr: true},
+		{name: "host as subdomain of other host", platform: ZoomPlatform, url: "https://zoom.us.evil.com/j/123", wantErr: true},
+		{name: "host in userinfo", platform: ZoomPlatform, url: "https://zoom.us@evil.com/j/123", wantErr: true},
+		{name: "non-http scheme", platform: ZoomPlatform, url: "ftp://zoom.us/j/123", wantErr: true},
+		{name: "javascript scheme", platform: ZoomPlatform, url: "javascript://zoom.us/%0Aalert(1)", wantErr: true},
+		{name: "no scheme", platform: ZoomPlatform, url: "zoom.us/j/123", wantErr: true},
+		{name: "host of other platform", platform: GoogleMeetPlatform, url: "https://zoom.us/j/123", wantErr: true},
+		{name: "platform without links", platform: UploadPlatform, url: "https://zoom.us/j/123", wantErr: true},
+		{name: "unknown platform", platform: Platform("skype"), url: "https://zoom.us/j/123", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := tt.url
+			err := tt.platform.Validate(PlatformMeta{MeetingURL: &url})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%q) error = %v, want error %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPlatformValidateMeta(t *testing.T) {
+	longID := strings.Repeat("a", externalMeetingIDMaxLength+1)
+	negative := -1
+
+	tests := []struct {
+		name    string
+		meta    PlatformMeta
+		wantErr bool
+	}{
+		{name: "empty", meta: PlatformMeta{}, wantErr: false},
+		{name: "participants", meta: PlatformMeta{Participants: []string{"Анна", "Bob"}}, wantErr: false},
+		{name: "blank participant", meta: PlatformMeta{Participants: []string{"Анна", " "}}, wantErr: true},
+		{name: "too long external id", meta: PlatformMeta{ExternalMeetingID: &longID}, wantErr: true},
+		{name: "negative duration", meta: PlatformMeta{DurationSeconds: &negative}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ZoomPlatform.Validate(tt.meta)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
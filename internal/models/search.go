@@ -0,0 +1,40 @@
+package models
+
+import (
+	"github.com/gofrs/uuid/v5"
+)
+
+type SearchResult struct {
+	NoteID      uuid.UUID  `db:"note_id"`
+	NoteTitle   string     `db:"note_title"`
+	SummaryID   *uuid.UUID `db:"summ_id"`
+	SummaryName *string    `db:"summ_name"`
+	Headline    string     `db:"headline"`
+	Rank        float64    `db:"rank"`
+}
+
+func (sr *SearchResult) ToTransfer() *SearchResultTransfer {
+	var summaryID *string
+	if sr.SummaryID != nil {
+		id := sr.SummaryID.String()
+		summaryID = &id
+	}
+
+	return &SearchResultTransfer{
+		NoteID:      sr.NoteID.String(),
+		NoteTitle:   sr.NoteTitle,
+		SummaryID:   summaryID,
+		SummaryName: sr.SummaryName,
+		Headline:    sr.Headline,
+		Rank:        sr.Rank,
+	}
+}
+
+type SearchResultTransfer struct {
+	NoteID      string  `json:"note_id"`
+	NoteTitle   string  `json:"note_title"`
+	SummaryID   *string `json:"summary_id"`
+	SummaryName *string `json:"summary_name"`
+	Headline    string  `json:"headline"`
+	Rank        float64 `json:"rank"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/lib/pq"
+)
+
+// Scopes of service clients
+const (
+	// SummaryWriteScope allows to save summaries' texts, role texts and finish summaries
+	SummaryWriteScope = "summary:write"
+	// SummaryReadActiveScope allows to list active summaries
+	SummaryReadActiveScope = "summary:read_active"
+)
+
+func IsValidServiceScope(scope string) bool {
+	switch scope {
+	case SummaryWriteScope, SummaryReadActiveScope:
+		return true
+	}
+
+	return false
+}
+
+type ServiceClient struct {
+	ID         uuid.UUID      `db:"id"`
+	Name       string         `db:"name"`
+	Scopes     pq.StringArray `db:"scopes"`
+	CreatedAt  time.Time      `db:"created_at"`
+	DisabledAt *time.Time     `db:"disabled_at"`
+}
+
+func (sc *ServiceClient) HasScope(scope string) bool {
+	for _, s := range sc.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+type ServiceKey struct {
+	ID        uuid.UUID  `db:"id"`
+	ClientID  uuid.UUID  `db:"client_id"`
+	KeyHash   string     `db:"key_hash"`
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt *time.Time `db:"expires_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+}
+
+// IsActive reports whether key is neither revoked nor expired
+func (sk *ServiceKey) IsActive(now time.Time) bool {
+	return sk.RevokedAt == nil && (sk.ExpiresAt == nil || now.Before(*sk.ExpiresAt))
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// sessionPublicIDLength is number of bytes of session id's hash used as public id
+const sessionPublicIDLength = 16
+
+// SessionClient describes device session is created from
+type SessionClient struct {
+	UserAgent string
+	IP        string
+}
+
+type Session struct {
+	// ID is secret stored in cookie, it must never be sent to clients in other way
+	ID         string
+	UserID     uuid.UUID
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	Client     SessionClient
+}
+
+func NewSession(ID string, userID uuid.UUID, client SessionClient) Session {
+	now := time.Now()
+	return Session{
+		ID:         ID,
+		UserID:     userID,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		Client:     client,
+	}
+}
+
+// PublicID identifies session in lists, it can't be used to restore session id
+func (s *Session) PublicID() string {
+	hash := sha256.Sum256([]byte(s.ID))
+	return hex.EncodeToString(hash[:sessionPublicIDLength])
+}
+
+func (s *Session) ToTransfer(currentSessionID string) *SessionTransfer {
+	return &SessionTransfer{
+		ID:         s.PublicID(),
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		UserAgent:  s.Client.UserAgent,
+		IP:         s.Client.IP,
+		Current:    s.ID == currentSessionID,
+	}
+}
+
+type SessionTransfer struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	Current    bool      `json:"current"`
+}
@@ -41,45 +41,98 @@ func (d Detalization) String() string {
 	return "Средняя"
 }
 
+type SummaryStatus string
+
+const (
+	RecordingSummaryStatus SummaryStatus = "recording"
+	FinishingSummaryStatus SummaryStatus = "finishing"
+	DoneSummaryStatus      SummaryStatus = "done"
+	FailedSummaryStatus    SummaryStatus = "failed"
+	AbandonedSummaryStatus SummaryStatus = "abandoned"
+)
+
+// IsActive reports whether summary still may be updated by recorder
+func (s SummaryStatus) IsActive() bool {
+	return s == RecordingSummaryStatus || s == FinishingSummaryStatus
+}
+
+// IsFinished reports whether summary won't be updated anymore
+func (s SummaryStatus) IsFinished() bool {
+	return s == DoneSummaryStatus || s == FailedSummaryStatus || s == AbandonedSummaryStatus
+}
+
 type Summary struct {
-	ID           uuid.UUID    `db:"id"`
-	Text         string       `db:"text"`
-	TextWithRole string       `db:"text_with_role"`
-	Role         string       `db:"role"`
-	Active       bool         `db:"active"`
-	Platform     string       `db:"platform"`
+	ID           uuid.UUID     `db:"id"`
+	Text         string        `db:"text"`
+	TextWithRole string        `db:"text_with_role"`
+	Role         string        `db:"role"`
+	Status       SummaryStatus `db:"status"`
+	FinishReason *string       `db:"finish_reason"`
+	FinishedAt   *time.Time    `db:"finished_at"`
+	UpdatedAt    time.Time     `db:"updated_at"`
+	Platform     Platform      `db:"platform"`
+	PlatformMeta
 	StartedAt    time.Time    `db:"started_at"`
 	Detalization Detalization `db:"detalization"`
 	Name         string       `db:"name"`
+	CreatorID    *uuid.UUID   `db:"creator_id"`
 }
 
 type SummaryIDStatus struct {
-	ID     uuid.UUID `db:"id"`
-	Active bool      `db:"active"`
+	ID     uuid.UUID     `db:"id"`
+	Status SummaryStatus `db:"status"`
 }
 
 func (s *Summary) ToTransfer() *SummaryTransfer {
 	return &SummaryTransfer{
-		ID:           s.ID.String(),
-		Text:         s.Text,
-		Active:       s.Active,
-		TextWithRole: s.TextWithRole,
-		Role:         s.Role,
-		Platform:     s.Platform,
-		StartedAt:    s.StartedAt,
-		Detalization: s.Detalization.String(),
-		Name:         s.Name,
+		ID:                s.ID.String(),
+		Text:              s.Text,
+		Active:            s.Status.IsActive(),
+		Status:            string(s.Status),
+		FinishReason:      s.FinishReason,
+		FinishedAt:        s.FinishedAt,
+		UpdatedAt:         s.UpdatedAt,
+		TextWithRole:      s.TextWithRole,
+		Role:              s.Role,
+		Platform:          string(s.Platform),
+		PlatformTitle:     s.Platform.Title(),
+		MeetingURL:        s.MeetingURL,
+		ExternalMeetingID: s.ExternalMeetingID,
+		Participants:      participantsToTransfer(s.Participants),
+		DurationSeconds:   s.DurationSeconds,
+		StartedAt:         s.StartedAt,
+		Detalization:      s.Detalization.String(),
+		Name:              s.Name,
+		CreatorID:         uuidToNullableString(s.CreatorID),
 	}
 }
 
 type SummaryTransfer struct {
-	ID           string    `json:"id"`
-	Text         string    `json:"text"`
-	TextWithRole string    `json:"text_with_role"`
-	Active       bool      `json:"active"`
-	Role         string    `json:"role"`
-	Platform     string    `json:"platform"`
-	StartedAt    time.Time `json:"started_at"`
-	Detalization string    `json:"detalization"`
-	Name         string    `json:"name"`
+	ID                string     `json:"id"`
+	Text              string     `json:"text"`
+	TextWithRole      string     `json:"text_with_role"`
+	Active            bool       `json:"active"`
+	Status            string     `json:"status"`
+	FinishReason      *string    `json:"finish_reason"`
+	FinishedAt        *time.Time `json:"finished_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	Role              string     `json:"role"`
+	Platform          string     `json:"platform"`
+	PlatformTitle     string     `json:"platform_title"`
+	MeetingURL        *string    `json:"meeting_url"`
+	ExternalMeetingID *string    `json:"external_meeting_id"`
+	Participants      []string   `json:"participants"`
+	DurationSeconds   *int       `json:"duration_seconds"`
+	StartedAt         time.Time  `json:"started_at"`
+	Detalization      string     `json:"detalization"`
+	Name              string     `json:"name"`
+	CreatorID         *string    `json:"creator_id"`
+}
+
+func participantsToTransfer(participants []string) []string {
+	if participants == nil {
+		return []string{}
+	}
+
+	return participants
 }
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+type SummaryChunk struct {
+	SummaryID  uuid.UUID `db:"summ_id"`
+	Seq        int       `db:"seq"`
+	Text       string    `db:"text"`
+	RecordedAt time.Time `db:"recorded_at"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+func (c *SummaryChunk) ToTransfer() *SummaryChunkTransfer {
+	return &SummaryChunkTransfer{
+		SummaryID:  c.SummaryID.String(),
+		Seq:        c.Seq,
+		Text:       c.Text,
+		RecordedAt: c.RecordedAt,
+		CreatedAt:  c.CreatedAt,
+	}
+}
+
+type SummaryChunkTransfer struct {
+	SummaryID  string    `json:"summ_id"`
+	Seq        int       `json:"seq"`
+	Text       string    `json:"text"`
+	RecordedAt time.Time `json:"recorded_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+type SummaryEventType string
+
+const (
+	// SummaryUpdatedEvent is emitted when summary's text, role text or status changes
+	SummaryUpdatedEvent SummaryEventType = "update"
+	// SummaryFinishedEvent is the last event of summary
+	SummaryFinishedEvent SummaryEventType = "finish"
+)
+
+type SummaryEvent struct {
+	Type    SummaryEventType `json:"type"`
+	Summary SummaryTransfer  `json:"summary"`
+}
+
+func NewSummaryEvent(eventType SummaryEventType, summ *Summary) SummaryEvent {
+	return SummaryEvent{
+		Type:    eventType,
+		Summary: *summ.ToTransfer(),
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// SummaryListCursor points to the last summary of page, summaries are sorted by start time
+type SummaryListCursor struct {
+	StartedAt time.Time `json:"ts"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func NewSummaryListCursor(lastSummary *Summary) *SummaryListCursor {
+	return &SummaryListCursor{StartedAt: lastSummary.StartedAt, ID: lastSummary.ID}
+}
+
+func (c *SummaryListCursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeSummaryListCursor(encoded string) (*SummaryListCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var cursor SummaryListCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &cursor, nil
+}
+
+type SummaryListOptions struct {
+	Limit  int
+	Cursor *SummaryListCursor
+
+	Platform     Platform
+	Detalization *Detalization
+	Status       SummaryStatus
+	StartedFrom  *time.Time
+	StartedTo    *time.Time
+	// NameQuery matches summaries which names contain it, case insensitive
+	NameQuery string
+	NoteID    *uuid.UUID
+	HasNote   *bool
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+type SummaryVersionKind string
+
+const (
+	// BaseSummaryVersion is summary text of some detalization
+	BaseSummaryVersion SummaryVersionKind = "base"
+	// RoleSummaryVersion is rewrite of base text for some role, e.g. "manager"
+	RoleSummaryVersion SummaryVersionKind = "role"
+)
+
+type SummaryVersion struct {
+	SummaryID    uuid.UUID          `db:"summ_id"`
+	Version      int                `db:"version"`
+	Kind         SummaryVersionKind `db:"kind"`
+	Detalization Detalization       `db:"detalization"`
+	Role         string             `db:"role"`
+	Text         string             `db:"text"`
+	CreatedAt    time.Time          `db:"created_at"`
+	UpdatedAt    time.Time          `db:"updated_at"`
+}
+
+func (v *SummaryVersion) ToTransfer() *SummaryVersionTransfer {
+	return &SummaryVersionTransfer{
+		SummaryID:    v.SummaryID.String(),
+		Version:      v.Version,
+		Kind:         string(v.Kind),
+		Detalization: v.Detalization.String(),
+		Role:         v.Role,
+		Text:         v.Text,
+		CreatedAt:    v.CreatedAt,
+		UpdatedAt:    v.UpdatedAt,
+	}
+}
+
+type SummaryVersionTransfer struct {
+	SummaryID    string    `json:"summ_id"`
+	Version      int       `json:"version"`
+	Kind         string    `json:"kind"`
+	Detalization string    `json:"detalization"`
+	Role         string    `json:"role"`
+	Text         string    `json:"text,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+type TaskStatus string
+
+const (
+	OpenTaskStatus       TaskStatus = "open"
+	InProgressTaskStatus TaskStatus = "in_progress"
+	DoneTaskStatus       TaskStatus = "done"
+	CancelledTaskStatus  TaskStatus = "cancelled"
+)
+
+func (s TaskStatus) IsValid() bool {
+	switch s {
+	case OpenTaskStatus, InProgressTaskStatus, DoneTaskStatus, CancelledTaskStatus:
+		return true
+	}
+
+	return false
+}
+
+// TaskDueDateLayout is format of tasks' due dates in requests and responses
+const TaskDueDateLayout = "2006-01-02"
+
+type Task struct {
+	ID          uuid.UUID
+	Title       string
+	Description string
+	Status      TaskStatus
+	Assignee    *User
+	DueDate     *time.Time
+	SummaryID   *uuid.UUID
+	NoteID      *uuid.UUID
+	CreatorID   *uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	// Access is access of user tasks are listed for, it's set only in lists
+	Access *string
+}
+
+func (t *Task) ToTransfer(allowedMethods []string) *TaskTransfer {
+	var assignee *UserTransfer
+	if t.Assignee != nil {
+		assignee = t.Assignee.ToTransfer()
+	}
+
+	var dueDate *string
+	if t.DueDate != nil {
+		date := t.DueDate.Format(TaskDueDateLayout)
+		dueDate = &date
+	}
+
+	return &TaskTransfer{
+		ID:          t.ID.String(),
+		Title:       t.Title,
+		Description: t.Description,
+		Status:      string(t.Status),
+		Assignee:    assignee,
+		DueDate:     dueDate,
+		SummaryID:   uuidToNullableString(t.SummaryID),
+		NoteID:      uuidToNullableString(t.NoteID),
+		CreatorID:   uuidToNullableString(t.CreatorID),
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+
+		AllowedMethods: allowedMethods,
+	}
+}
+
+type TaskTransfer struct {
+	ID          string        `json:"id"`
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	Status      string        `json:"status"`
+	Assignee    *UserTransfer `json:"assignee"`
+	DueDate     *string       `json:"due_date"`
+	SummaryID   *string       `json:"summ_id"`
+	NoteID      *string       `json:"note_id"`
+	CreatorID   *string       `json:"creator_id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+
+	AllowedMethods []string `json:"allowed_methods"`
+}
+
+// TaskProposal is task found in summary text, not saved until user creates it
+type TaskProposal struct {
+	Title   string
+	DueDate *time.Time
+	// Line is number of summary text's line task was found on, starting from 1
+	Line int
+}
+
+func (p *TaskProposal) ToTransfer() *TaskProposalTransfer {
+	var dueDate *string
+	if p.DueDate != nil {
+		date := p.DueDate.Format(TaskDueDateLayout)
+		dueDate = &date
+	}
+
+	return &TaskProposalTransfer{
+		Title:   p.Title,
+		DueDate: dueDate,
+		Line:    p.Line,
+	}
+}
+
+type TaskProposalTransfer struct {
+	Title   string  `json:"title"`
+	DueDate *string `json:"due_date"`
+	Line    int     `json:"line"`
+}
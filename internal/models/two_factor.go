@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// UserTOTP is user's TOTP second factor, it's pending until EnabledAt is set.
+// Secret is deliberately stored in plaintext: unlike password it can't be hashed, since codes are computed from it,
+// and encryption key would be kept next to database credentials anyway. So database access is treated
+// as access to second factor, and database backups must be protected accordingly
+type UserTOTP struct {
+	UserID       uuid.UUID  `db:"user_id"`
+	Secret       string     `db:"secret"`
+	EnabledAt    *time.Time `db:"enabled_at"`
+	LastUsedStep int64      `db:"last_used_step"`
+}
+
+func (t *UserTOTP) Enabled() bool {
+	return t != nil && t.EnabledAt != nil
+}
+
+// TOTPEnrollment is shown to user once, URI is meant to be rendered as QR code
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type TwoFactorStatus struct {
+	Enabled           bool       `json:"enabled"`
+	EnabledAt         *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+}
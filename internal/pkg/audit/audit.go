@@ -0,0 +1,9 @@
+package audit
+
+import "github.com/yarikTri/archipelago-notes-api/internal/models"
+
+// Repository is append-only: events can't be changed or deleted
+type Repository interface {
+	Create(event *models.AuditEvent) error
+	ListByTarget(targetType, targetID string) ([]*models.AuditEvent, error)
+}
@@ -0,0 +1,50 @@
+package postgresql
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
+
+// PostgreSQL implements audit.Repository
+type PostgreSQL struct {
+	db *sqlx.DB
+}
+
+func NewPostgreSQL(db *sqlx.DB) *PostgreSQL {
+	return &PostgreSQL{
+		db: db,
+	}
+}
+
+func (p *PostgreSQL) Create(event *models.AuditEvent) error {
+	query := fmt.Sprint(
+		`INSERT INTO audit_event
+		(actor_id, target_type, target_id, action, principal_type, principal_id, old_value, new_value)
+		VALUES (:actor_id, :target_type, :target_id, :action, :principal_type, :principal_id, :old_value, :new_value)`,
+	)
+
+	if _, err := p.db.NamedExec(query, event); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p *PostgreSQL) ListByTarget(targetType, targetID string) ([]*models.AuditEvent, error) {
+	query := fmt.Sprint(
+		`SELECT id, actor_id, target_type, target_id, action, principal_type, principal_id, old_value, new_value, created_at
+			FROM audit_event
+			WHERE target_type = $1 AND target_id = $2
+			ORDER BY created_at DESC, id DESC`,
+	)
+
+	var events []*models.AuditEvent
+	if err := p.db.Select(&events, query, targetType, targetID); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return events, nil
+}
@@ -1,26 +1,162 @@
 package auth
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
 )
 
+// ErrWeakPassword is returned when new password doesn't satisfy password policy
+var ErrWeakPassword = errors.New("password doesn't satisfy policy")
+
+// ErrWrongPassword is returned when current password doesn't match on password change or second factor enrollment
+var ErrWrongPassword = errors.New("wrong password")
+
+// ErrSessionNotFound is returned when session to revoke doesn't belong to user or has already ended
+var ErrSessionNotFound = errors.New("session not found")
+
+// TooManyAttemptsError is returned when login or signup is throttled
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("too many attempts, retry after %s", e.RetryAfter)
+}
+
+// ErrInvalidResetToken is returned when password reset token is unknown, used or expired
+var ErrInvalidResetToken = errors.New("invalid password reset token")
+
+// ErrTwoFactorAlreadyEnabled is returned on enrollment when user has already enabled second factor
+var ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+
+// ErrTwoFactorNotEnabled is returned when second factor is confirmed before enrollment or disabled while not enabled
+var ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+
+// ErrInvalidTwoFactorCode is returned when neither TOTP code nor recovery code matches
+var ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+
+// ErrInvalidPendingLogin is returned when pending login token is unknown, expired or used up
+var ErrInvalidPendingLogin = errors.New("invalid pending login token")
+
+// TwoFactorRequiredError is returned by Login when password matches but user has second factor,
+// login is completed with Token by LoginTwoFactor
+type TwoFactorRequiredError struct {
+	Token     string
+	ExpiresIn time.Duration
+}
+
+func (e *TwoFactorRequiredError) Error() string {
+	return "two-factor authentication required"
+}
+
 type Usecase interface {
+	// GetUserIDBySessionID also prolongs session, which expires after idle timeout.
+	// Methods taking userID expect user of current session resolved by it
 	GetUserIDBySessionID(sessionID string) (uuid.UUID, error)
-	SignUp(email, name, password string) (string, uuid.UUID, time.Duration, error)
-	Login(email, password string) (string, uuid.UUID, time.Duration, error)
+	SignUp(email, name, password string, client models.SessionClient) (string, uuid.UUID, time.Duration, error)
+	// Login returns TwoFactorRequiredError instead of session if user has enabled second factor
+	Login(email, password string, client models.SessionClient) (string, uuid.UUID, time.Duration, error)
+	// LoginTwoFactor completes login started by Login with TOTP code or recovery code
+	LoginTwoFactor(token, code string, client models.SessionClient) (string, uuid.UUID, time.Duration, error)
 	Logout(sessionID string) error
+
+	ListSessions(userID uuid.UUID) ([]*models.Session, error)
+	// RevokeSession ends session of the user by its public id
+	RevokeSession(userID uuid.UUID, publicID string) error
+	// RevokeOtherSessions ends all user's sessions except current sessionID
+	RevokeOtherSessions(userID uuid.UUID, sessionID string) error
+
+	// RequestPasswordReset emails reset link, it doesn't report whether user with email exists
+	RequestPasswordReset(email string) error
+	// ResetPassword sets new password by reset token and ends all user's sessions
+	ResetPassword(token, password string) error
+	// ChangePassword sets new password of session's user and ends his other sessions
+	ChangePassword(userID uuid.UUID, sessionID, currentPassword, newPassword string) error
+
+	GetTwoFactorStatus(userID uuid.UUID) (*models.TwoFactorStatus, error)
+	// EnrollTOTP checks password and generates new secret, which isn't required on login until it's confirmed
+	EnrollTOTP(userID uuid.UUID, password string) (*models.TOTPEnrollment, error)
+	// ConfirmTOTP checks password and enables second factor by code from authenticator, it returns recovery codes
+	ConfirmTOTP(userID uuid.UUID, password, code string) ([]string, error)
+	// RegenerateRecoveryCodes replaces all recovery codes, code is TOTP code or recovery code
+	RegenerateRecoveryCodes(userID uuid.UUID, code string) ([]string, error)
+	// DisableTOTP removes second factor, code is TOTP code or recovery code
+	DisableTOTP(userID uuid.UUID, code string) error
 }
 
 type SessionsRepository interface {
 	GetUserIDBySessionID(sessionID string) (uuid.UUID, error)
-	CreateSession(sessionID string, userID uuid.UUID, expiration time.Duration) error
+	// CreateSession keeps user's sessions index for maxExpiration, which no session of user outlives
+	CreateSession(session models.Session, expiration, maxExpiration time.Duration) error
+	GetSession(sessionID string) (*models.Session, error)
+	// TouchSession returns NotFoundError if session was deleted meanwhile
+	TouchSession(sessionID string, lastSeenAt time.Time, expiration time.Duration) error
+	ListUserSessions(userID uuid.UUID) ([]*models.Session, error)
 	DeleteSession(sessionID string) error
+	// DeleteUserSessions deletes all user's sessions except exceptSessionID, which may be empty
+	DeleteUserSessions(userID uuid.UUID, exceptSessionID string) error
+	// SetSessionsValidAfter stores time user's sessions were ended at, it's kept for expiration
+	SetSessionsValidAfter(userID uuid.UUID, validAfter time.Time, expiration time.Duration) error
+	// GetSessionsValidAfter returns nil if user's sessions weren't ended
+	GetSessionsValidAfter(userID uuid.UUID) (*time.Time, error)
+
+	// CreatePendingLogin stores login waiting for second factor
+	CreatePendingLogin(token string, userID uuid.UUID, expiration time.Duration) error
+	// GetPendingLogin returns NotFoundError for unknown and expired tokens
+	GetPendingLogin(token string) (uuid.UUID, error)
+	// HitPendingLogin counts second factor attempt before it's checked and returns number of attempts
+	HitPendingLogin(token string) (int, error)
+	DeletePendingLogin(token string) error
 }
 
 type UsersRepository interface {
 	GetUserIDAndPasswordByEmail(email string) (uuid.UUID, string, error)
+	GetPasswordByID(userID uuid.UUID) (string, error)
 	CreateUser(email, name, passwordHash string) (uuid.UUID, error)
+	UpdatePassword(userID uuid.UUID, passwordHash string) error
 	DeleteUser(userID uuid.UUID) error
+
+	// CreatePasswordReset replaces user's unused reset tokens with new one
+	CreatePasswordReset(userID uuid.UUID, tokenHash string, expiresAt time.Time) error
+	// GetLastPasswordResetTime returns nil if reset was never requested
+	GetLastPasswordResetTime(userID uuid.UUID) (*time.Time, error)
+	// ResetPasswordByTokenHash uses token and sets password of its user, returns NotFoundError for invalid token
+	ResetPasswordByTokenHash(tokenHash, passwordHash string) (uuid.UUID, error)
+
+	// GetTOTP returns NotFoundError if user has never enrolled
+	GetTOTP(userID uuid.UUID) (*models.UserTOTP, error)
+	// SaveTOTPSecret replaces pending secret, it returns false if enabled secret exists
+	SaveTOTPSecret(userID uuid.UUID, secret string) (bool, error)
+	// EnableTOTP enables pending secret, marks step used and replaces recovery codes
+	EnableTOTP(userID uuid.UUID, step int64, recoveryCodeHashes []string) error
+	// UseTOTPStep marks step used, it returns false if the step or a later one was already used
+	UseTOTPStep(userID uuid.UUID, step int64) (bool, error)
+	// UseRecoveryCode marks code used, it returns false for unknown and used codes
+	UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error)
+	CountRecoveryCodes(userID uuid.UUID) (int, error)
+	ReplaceRecoveryCodes(userID uuid.UUID, recoveryCodeHashes []string) error
+	// DeleteTOTP removes secret and recovery codes
+	DeleteTOTP(userID uuid.UUID) error
+}
+
+// Limiter throttles attempts by key, e.g. ip or email
+type Limiter interface {
+	// Hit checks and registers attempt of key in one step, so it's called before attempt is checked.
+	// Attempt made while key is blocked isn't counted
+	Hit(key string) (models.LimitHit, error)
+	// Reset forgets attempts of key
+	Reset(key string) error
+}
+
+// Limiters are used by Usecase: failed logins are counted by ip and by email, signups by ip,
+// second factor codes and current passwords of logged in user by user id
+type Limiters struct {
+	LoginByIP       Limiter
+	LoginByEmail    Limiter
+	SignUpByIP      Limiter
+	TwoFactorByUser Limiter
 }
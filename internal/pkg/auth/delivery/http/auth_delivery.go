@@ -3,12 +3,15 @@ package http
 import (
 	"errors"
 	"github.com/lib/pq"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-park-mail-ru/2023_1_Technokaif/pkg/logger"
 	commonAuth "github.com/yarikTri/archipelago-notes-api/internal/common/http/auth"
 	commonHttp "github.com/yarikTri/archipelago-notes-api/internal/common/http/constants"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
 	"github.com/yarikTri/archipelago-notes-api/internal/pkg/auth"
 )
 
@@ -24,6 +27,25 @@ func NewHandler(au auth.Usecase, l logger.Logger) *Handler {
 	}
 }
 
+func sessionClient(c *gin.Context) models.SessionClient {
+	return models.SessionClient{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+}
+
+// respondTooManyAttempts responds 429 with Retry-After if err is TooManyAttemptsError
+func respondTooManyAttempts(c *gin.Context, err error) bool {
+	var tooManyErr *auth.TooManyAttemptsError
+	if !errors.As(err, &tooManyErr) {
+		return false
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(tooManyErr.RetryAfter.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, "Too many attempts")
+	return true
+}
+
 // CheckSession ..
 func (h *Handler) CheckSession(c *gin.Context) {
 	sessionID, err := commonAuth.GetSessionID(c)
@@ -64,14 +86,16 @@ func (h *Handler) SignUp(c *gin.Context) {
 		return
 	}
 
-	if len(signUpInfo.Password) > 72 {
-		c.JSON(http.StatusBadRequest, "Too long password")
+	sessionID, userID, expiration, err := h.authUsecase.SignUp(signUpInfo.Email, signUpInfo.Name, signUpInfo.Password, sessionClient(c))
+	if errors.Is(err, auth.ErrWeakPassword) {
+		c.JSON(http.StatusBadRequest, err.Error())
 		return
 	}
-
-	sessionID, userID, expiration, err := h.authUsecase.SignUp(signUpInfo.Email, signUpInfo.Name, signUpInfo.Password)
 	if err != nil {
 		h.logger.Error(err.Error())
+		if respondTooManyAttempts(c, err) {
+			return
+		}
 		var consistentError *pq.Error
 		if errors.As(err, &consistentError) && consistentError.Code == "23505" {
 			c.JSON(http.StatusBadRequest, "User already exists")
@@ -102,9 +126,20 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	sessionID, userID, expiration, err := h.authUsecase.Login(credentials.Email, credentials.Password)
+	sessionID, userID, expiration, err := h.authUsecase.Login(credentials.Email, credentials.Password, sessionClient(c))
+	var twoFactorErr *auth.TwoFactorRequiredError
+	if errors.As(err, &twoFactorErr) {
+		c.JSON(http.StatusAccepted, TwoFactorRequiredResponse{
+			TwoFactorToken: twoFactorErr.Token,
+			ExpiresIn:      int(twoFactorErr.ExpiresIn.Seconds()),
+		})
+		return
+	}
 	if err != nil {
 		h.logger.Error(err.Error())
+		if respondTooManyAttempts(c, err) {
+			return
+		}
 		c.JSON(http.StatusUnauthorized, map[string]string{"error": "Not found user"})
 		return
 	}
@@ -113,6 +148,49 @@ func (h *Handler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, LoginResponse{UserID: userID.String()})
 }
 
+// TwoFactorRequiredResponse is returned by Login with 202 instead of session,
+// login is completed by LoginTwoFactor within ExpiresIn seconds
+type TwoFactorRequiredResponse struct {
+	TwoFactorToken string `json:"two_factor_token"`
+	ExpiresIn      int    `json:"expires_in"`
+}
+
+type LoginTwoFactorRequest struct {
+	TwoFactorToken string `json:"two_factor_token"`
+	// Code is code from authenticator or recovery code
+	Code string `json:"code"`
+}
+
+// LoginTwoFactor ..
+func (h *Handler) LoginTwoFactor(c *gin.Context) {
+	var req LoginTwoFactorRequest
+	if err := c.BindJSON(&req); err != nil || req.TwoFactorToken == "" || req.Code == "" {
+		c.JSON(http.StatusBadRequest, "Invalid two-factor login data")
+		return
+	}
+
+	sessionID, userID, expiration, err := h.authUsecase.LoginTwoFactor(req.TwoFactorToken, req.Code, sessionClient(c))
+	if errors.Is(err, auth.ErrInvalidPendingLogin) {
+		c.JSON(http.StatusUnauthorized, "Login expired, enter password again")
+		return
+	}
+	if errors.Is(err, auth.ErrInvalidTwoFactorCode) {
+		c.JSON(http.StatusUnauthorized, "Invalid code")
+		return
+	}
+	if err != nil {
+		if respondTooManyAttempts(c, err) {
+			return
+		}
+		h.logger.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, "Error while login")
+		return
+	}
+
+	c.SetCookie(commonHttp.SessionIdCookieName, sessionID, int(expiration.Seconds()), "", "", true, true)
+	c.JSON(http.StatusOK, LoginResponse{UserID: userID.String()})
+}
+
 // Logout ..
 func (h *Handler) Logout(c *gin.Context) {
 	sessionID, err := commonAuth.GetSessionID(c)
@@ -130,3 +208,363 @@ func (h *Handler) Logout(c *gin.Context) {
 	c.SetCookie(commonHttp.SessionIdCookieName, "", -1, "", "", true, true)
 	c.JSON(http.StatusOK, "OK")
 }
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ForgotPassword responds OK even for unknown email, so registered emails can't be found out
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.BindJSON(&req); err != nil || req.Email == "" {
+		c.JSON(http.StatusBadRequest, "Invalid forgot password data")
+		return
+	}
+
+	if err := h.authUsecase.RequestPasswordReset(req.Email); err != nil {
+		h.logger.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, "Error while requesting password reset")
+		return
+	}
+
+	c.JSON(http.StatusOK, "OK")
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// ResetPassword sets new password by token from reset email, all user's sessions are ended
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.BindJSON(&req); err != nil || req.Token == "" {
+		c.JSON(http.StatusBadRequest, "Invalid reset password data")
+		return
+	}
+
+	err := h.authUsecase.ResetPassword(req.Token, req.Password)
+	if errors.Is(err, auth.ErrWeakPassword) {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, auth.ErrInvalidResetToken) {
+		c.JSON(http.StatusBadRequest, "Invalid or expired token")
+		return
+	}
+	if err != nil {
+		h.logger.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, "Error while reset password")
+		return
+	}
+
+	c.JSON(http.StatusOK, "OK")
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// ChangePassword keeps current session, other sessions of user are ended
+func (h *Handler) ChangePassword(c *gin.Context) {
+	sessionID, err := commonAuth.GetSessionID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, "Forbidden")
+		return
+	}
+
+	userID, err := h.authUsecase.GetUserIDBySessionID(sessionID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, "Forbidden")
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, "Invalid change password data")
+		return
+	}
+
+	err = h.authUsecase.ChangePassword(userID, sessionID, req.CurrentPassword, req.NewPassword)
+	if respondTooManyAttempts(c, err) {
+		return
+	}
+	if errors.Is(err, auth.ErrWrongPassword) {
+		c.JSON(http.StatusForbidden, "Wrong password")
+		return
+	}
+	if errors.Is(err, auth.ErrWeakPassword) {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		h.logger.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, "Error while change password")
+		return
+	}
+
+	c.JSON(http.StatusOK, "OK")
+}
+
+type ListSessionsResponse struct {
+	Sessions []*models.SessionTransfer `json:"sessions"`
+}
+
+// ListSessions returns sessions of user, current one is marked
+func (h *Handler) ListSessions(c *gin.Context) {
+	sessionID, err := commonAuth.GetSessionID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, "Forbidden")
+		return
+	}
+
+	userID, err := h.authUsecase.GetUserIDBySessionID(sessionID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, "Forbidden")
+		return
+	}
+
+	sessions, err := h.authUsecase.ListSessions(userID)
+	if err != nil {
+		h.logger.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, "Error while listing sessions")
+		return
+	}
+
+	sessionTransfers := make([]*models.SessionTransfer, 0, len(sessions))
+	for _, session := range sessions {
+		sessionTransfers = append(sessionTransfers, session.ToTransfer(sessionID))
+	}
+
+	c.JSON(http.StatusOK, ListSessionsResponse{Sessions: sessionTransfers})
+}
+
+// RevokeSession ends session by id from sessions list
+func (h *Handler) RevokeSession(c *gin.Context) {
+	sessionID, err := commonAuth.GetSessionID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, "Forbidden")
+		return
+	}
+
+	userID, err := h.authUsecase.GetUserIDBySessionID(sessionID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, "Forbidden")
+		return
+	}
+
+	err = h.authUsecase.RevokeSession(userID, c.Param("id"))
+	if errors.Is(err, auth.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, "Session not found")
+		return
+	}
+	if err != nil {
+		h.logger.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, "Error while revoking session")
+		return
+	}
+
+	c.JSON(http.StatusOK, "OK")
+}
+
+// RevokeOtherSessions logs user out everywhere except current session
+func (h *Handler) RevokeOtherSessions(c *gin.Context) {
+	sessionID, err := commonAuth.GetSessionID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, "Forbidden")
+		return
+	}
+
+	userID, err := h.authUsecase.GetUserIDBySessionID(sessionID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, "Forbidden")
+		return
+	}
+
+	if err := h.authUsecase.RevokeOtherSessions(userID, sessionID); err != nil {
+		h.logger.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, "Error while revoking sessions")
+		return
+	}
+
+	c.JSON(http.StatusOK, "OK")
+}
+
+// GetTwoFactorStatus ..
+func (h *Handler) GetTwoFactorStatus(c *gin.Context) {
+	sessionID, err := commonAuth.GetSessionID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, "Forbidden")
+		return
+	}
+
+	userID, err := h.authUsecase.GetUserIDBySessionID(sessionID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, "Forbidden")
+		return
+	}
+
+	status, err := h.authUsecase.GetTwoFactorStatus(userID)
+	if err != nil {
+		h.logger.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, "Error while getting two-factor status")
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+type EnrollTOTPRequest struct {
+	Password string `json:"password"`
+}
+
+// EnrollTOTP returns new secret and otpauth URI to be shown as QR code,
+// second factor isn't required until it's confirmed by ConfirmTOTP
+func (h *Handler) EnrollTOTP(c *gin.Context) {
+	sessionID, err := commonAuth.GetSessionID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, "Forbidden")
+		return
+	}
+
+	userID, err := h.authUsecase.GetUserIDBySessionID(sessionID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, "Forbidden")
+		return
+	}
+
+	var req EnrollTOTPRequest
+	if err := c.BindJSON(&req); err != nil || req.Password == "" {
+		c.JSON(http.StatusBadRequest, "Invalid two-factor enrollment data")
+		return
+	}
+
+	enrollment, err := h.authUsecase.EnrollTOTP(userID, req.Password)
+	if err != nil {
+		h.respondTwoFactorError(c, err, "Error while enrolling two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type ConfirmTOTPRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// ConfirmTOTP enables second factor, recovery codes are returned only once
+func (h *Handler) ConfirmTOTP(c *gin.Context) {
+	sessionID, err := commonAuth.GetSessionID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, "Forbidden")
+		return
+	}
+
+	userID, err := h.authUsecase.GetUserIDBySessionID(sessionID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, "Forbidden")
+		return
+	}
+
+	var req ConfirmTOTPRequest
+	if err := c.BindJSON(&req); err != nil || req.Password == "" || req.Code == "" {
+		c.JSON(http.StatusBadRequest, "Invalid two-factor code data")
+		return
+	}
+
+	codes, err := h.authUsecase.ConfirmTOTP(userID, req.Password, req.Code)
+	if err != nil {
+		h.respondTwoFactorError(c, err, "Error while confirming two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// RegenerateRecoveryCodes invalidates all previous recovery codes
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	sessionID, err := commonAuth.GetSessionID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, "Forbidden")
+		return
+	}
+
+	userID, err := h.authUsecase.GetUserIDBySessionID(sessionID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, "Forbidden")
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.BindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, "Invalid two-factor code data")
+		return
+	}
+
+	codes, err := h.authUsecase.RegenerateRecoveryCodes(userID, req.Code)
+	if err != nil {
+		h.respondTwoFactorError(c, err, "Error while regenerating recovery codes")
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTP ..
+func (h *Handler) DisableTOTP(c *gin.Context) {
+	sessionID, err := commonAuth.GetSessionID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, "Forbidden")
+		return
+	}
+
+	userID, err := h.authUsecase.GetUserIDBySessionID(sessionID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, "Forbidden")
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.BindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, "Invalid two-factor code data")
+		return
+	}
+
+	if err := h.authUsecase.DisableTOTP(userID, req.Code); err != nil {
+		h.respondTwoFactorError(c, err, "Error while disabling two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, "OK")
+}
+
+// respondTwoFactorError maps errors of second factor management, unknown ones are responded with 500 and message
+func (h *Handler) respondTwoFactorError(c *gin.Context, err error, message string) {
+	if respondTooManyAttempts(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, auth.ErrInvalidTwoFactorCode):
+		c.JSON(http.StatusForbidden, "Invalid code")
+	case errors.Is(err, auth.ErrWrongPassword):
+		c.JSON(http.StatusForbidden, "Wrong password")
+	case errors.Is(err, auth.ErrTwoFactorNotEnabled):
+		c.JSON(http.StatusConflict, "Two-factor authentication is not enabled")
+	case errors.Is(err, auth.ErrTwoFactorAlreadyEnabled):
+		c.JSON(http.StatusConflict, "Two-factor authentication is already enabled")
+	default:
+		h.logger.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, message)
+	}
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
+
+type entry struct {
+	attempts     int
+	lastAttempt  time.Time
+	blockedUntil time.Time
+}
+
+// Limiter implements auth.Limiter inside one process, so it's suitable for tests and single replica only
+type Limiter struct {
+	mu        sync.Mutex
+	policy    models.LimitPolicy
+	entries   map[string]*entry
+	lastPrune time.Time
+}
+
+func NewLimiter(policy models.LimitPolicy) *Limiter {
+	return &Limiter{
+		policy:  policy,
+		entries: make(map[string]*entry),
+	}
+}
+
+func (l *Limiter) Hit(key string) (models.LimitHit, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.prune(now)
+
+	e, ok := l.entries[key]
+	if !ok || l.expired(e, now) {
+		e = &entry{}
+		l.entries[key] = e
+	}
+
+	if e.blockedUntil.After(now) {
+		return models.NewBlockedLimitHit(e.attempts, e.blockedUntil.Sub(now)), nil
+	}
+
+	e.attempts++
+	e.lastAttempt = now
+
+	hit := models.NewLimitHit(l.policy, e.attempts)
+	e.blockedUntil = now.Add(hit.RetryAfter)
+
+	return hit, nil
+}
+
+func (l *Limiter) Reset(key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.entries, key)
+	return nil
+}
+
+// expired reports whether entry's attempts are forgotten: window has passed since block ended
+func (l *Limiter) expired(e *entry, now time.Time) bool {
+	forgetAt := e.lastAttempt.Add(l.policy.Window)
+	if e.blockedUntil.After(e.lastAttempt) {
+		forgetAt = e.blockedUntil.Add(l.policy.Window)
+	}
+
+	return now.After(forgetAt)
+}
+
+// prune deletes expired entries at most once in window, so memory doesn't grow with every new key
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < l.policy.Window {
+		return
+	}
+	l.lastPrune = now
+
+	for key, e := range l.entries {
+		if l.expired(e, now) {
+			delete(l.entries, key)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
+
+func TestLimiterHit(t *testing.T) {
+	l := NewLimiter(models.LimitPolicy{
+		FreeAttempts:    2,
+		BaseDelay:       time.Hour,
+		MaxDelay:        time.Hour,
+		LockoutAttempts: 5,
+		LockoutDuration: 2 * time.Hour,
+		Window:          time.Hour,
+	})
+
+	tests := []struct {
+		name         string
+		wantAttempts int
+		wantBlocked  bool
+		wantDelayed  bool
+	}{
+		{"first free attempt", 1, false, false},
+		{"last free attempt", 2, false, false},
+		{"delayed attempt", 3, false, true},
+		{"blocked attempt isn't counted", 3, true, true},
+	}
+
+	for _, tt := range tests {
+		hit, err := l.Hit("key")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if hit.Attempts != tt.wantAttempts || hit.Blocked != tt.wantBlocked || (hit.RetryAfter > 0) != tt.wantDelayed {
+			t.Errorf("%s: got %+v, want attempts %d, blocked %v, delayed %v",
+				tt.name, hit, tt.wantAttempts, tt.wantBlocked, tt.wantDelayed)
+		}
+	}
+
+	if hit, _ := l.Hit("another"); hit.Attempts != 1 || hit.Blocked {
+		t.Errorf("keys aren't separated: got %+v", hit)
+	}
+
+	if err := l.Reset("key"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hit, _ := l.Hit("key"); hit.Attempts != 1 || hit.Blocked {
+		t.Errorf("attempts aren't forgotten after reset: got %+v", hit)
+	}
+}
+
+func TestLimiterHitLockout(t *testing.T) {
+	l := NewLimiter(models.LimitPolicy{
+		FreeAttempts:    5,
+		LockoutAttempts: 2,
+		LockoutDuration: time.Hour,
+		Window:          time.Hour,
+	})
+
+	if hit, _ := l.Hit("key"); hit.LockedOut || hit.RetryAfter != 0 {
+		t.Errorf("first attempt: got %+v", hit)
+	}
+	if hit, _ := l.Hit("key"); !hit.LockedOut || hit.RetryAfter != time.Hour {
+		t.Errorf("locking attempt: got %+v", hit)
+	}
+	if hit, _ := l.Hit("key"); !hit.Blocked || hit.LockedOut {
+		t.Errorf("attempt while locked out: got %+v", hit)
+	}
+}
+
+func TestLimiterExpired(t *testing.T) {
+	l := NewLimiter(models.LimitPolicy{Window: time.Hour})
+	start := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		entry entry
+		now   time.Time
+		want  bool
+	}{
+		{"inside window", entry{attempts: 1, lastAttempt: start}, start.Add(59 * time.Minute), false},
+		{"window passed", entry{attempts: 1, lastAttempt: start}, start.Add(61 * time.Minute), true},
+		{"blocked", entry{attempts: 5, lastAttempt: start, blockedUntil: start.Add(2 * time.Hour)}, start.Add(90 * time.Minute), false},
+		{"window after block", entry{attempts: 5, lastAttempt: start, blockedUntil: start.Add(2 * time.Hour)}, start.Add(150 * time.Minute), false},
+		{"window after block passed", entry{attempts: 5, lastAttempt: start, blockedUntil: start.Add(2 * time.Hour)}, start.Add(181 * time.Minute), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := l.expired(&tt.entry, tt.now); got != tt.want {
+				t.Errorf("expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimiterPrune(t *testing.T) {
+	l := NewLimiter(models.LimitPolicy{Window: time.Hour})
+	start := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	l.entries["old"] = &entry{attempts: 1, lastAttempt: start.Add(-2 * time.Hour)}
+	l.entries["fresh"] = &entry{attempts: 1, lastAttempt: start.Add(-time.Minute)}
+
+	l.prune(start)
+	if _, ok := l.entries["old"]; ok {
+		t.Error("expired entry isn't pruned")
+	}
+	if _, ok := l.entries["fresh"]; !ok {
+		t.Error("fresh entry is pruned")
+	}
+
+	l.entries["old"] = &entry{attempts: 1, lastAttempt: start.Add(-2 * time.Hour)}
+	l.prune(start.Add(time.Minute))
+	if _, ok := l.entries["old"]; !ok {
+		t.Error("entries are pruned more than once in window")
+	}
+
+	l.prune(start.Add(time.Hour))
+	if _, ok := l.entries["old"]; ok {
+		t.Error("expired entry isn't pruned after window")
+	}
+	if _, ok := l.entries["fresh"]; ok {
+		t.Error("entry expired meanwhile isn't pruned")
+	}
+}
@@ -0,0 +1,91 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
+
+// maxHitRetries limits retries of attempt conflicting with concurrent ones
+const maxHitRetries = 10
+
+// Limiter implements auth.Limiter, so limits are shared by all replicas.
+// Attempts are counted in key which expires after window without attempts,
+// block is a separate key which expires when key may be used again
+type Limiter struct {
+	db     *redis.Client
+	prefix string
+	policy models.LimitPolicy
+}
+
+// NewLimiter creates limiter, prefix separates keys of limiters with different policies
+func NewLimiter(db *redis.Client, prefix string, policy models.LimitPolicy) *Limiter {
+	return &Limiter{
+		db:     db,
+		prefix: prefix,
+		policy: policy,
+	}
+}
+
+func (l *Limiter) attemptsKey(key string) string {
+	return fmt.Sprintf("limiter:%s:attempts:%s", l.prefix, key)
+}
+
+func (l *Limiter) blockKey(key string) string {
+	return fmt.Sprintf("limiter:%s:block:%s", l.prefix, key)
+}
+
+// Hit is optimistic transaction: if key is changed by concurrent attempt, it's retried
+func (l *Limiter) Hit(key string) (models.LimitHit, error) {
+	attemptsKey, blockKey := l.attemptsKey(key), l.blockKey(key)
+
+	var hit models.LimitHit
+	hitTx := func(tx *redis.Tx) error {
+		ttl, err := tx.PTTL(context.TODO(), blockKey).Result()
+		if err != nil {
+			return err
+		}
+		attempts, err := tx.Get(context.TODO(), attemptsKey).Int()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+
+		// Negative TTL means that key doesn't exist
+		if ttl > 0 {
+			hit = models.NewBlockedLimitHit(attempts, ttl)
+			return nil
+		}
+
+		attempts++
+		hit = models.NewLimitHit(l.policy, attempts)
+
+		_, err = tx.TxPipelined(context.TODO(), func(pipe redis.Pipeliner) error {
+			pipe.Set(context.TODO(), attemptsKey, attempts, hit.RetryAfter+l.policy.Window)
+			if hit.RetryAfter > 0 {
+				pipe.Set(context.TODO(), blockKey, attempts, hit.RetryAfter)
+			}
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < maxHitRetries; i++ {
+		err := l.db.Watch(context.TODO(), hitTx, attemptsKey, blockKey)
+		if err == redis.TxFailedErr {
+			continue
+		}
+		if err != nil {
+			return models.LimitHit{}, err
+		}
+
+		return hit, nil
+	}
+
+	return models.LimitHit{}, fmt.Errorf("(limiter) too many concurrent attempts of %s", key)
+}
+
+func (l *Limiter) Reset(key string) error {
+	return l.db.Del(context.TODO(), l.attemptsKey(key), l.blockKey(key)).Err()
+}
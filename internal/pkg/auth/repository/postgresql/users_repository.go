@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"github.com/lib/pq"
+	"time"
 
 	"github.com/gofrs/uuid/v5"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"github.com/yarikTri/archipelago-notes-api/internal/common/repository"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
 )
 
 // UsersRepository implements auth.UsersRepository
@@ -38,7 +40,7 @@ func (ur *UsersRepository) GetUserIDAndPasswordByEmail(email string) (uuid.UUID,
 	var creds userIDAndPasswordRaw
 	if err := ur.db.Get(&creds, query, email); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return uuid.Max, "", fmt.Errorf("(repo) user not found: %v", err)
+			return uuid.Max, "", fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: email}, err)
 		}
 
 		return uuid.Max, "", fmt.Errorf("(repo) failed to exec query: %w", err)
@@ -49,6 +51,25 @@ func (ur *UsersRepository) GetUserIDAndPasswordByEmail(email string) (uuid.UUID,
 	return id, creds.Password, nil
 }
 
+func (ur *UsersRepository) GetPasswordByID(userID uuid.UUID) (string, error) {
+	query := fmt.Sprint(
+		`SELECT password_hash
+			FROM "user"
+			WHERE id = $1`,
+	)
+
+	var passwordHash string
+	if err := ur.db.Get(&passwordHash, query, userID.String()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: userID}, err)
+		}
+
+		return "", fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return passwordHash, nil
+}
+
 func (ur *UsersRepository) CreateUser(email, name, passwordHash string) (uuid.UUID, error) {
 	query := fmt.Sprint(
 		`INSERT INTO "user" (email, name, password_hash) VALUES ($1, $2, $3) RETURNING id`,
@@ -87,3 +108,327 @@ func (ur *UsersRepository) DeleteUser(userID uuid.UUID) error {
 
 	return nil
 }
+
+func (ur *UsersRepository) UpdatePassword(userID uuid.UUID, passwordHash string) error {
+	query := fmt.Sprint(
+		`UPDATE "user" SET password_hash = $2 WHERE id = $1`,
+	)
+
+	resExec, err := ur.db.Exec(query, userID.String(), passwordHash)
+	if err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+	updated, err := resExec.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to check RowsAffected: %w", err)
+	}
+
+	if updated == 0 {
+		return fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: userID})
+	}
+
+	return nil
+}
+
+func (ur *UsersRepository) CreatePasswordReset(userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	tx, err := ur.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	deleteQuery := fmt.Sprint(
+		`DELETE
+		FROM password_reset
+		WHERE user_id = $1 AND used_at IS NULL`,
+	)
+
+	if _, err := tx.Exec(deleteQuery, userID.String()); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	query := fmt.Sprint(
+		`INSERT INTO password_reset (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)`,
+	)
+
+	if _, err := tx.Exec(query, userID.String(), tokenHash, expiresAt); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (ur *UsersRepository) GetLastPasswordResetTime(userID uuid.UUID) (*time.Time, error) {
+	query := fmt.Sprint(
+		`SELECT MAX(created_at)
+			FROM password_reset
+			WHERE user_id = $1`,
+	)
+
+	var lastRequestedAt *time.Time
+	if err := ur.db.Get(&lastRequestedAt, query, userID.String()); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return lastRequestedAt, nil
+}
+
+// ResetPasswordByTokenHash marks token used, so it can't be used twice even concurrently
+func (ur *UsersRepository) ResetPasswordByTokenHash(tokenHash, passwordHash string) (uuid.UUID, error) {
+	tx, err := ur.db.Beginx()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	useQuery := fmt.Sprint(
+		`UPDATE password_reset
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		RETURNING user_id`,
+	)
+
+	var userID uuid.UUID
+	if err := tx.Get(&userID, useQuery, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: "reset token"}, err)
+		}
+
+		return uuid.Nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	query := fmt.Sprint(
+		`UPDATE "user" SET password_hash = $2 WHERE id = $1`,
+	)
+
+	if _, err := tx.Exec(query, userID.String(), passwordHash); err != nil {
+		return uuid.Nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return userID, nil
+}
+
+func (ur *UsersRepository) GetTOTP(userID uuid.UUID) (*models.UserTOTP, error) {
+	query := fmt.Sprint(
+		`SELECT user_id, secret, enabled_at, last_used_step
+			FROM user_totp
+			WHERE user_id = $1`,
+	)
+
+	var userTOTP models.UserTOTP
+	if err := ur.db.Get(&userTOTP, query, userID.String()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: userID}, err)
+		}
+
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return &userTOTP, nil
+}
+
+// SaveTOTPSecret stores secret in plaintext, see models.UserTOTP
+func (ur *UsersRepository) SaveTOTPSecret(userID uuid.UUID, secret string) (bool, error) {
+	query := fmt.Sprint(
+		`INSERT INTO user_totp (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, created_at = CURRENT_TIMESTAMP, last_used_step = 0
+		WHERE user_totp.enabled_at IS NULL`,
+	)
+
+	resExec, err := ur.db.Exec(query, userID.String(), secret)
+	if err != nil {
+		return false, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+	saved, err := resExec.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("(repo) failed to check RowsAffected: %w", err)
+	}
+
+	return saved > 0, nil
+}
+
+func (ur *UsersRepository) EnableTOTP(userID uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	tx, err := ur.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprint(
+		`UPDATE user_totp
+		SET enabled_at = CURRENT_TIMESTAMP, last_used_step = $2
+		WHERE user_id = $1 AND enabled_at IS NULL`,
+	)
+
+	resExec, err := tx.Exec(query, userID.String(), step)
+	if err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+	enabled, err := resExec.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to check RowsAffected: %w", err)
+	}
+
+	if enabled == 0 {
+		return fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: userID})
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UseTOTPStep only moves used step forward, so concurrent logins with the same code can't both pass
+func (ur *UsersRepository) UseTOTPStep(userID uuid.UUID, step int64) (bool, error) {
+	query := fmt.Sprint(
+		`UPDATE user_totp
+		SET last_used_step = $2
+		WHERE user_id = $1 AND enabled_at IS NOT NULL AND last_used_step < $2`,
+	)
+
+	resExec, err := ur.db.Exec(query, userID.String(), step)
+	if err != nil {
+		return false, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+	used, err := resExec.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("(repo) failed to check RowsAffected: %w", err)
+	}
+
+	return used > 0, nil
+}
+
+func (ur *UsersRepository) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	query := fmt.Sprint(
+		`UPDATE totp_recovery_code
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`,
+	)
+
+	resExec, err := ur.db.Exec(query, userID.String(), codeHash)
+	if err != nil {
+		return false, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+	used, err := resExec.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("(repo) failed to check RowsAffected: %w", err)
+	}
+
+	return used > 0, nil
+}
+
+func (ur *UsersRepository) CountRecoveryCodes(userID uuid.UUID) (int, error) {
+	query := fmt.Sprint(
+		`SELECT COUNT(*)
+			FROM totp_recovery_code
+			WHERE user_id = $1 AND used_at IS NULL`,
+	)
+
+	var count int
+	if err := ur.db.Get(&count, query, userID.String()); err != nil {
+		return 0, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return count, nil
+}
+
+func (ur *UsersRepository) ReplaceRecoveryCodes(userID uuid.UUID, recoveryCodeHashes []string) error {
+	tx, err := ur.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func replaceRecoveryCodes(tx *sqlx.Tx, userID uuid.UUID, recoveryCodeHashes []string) error {
+	deleteQuery := fmt.Sprint(
+		`DELETE
+		FROM totp_recovery_code
+		WHERE user_id = $1`,
+	)
+
+	if _, err := tx.Exec(deleteQuery, userID.String()); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	query := fmt.Sprint(
+		`INSERT INTO totp_recovery_code (user_id, code_hash)
+		SELECT $1, UNNEST($2::VARCHAR[])`,
+	)
+
+	if _, err := tx.Exec(query, userID.String(), pq.Array(recoveryCodeHashes)); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (ur *UsersRepository) DeleteTOTP(userID uuid.UUID) error {
+	tx, err := ur.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	codesQuery := fmt.Sprint(
+		`DELETE
+		FROM totp_recovery_code
+		WHERE user_id = $1`,
+	)
+
+	if _, err := tx.Exec(codesQuery, userID.String()); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	query := fmt.Sprint(
+		`DELETE
+		FROM user_totp
+		WHERE user_id = $1`,
+	)
+
+	resExec, err := tx.Exec(query, userID.String())
+	if err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+	deleted, err := resExec.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to check RowsAffected: %w", err)
+	}
+
+	if deleted == 0 {
+		return fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: userID})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -2,12 +2,21 @@ package redis
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
 	"github.com/gofrs/uuid/v5"
 	"github.com/redis/go-redis/v9"
-	"time"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/repository"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
 )
 
-// SessionsRepository implements auth.SessionsRepository
+// SessionsRepository implements auth.SessionsRepository.
+// Session id key stores user id, session's metadata is stored in hash with the same expiration
+// and user's sessions are indexed in set, which is cleaned up from expired sessions on listing
 type SessionsRepository struct {
 	db *redis.Client
 }
@@ -18,14 +27,325 @@ func NewSessionsRepository(db *redis.Client) *SessionsRepository {
 	}
 }
 
+func sessionKey(sessionID string) string {
+	return fmt.Sprintf("session:%s", sessionID)
+}
+
+func userSessionsKey(userID uuid.UUID) string {
+	return fmt.Sprintf("user_sessions:%s", userID.String())
+}
+
+// sessionsValidAfterKey is time user's sessions were ended at,
+// sessions created before user's sessions were indexed are rejected by it
+func sessionsValidAfterKey(userID uuid.UUID) string {
+	return fmt.Sprintf("sessions_valid_after:%s", userID.String())
+}
+
+// pendingLoginKey is hash of user id and failed attempts of login waiting for second factor
+func pendingLoginKey(token string) string {
+	return fmt.Sprintf("pending_login:%s", token)
+}
+
+const (
+	attemptsField = "attempts"
+
+	userIDField     = "user_id"
+	createdAtField  = "created_at"
+	lastSeenAtField = "last_seen_at"
+	userAgentField  = "user_agent"
+	ipField         = "ip"
+)
+
+// GetUserIDBySessionID returns NotFoundError for unknown and expired sessions
 func (sr *SessionsRepository) GetUserIDBySessionID(sessionID string) (uuid.UUID, error) {
-	return uuid.FromString(sr.db.Get(context.TODO(), sessionID).String())
+	userID, err := sr.db.Get(context.TODO(), sessionID).Result()
+	if err == redis.Nil {
+		return uuid.Nil, fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: "session"})
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return uuid.FromString(userID)
+}
+
+// CreateSession prolongs user's sessions index, so it expires after the last session of user
+func (sr *SessionsRepository) CreateSession(session models.Session, expiration, maxExpiration time.Duration) error {
+	_, err := sr.db.TxPipelined(context.TODO(), func(pipe redis.Pipeliner) error {
+		pipe.Set(context.TODO(), session.ID, session.UserID.String(), expiration)
+		pipe.HSet(context.TODO(), sessionKey(session.ID),
+			userIDField, session.UserID.String(),
+			createdAtField, session.CreatedAt.Unix(),
+			lastSeenAtField, session.LastSeenAt.Unix(),
+			userAgentField, session.Client.UserAgent,
+			ipField, session.Client.IP,
+		)
+		pipe.Expire(context.TODO(), sessionKey(session.ID), expiration)
+		pipe.SAdd(context.TODO(), userSessionsKey(session.UserID), session.ID)
+		pipe.Expire(context.TODO(), userSessionsKey(session.UserID), maxExpiration)
+		return nil
+	})
+
+	return err
+}
+
+// GetSession returns NotFoundError for expired sessions and sessions created without metadata
+func (sr *SessionsRepository) GetSession(sessionID string) (*models.Session, error) {
+	sessions, err := sr.getSessions([]string{sessionID})
+	if err != nil {
+		return nil, err
+	}
+
+	if sessions[0] == nil {
+		return nil, fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: "session"})
+	}
+
+	return sessions[0], nil
+}
+
+// getSessions returns nil in place of expired sessions
+func (sr *SessionsRepository) getSessions(sessionIDs []string) ([]*models.Session, error) {
+	userIDCmds := make([]*redis.StringCmd, len(sessionIDs))
+	metaCmds := make([]*redis.MapStringStringCmd, len(sessionIDs))
+	_, err := sr.db.Pipelined(context.TODO(), func(pipe redis.Pipeliner) error {
+		for i, sessionID := range sessionIDs {
+			userIDCmds[i] = pipe.Get(context.TODO(), sessionID)
+			metaCmds[i] = pipe.HGetAll(context.TODO(), sessionKey(sessionID))
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	sessions := make([]*models.Session, len(sessionIDs))
+	for i, sessionID := range sessionIDs {
+		if userIDCmds[i].Err() == redis.Nil {
+			continue
+		}
+		if userIDCmds[i].Err() != nil {
+			return nil, userIDCmds[i].Err()
+		}
+
+		meta, err := metaCmds[i].Result()
+		if err != nil {
+			return nil, err
+		}
+		if meta[userIDField] == "" {
+			continue
+		}
+
+		session, err := sessionFromMeta(sessionID, meta)
+		if err != nil {
+			return nil, err
+		}
+		sessions[i] = session
+	}
+
+	return sessions, nil
+}
+
+func sessionFromMeta(sessionID string, meta map[string]string) (*models.Session, error) {
+	userID, err := uuid.FromString(meta[userIDField])
+	if err != nil {
+		return nil, fmt.Errorf("(repo) invalid session's user id: %w", err)
+	}
+	createdAt, err := strconv.ParseInt(meta[createdAtField], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("(repo) invalid session's creation time: %w", err)
+	}
+	lastSeenAt, err := strconv.ParseInt(meta[lastSeenAtField], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("(repo) invalid session's last seen time: %w", err)
+	}
+
+	return &models.Session{
+		ID:         sessionID,
+		UserID:     userID,
+		CreatedAt:  time.Unix(createdAt, 0),
+		LastSeenAt: time.Unix(lastSeenAt, 0),
+		Client: models.SessionClient{
+			UserAgent: meta[userAgentField],
+			IP:        meta[ipField],
+		},
+	}, nil
+}
+
+// touchSessionScript prolongs session only if it still exists,
+// so metadata of session deleted meanwhile isn't recreated
+var touchSessionScript = redis.NewScript(`
+if redis.call("PEXPIRE", KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call("HSET", KEYS[2], ARGV[2], ARGV[3])
+redis.call("PEXPIRE", KEYS[2], ARGV[1])
+return 1
+`)
+
+// TouchSession updates last seen time of session and sets its new expiration
+func (sr *SessionsRepository) TouchSession(sessionID string, lastSeenAt time.Time, expiration time.Duration) error {
+	touched, err := touchSessionScript.Run(context.TODO(), sr.db,
+		[]string{sessionID, sessionKey(sessionID)},
+		expiration.Milliseconds(), lastSeenAtField, lastSeenAt.Unix(),
+	).Int()
+	if err != nil {
+		return err
+	}
+
+	if touched == 0 {
+		return fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: "session"})
+	}
+
+	return nil
 }
 
-func (sr *SessionsRepository) CreateSession(sessionID string, userID uuid.UUID, expiration time.Duration) error {
-	return sr.db.Set(context.TODO(), sessionID, userID.String(), expiration).Err()
+// ListUserSessions returns user's sessions which haven't expired yet, sorted from the latest
+func (sr *SessionsRepository) ListUserSessions(userID uuid.UUID) ([]*models.Session, error) {
+	sessionIDs, err := sr.db.SMembers(context.TODO(), userSessionsKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(sessionIDs) == 0 {
+		return []*models.Session{}, nil
+	}
+
+	sessions, err := sr.getSessions(sessionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	alive := make([]*models.Session, 0, len(sessions))
+	expired := make([]string, 0)
+	for i, session := range sessions {
+		if session == nil {
+			expired = append(expired, sessionIDs[i])
+			continue
+		}
+		alive = append(alive, session)
+	}
+
+	if len(expired) > 0 {
+		if err := sr.db.SRem(context.TODO(), userSessionsKey(userID), expired).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(alive, func(i, j int) bool {
+		return alive[i].LastSeenAt.After(alive[j].LastSeenAt)
+	})
+
+	return alive, nil
 }
 
 func (sr *SessionsRepository) DeleteSession(sessionID string) error {
-	return sr.db.Del(context.TODO(), sessionID).Err()
+	userID, err := sr.GetUserIDBySessionID(sessionID)
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = sr.db.TxPipelined(context.TODO(), func(pipe redis.Pipeliner) error {
+		pipe.Del(context.TODO(), sessionID, sessionKey(sessionID))
+		pipe.SRem(context.TODO(), userSessionsKey(userID), sessionID)
+		return nil
+	})
+
+	return err
+}
+
+func (sr *SessionsRepository) DeleteUserSessions(userID uuid.UUID, exceptSessionID string) error {
+	sessionIDs, err := sr.db.SMembers(context.TODO(), userSessionsKey(userID)).Result()
+	if err != nil {
+		return err
+	}
+
+	toDelete := make([]string, 0, len(sessionIDs))
+	keys := make([]string, 0, 2*len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		if sessionID != exceptSessionID {
+			toDelete = append(toDelete, sessionID)
+			keys = append(keys, sessionID, sessionKey(sessionID))
+		}
+	}
+	if len(toDelete) == 0 {
+		return nil
+	}
+
+	_, err = sr.db.TxPipelined(context.TODO(), func(pipe redis.Pipeliner) error {
+		pipe.Del(context.TODO(), keys...)
+		pipe.SRem(context.TODO(), userSessionsKey(userID), toDelete)
+		return nil
+	})
+
+	return err
+}
+
+func (sr *SessionsRepository) SetSessionsValidAfter(userID uuid.UUID, validAfter time.Time, expiration time.Duration) error {
+	return sr.db.Set(context.TODO(), sessionsValidAfterKey(userID), validAfter.Unix(), expiration).Err()
+}
+
+func (sr *SessionsRepository) GetSessionsValidAfter(userID uuid.UUID) (*time.Time, error) {
+	validAfter, err := sr.db.Get(context.TODO(), sessionsValidAfterKey(userID)).Int64()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t := time.Unix(validAfter, 0)
+	return &t, nil
+}
+
+func (sr *SessionsRepository) CreatePendingLogin(token string, userID uuid.UUID, expiration time.Duration) error {
+	_, err := sr.db.TxPipelined(context.TODO(), func(pipe redis.Pipeliner) error {
+		pipe.HSet(context.TODO(), pendingLoginKey(token), userIDField, userID.String(), attemptsField, 0)
+		pipe.Expire(context.TODO(), pendingLoginKey(token), expiration)
+		return nil
+	})
+
+	return err
+}
+
+func (sr *SessionsRepository) GetPendingLogin(token string) (uuid.UUID, error) {
+	userID, err := sr.db.HGet(context.TODO(), pendingLoginKey(token), userIDField).Result()
+	if err == redis.Nil {
+		return uuid.Nil, fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: "pending login"})
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return uuid.FromString(userID)
+}
+
+// HitPendingLogin returns NotFoundError if login expired meanwhile,
+// key recreated by increment without expiration is deleted then
+func (sr *SessionsRepository) HitPendingLogin(token string) (int, error) {
+	var attemptsCmd *redis.IntCmd
+	var ttlCmd *redis.DurationCmd
+	_, err := sr.db.TxPipelined(context.TODO(), func(pipe redis.Pipeliner) error {
+		attemptsCmd = pipe.HIncrBy(context.TODO(), pendingLoginKey(token), attemptsField, 1)
+		ttlCmd = pipe.TTL(context.TODO(), pendingLoginKey(token))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if ttlCmd.Val() < 0 {
+		if err := sr.DeletePendingLogin(token); err != nil {
+			return 0, err
+		}
+		return 0, fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: "pending login"})
+	}
+
+	return int(attemptsCmd.Val()), nil
+}
+
+func (sr *SessionsRepository) DeletePendingLogin(token string) error {
+	return sr.db.Del(context.TODO(), pendingLoginKey(token)).Err()
 }
@@ -2,30 +2,92 @@ package usecase
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/go-park-mail-ru/2023_1_Technokaif/pkg/logger"
 	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/clients/invitations/email"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/repository"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/totp"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/audit"
 	"github.com/yarikTri/archipelago-notes-api/internal/pkg/auth"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/users"
 	"golang.org/x/crypto/bcrypt"
 )
 
 const (
 	sessionIDLength = 32
-	sessionTTL      = 90 * 24 * time.Hour
+	// sessionTTL limits session's lifetime, even if it's used every day
+	sessionTTL = 90 * 24 * time.Hour
+	// sessionTouchPeriod is precision of session's last seen time,
+	// so session isn't prolonged on every request
+	sessionTouchPeriod = time.Minute
+
+	resetTokenLength   = 32
+	resetTokenTTL      = time.Hour
+	resetRequestPeriod = time.Minute
+
+	pendingLoginTokenLength = 32
+	// pendingLoginTTL is time user has to enter second factor after password
+	pendingLoginTTL = 5 * time.Minute
+	// pendingLoginMaxAttempts is number of wrong codes pending login is dropped after
+	pendingLoginMaxAttempts = 5
+
+	totpIssuer = "Archipelago"
+	// totpSkew is number of steps code is accepted before and after current one, covering clock drift
+	totpSkew = 1
+
+	recoveryCodesCount = 10
+	// recoveryCodeLength is number of random bytes, 80 bits make unsalted hash of code as strong as password hash.
+	// Base32 of them is split in groups of recoveryCodeGroupLength with dashes
+	recoveryCodeLength      = 10
+	recoveryCodeGroupLength = 4
+
+	passwordMinLength = 8
+	// bcrypt ignores bytes after the 72nd
+	passwordMaxLength = 72
 )
 
 // Usecase implements auth.Usecase
 type Usecase struct {
 	sessionsRepo auth.SessionsRepository
 	usersRepo    auth.UsersRepository
+	auditRepo    audit.Repository
+	usersUsecase users.Usecase
+
+	passwordResetClient email.IEmailPasswordResetClient
+	lockoutNoticeClient email.IEmailLockoutNoticeClient
+
+	limiters auth.Limiters
+
+	// sessionIdleTTL is time session expires after if it isn't used
+	sessionIdleTTL time.Duration
+
+	logger logger.Logger
 }
 
-func NewUsecase(sr auth.SessionsRepository, ur auth.UsersRepository) *Usecase {
+func NewUsecase(sr auth.SessionsRepository, ur auth.UsersRepository, ar audit.Repository, uu users.Usecase,
+	prc email.IEmailPasswordResetClient, lnc email.IEmailLockoutNoticeClient, l auth.Limiters,
+	sessionIdleTTL time.Duration, lg logger.Logger) *Usecase {
 	return &Usecase{
-		sessionsRepo: sr,
-		usersRepo:    ur,
+		sessionsRepo:        sr,
+		usersRepo:           ur,
+		auditRepo:           ar,
+		usersUsecase:        uu,
+		passwordResetClient: prc,
+		lockoutNoticeClient: lnc,
+		limiters:            l,
+		sessionIdleTTL:      sessionIdleTTL,
+		logger:              lg,
 	}
 }
 
@@ -43,17 +105,103 @@ func (u *Usecase) generateSessionID(length uint) string {
 }
 
 func (u *Usecase) GetUserIDBySessionID(sessionID string) (uuid.UUID, error) {
-	return u.sessionsRepo.GetUserIDBySessionID(sessionID)
+	session, err := u.sessionsRepo.GetSession(sessionID)
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return u.getLegacySessionUserID(sessionID)
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) < sessionTouchPeriod {
+		return session.UserID, nil
+	}
+
+	expiration := u.sessionExpiration(session, now)
+	if expiration <= 0 {
+		if err := u.sessionsRepo.DeleteSession(sessionID); err != nil {
+			return uuid.Nil, err
+		}
+		return uuid.Nil, auth.ErrSessionNotFound
+	}
+
+	if err := u.sessionsRepo.TouchSession(sessionID, now, expiration); err != nil {
+		return uuid.Nil, err
+	}
+
+	return session.UserID, nil
+}
+
+// getLegacySessionUserID resolves session created before metadata was stored, such session lives
+// until its initial expiration. It isn't indexed by user, so it's rejected once user's sessions were ended
+func (u *Usecase) getLegacySessionUserID(sessionID string) (uuid.UUID, error) {
+	userID, err := u.sessionsRepo.GetUserIDBySessionID(sessionID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	validAfter, err := u.sessionsRepo.GetSessionsValidAfter(userID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if validAfter != nil {
+		if err := u.sessionsRepo.DeleteSession(sessionID); err != nil {
+			return uuid.Nil, err
+		}
+		return uuid.Nil, auth.ErrSessionNotFound
+	}
+
+	return userID, nil
+}
+
+// endUserSessions deletes user's sessions except exceptSessionID, which may be empty.
+// Cutoff time is kept as long as sessions not indexed by user may live
+func (u *Usecase) endUserSessions(userID uuid.UUID, exceptSessionID string) error {
+	if err := u.sessionsRepo.SetSessionsValidAfter(userID, time.Now(), sessionTTL); err != nil {
+		return err
+	}
+
+	return u.sessionsRepo.DeleteUserSessions(userID, exceptSessionID)
+}
+
+// sessionExpiration is idle timeout, which is cut so session doesn't outlive sessionTTL
+func (u *Usecase) sessionExpiration(session *models.Session, now time.Time) time.Duration {
+	expiration := u.sessionIdleTTL
+	if untilEnd := session.CreatedAt.Add(sessionTTL).Sub(now); untilEnd < expiration {
+		expiration = untilEnd
+	}
+
+	return expiration
+}
+
+func (u *Usecase) createSession(userID uuid.UUID, client models.SessionClient) (string, error) {
+	session := models.NewSession(u.generateSessionID(sessionIDLength), userID, client)
+	if err := u.sessionsRepo.CreateSession(session, u.sessionExpiration(&session, session.CreatedAt), sessionTTL); err != nil {
+		return "", err
+	}
+
+	return session.ID, nil
 }
 
-func (u *Usecase) SignUp(email, name, password string) (string, uuid.UUID, time.Duration, error) {
+// SignUp is throttled by ip, every attempt with acceptable password is counted
+func (u *Usecase) SignUp(email, name, password string, client models.SessionClient) (string, uuid.UUID, time.Duration, error) {
+	if err := validatePassword(password); err != nil {
+		return "", uuid.Max, 0, err
+	}
+
+	if _, err := hitLimit(u.limiters.SignUpByIP, client.IP); err != nil {
+		return "", uuid.Max, 0, err
+	}
+
 	userID, err := u.usersRepo.CreateUser(email, name, u.getPasswordHash(password))
 	if err != nil {
 		return "", uuid.Max, 0, err
 	}
 
-	sessionID := u.generateSessionID(sessionIDLength)
-	if err := u.sessionsRepo.CreateSession(sessionID, userID, sessionTTL); err != nil {
+	sessionID, err := u.createSession(userID, client)
+	if err != nil {
 
 		if errDelete := u.usersRepo.DeleteUser(userID); errDelete != nil {
 			// VERY SUS MOMENT
@@ -63,27 +211,601 @@ func (u *Usecase) SignUp(email, name, password string) (string, uuid.UUID, time.
 		return "", uuid.Max, 0, err
 	}
 
+	u.recordEvent(userID, userID, models.SignedUpAuditAction)
+
+	// User is already signed up and may request confirmation again, so failed sending doesn't fail signup.
+	// Email is sent in background, so signup doesn't wait for mail server
+	go func() {
+		if err := u.usersUsecase.SendEmailConfirmation(userID); err != nil {
+			u.logger.Errorf("failed to send email confirmation to user %s: %v", userID, err)
+		}
+	}()
+
 	return sessionID, userID, sessionTTL, nil
 }
 
-func (u *Usecase) Login(email, password string) (string, uuid.UUID, time.Duration, error) {
+// Login is throttled by ip and by email, every attempt is counted before password is checked,
+// so concurrent attempts can't exceed limit. Successful login forgets attempts of email.
+// Password isn't checked at all while throttled
+func (u *Usecase) Login(email, password string, client models.SessionClient) (string, uuid.UUID, time.Duration, error) {
+	if _, err := hitLimit(u.limiters.LoginByIP, client.IP); err != nil {
+		return "", uuid.Max, 0, err
+	}
+	emailHit, err := hitLimit(u.limiters.LoginByEmail, emailLimitKey(email))
+	if err != nil {
+		return "", uuid.Max, 0, err
+	}
+
 	userID, passwordHash, err := u.usersRepo.GetUserIDAndPasswordByEmail(email)
 	if err != nil {
 		return "", uuid.Max, 0, err
 	}
 
 	if err = bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)); err != nil {
+		u.recordEvent(uuid.Nil, userID, models.LoginFailedAuditAction)
+		u.notifyLockout(email, userID, emailHit)
 		return "", uuid.Max, 0, fmt.Errorf("passwords dont match")
 	}
 
-	sessionID := u.generateSessionID(sessionIDLength)
-	if err := u.sessionsRepo.CreateSession(sessionID, userID, sessionTTL); err != nil {
+	if err := u.limiters.LoginByEmail.Reset(emailLimitKey(email)); err != nil {
 		return "", uuid.Max, 0, err
 	}
 
+	userTOTP, err := u.getTOTP(userID)
+	if err != nil {
+		return "", uuid.Max, 0, err
+	}
+	if userTOTP.Enabled() {
+		token := u.generateSessionID(pendingLoginTokenLength)
+		if token == "" {
+			return "", uuid.Max, 0, errors.New("(usecase) failed to generate pending login token")
+		}
+
+		if err := u.sessionsRepo.CreatePendingLogin(token, userID, pendingLoginTTL); err != nil {
+			return "", uuid.Max, 0, err
+		}
+
+		return "", uuid.Max, 0, &auth.TwoFactorRequiredError{Token: token, ExpiresIn: pendingLoginTTL}
+	}
+
+	sessionID, err := u.createSession(userID, client)
+	if err != nil {
+		return "", uuid.Max, 0, err
+	}
+
+	u.recordEvent(userID, userID, models.LoggedInAuditAction)
+
 	return sessionID, userID, sessionTTL, nil
 }
 
+// LoginTwoFactor is throttled by ip like Login, every code is counted by ip and by pending login
+// before it's checked, pending login is dropped after too many codes
+func (u *Usecase) LoginTwoFactor(token, code string, client models.SessionClient) (string, uuid.UUID, time.Duration, error) {
+	if _, err := hitLimit(u.limiters.LoginByIP, client.IP); err != nil {
+		return "", uuid.Max, 0, err
+	}
+
+	userID, err := u.sessionsRepo.GetPendingLogin(token)
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return "", uuid.Max, 0, auth.ErrInvalidPendingLogin
+	}
+	if err != nil {
+		return "", uuid.Max, 0, err
+	}
+
+	attempts, err := u.sessionsRepo.HitPendingLogin(token)
+	if errors.As(err, &notFoundErr) {
+		return "", uuid.Max, 0, auth.ErrInvalidPendingLogin
+	}
+	if err != nil {
+		return "", uuid.Max, 0, err
+	}
+	if attempts > pendingLoginMaxAttempts {
+		if err := u.sessionsRepo.DeletePendingLogin(token); err != nil {
+			return "", uuid.Max, 0, err
+		}
+		return "", uuid.Max, 0, auth.ErrInvalidPendingLogin
+	}
+
+	userTOTP, err := u.getTOTP(userID)
+	if err != nil {
+		return "", uuid.Max, 0, err
+	}
+	if !userTOTP.Enabled() {
+		// Second factor was disabled meanwhile, so login is started again with password only
+		if err := u.sessionsRepo.DeletePendingLogin(token); err != nil {
+			return "", uuid.Max, 0, err
+		}
+		return "", uuid.Max, 0, auth.ErrInvalidPendingLogin
+	}
+
+	ok, err := u.verifySecondFactor(userTOTP, code)
+	if err != nil {
+		return "", uuid.Max, 0, err
+	}
+	if !ok {
+		u.recordEvent(uuid.Nil, userID, models.LoginFailedAuditAction)
+		if attempts == pendingLoginMaxAttempts {
+			if err := u.sessionsRepo.DeletePendingLogin(token); err != nil {
+				return "", uuid.Max, 0, err
+			}
+		}
+		return "", uuid.Max, 0, auth.ErrInvalidTwoFactorCode
+	}
+
+	if err := u.sessionsRepo.DeletePendingLogin(token); err != nil {
+		return "", uuid.Max, 0, err
+	}
+
+	sessionID, err := u.createSession(userID, client)
+	if err != nil {
+		return "", uuid.Max, 0, err
+	}
+
+	u.recordEvent(userID, userID, models.LoggedInAuditAction)
+
+	return sessionID, userID, sessionTTL, nil
+}
+
+// notifyLockout notifies user when failed attempt has locked the account out.
+// Email is sent in background, so failed login doesn't wait for mail server and fails the same way
+func (u *Usecase) notifyLockout(email string, userID uuid.UUID, hit models.LimitHit) {
+	if !hit.LockedOut {
+		return
+	}
+
+	u.recordEvent(uuid.Nil, userID, models.LockedOutAuditAction)
+
+	go func() {
+		if err := u.lockoutNoticeClient.SendLockoutNotice(email, hit.RetryAfter); err != nil {
+			u.logger.Errorf("failed to send lockout notice to user %s: %v", userID, err)
+		}
+	}()
+}
+
+// hitLimit counts attempt of key, it returns TooManyAttemptsError while key is throttled
+func hitLimit(limiter auth.Limiter, key string) (models.LimitHit, error) {
+	hit, err := limiter.Hit(key)
+	if err != nil {
+		return models.LimitHit{}, err
+	}
+
+	if hit.Blocked {
+		return hit, &auth.TooManyAttemptsError{RetryAfter: hit.RetryAfter}
+	}
+
+	return hit, nil
+}
+
+func emailLimitKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Logout of unknown or already expired session is successful
 func (u *Usecase) Logout(sessionID string) error {
-	return u.sessionsRepo.DeleteSession(sessionID)
+	userID, err := u.sessionsRepo.GetUserIDBySessionID(sessionID)
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := u.sessionsRepo.DeleteSession(sessionID); err != nil {
+		return err
+	}
+
+	u.recordEvent(userID, userID, models.LoggedOutAuditAction)
+
+	return nil
+}
+
+// ListSessions returns sessions of user, which haven't expired yet
+func (u *Usecase) ListSessions(userID uuid.UUID) ([]*models.Session, error) {
+	return u.sessionsRepo.ListUserSessions(userID)
+}
+
+func (u *Usecase) RevokeSession(userID uuid.UUID, publicID string) error {
+	sessions, err := u.sessionsRepo.ListUserSessions(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if session.PublicID() != publicID {
+			continue
+		}
+
+		if err := u.sessionsRepo.DeleteSession(session.ID); err != nil {
+			return err
+		}
+
+		u.recordEvent(userID, userID, models.SessionRevokedAuditAction)
+		return nil
+	}
+
+	return auth.ErrSessionNotFound
+}
+
+func (u *Usecase) RevokeOtherSessions(userID uuid.UUID, sessionID string) error {
+	if err := u.endUserSessions(userID, sessionID); err != nil {
+		return err
+	}
+
+	u.recordEvent(userID, userID, models.SessionRevokedAuditAction)
+
+	return nil
+}
+
+// RequestPasswordReset silently does nothing for unknown email and repeated requests,
+// so it can't be used to check whether email is registered or to flood mailbox
+func (u *Usecase) RequestPasswordReset(email string) error {
+	userID, _, err := u.usersRepo.GetUserIDAndPasswordByEmail(email)
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	lastRequestedAt, err := u.usersRepo.GetLastPasswordResetTime(userID)
+	if err != nil {
+		return err
+	}
+	if lastRequestedAt != nil && time.Since(*lastRequestedAt) < resetRequestPeriod {
+		return nil
+	}
+
+	token := u.generateSessionID(resetTokenLength)
+	if token == "" {
+		return errors.New("(usecase) failed to generate reset token")
+	}
+
+	if err := u.usersRepo.CreatePasswordReset(userID, hashResetToken(token), time.Now().Add(resetTokenTTL)); err != nil {
+		return err
+	}
+
+	// Email is sent in background, so response time doesn't tell whether email is registered
+	go func() {
+		if err := u.passwordResetClient.SendPasswordReset(email, token); err != nil {
+			u.logger.Errorf("failed to send password reset to user %s: %v", userID, err)
+		}
+	}()
+
+	return nil
+}
+
+func (u *Usecase) ResetPassword(token, password string) error {
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+
+	userID, err := u.usersRepo.ResetPasswordByTokenHash(hashResetToken(token), u.getPasswordHash(password))
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return auth.ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+
+	// Password is already changed, so failed ending of sessions is only logged
+	if err := u.endUserSessions(userID, ""); err != nil {
+		u.logger.Errorf("failed to end sessions of user %s after password reset: %v", userID, err)
+	}
+
+	u.recordEvent(uuid.Nil, userID, models.PasswordResetAuditAction)
+
+	return nil
+}
+
+// ChangePassword counts attempt before current password is checked like EnrollTOTP,
+// so stolen session can't be used to brute force password
+func (u *Usecase) ChangePassword(userID uuid.UUID, sessionID, currentPassword, newPassword string) error {
+	if _, err := hitLimit(u.limiters.TwoFactorByUser, userID.String()); err != nil {
+		return err
+	}
+	if err := u.checkPassword(userID, currentPassword); err != nil {
+		return err
+	}
+
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+
+	if err := u.usersRepo.UpdatePassword(userID, u.getPasswordHash(newPassword)); err != nil {
+		return err
+	}
+
+	if err := u.endUserSessions(userID, sessionID); err != nil {
+		return err
+	}
+
+	u.recordEvent(userID, userID, models.PasswordChangedAuditAction)
+
+	return nil
+}
+
+func (u *Usecase) GetTwoFactorStatus(userID uuid.UUID) (*models.TwoFactorStatus, error) {
+	userTOTP, err := u.getTOTP(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !userTOTP.Enabled() {
+		return &models.TwoFactorStatus{}, nil
+	}
+
+	codesLeft, err := u.usersRepo.CountRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorStatus{
+		Enabled:           true,
+		EnabledAt:         userTOTP.EnabledAt,
+		RecoveryCodesLeft: codesLeft,
+	}, nil
+}
+
+// EnrollTOTP may be called again until secret is confirmed, previous pending secret is replaced then.
+// Password is required, so stolen session can't bind attacker's authenticator.
+// Password attempts are counted like wrong codes
+func (u *Usecase) EnrollTOTP(userID uuid.UUID, password string) (*models.TOTPEnrollment, error) {
+	if _, err := hitLimit(u.limiters.TwoFactorByUser, userID.String()); err != nil {
+		return nil, err
+	}
+	if err := u.checkPassword(userID, password); err != nil {
+		return nil, err
+	}
+
+	user, err := u.usersUsecase.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := u.usersRepo.SaveTOTPSecret(userID, secret)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, auth.ErrTwoFactorAlreadyEnabled
+	}
+
+	return &models.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP requires password like EnrollTOTP, password and code are counted as one attempt
+// before they are checked
+func (u *Usecase) ConfirmTOTP(userID uuid.UUID, password, code string) ([]string, error) {
+	userTOTP, err := u.getTOTP(userID)
+	if err != nil {
+		return nil, err
+	}
+	if userTOTP == nil {
+		return nil, auth.ErrTwoFactorNotEnabled
+	}
+	if userTOTP.Enabled() {
+		return nil, auth.ErrTwoFactorAlreadyEnabled
+	}
+
+	if _, err := hitLimit(u.limiters.TwoFactorByUser, userID.String()); err != nil {
+		return nil, err
+	}
+	if err := u.checkPassword(userID, password); err != nil {
+		return nil, err
+	}
+
+	step, ok, err := totp.Validate(userTOTP.Secret, code, time.Now(), totpSkew)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, auth.ErrInvalidTwoFactorCode
+	}
+
+	if err := u.limiters.TwoFactorByUser.Reset(userID.String()); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.usersRepo.EnableTOTP(userID, step, hashes); err != nil {
+		return nil, err
+	}
+
+	u.recordEvent(userID, userID, models.TwoFactorEnabledAuditAction)
+
+	return codes, nil
+}
+
+func (u *Usecase) RegenerateRecoveryCodes(userID uuid.UUID, code string) ([]string, error) {
+	if err := u.checkSecondFactor(userID, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.usersRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (u *Usecase) DisableTOTP(userID uuid.UUID, code string) error {
+	if err := u.checkSecondFactor(userID, code); err != nil {
+		return err
+	}
+
+	if err := u.usersRepo.DeleteTOTP(userID); err != nil {
+		return err
+	}
+
+	u.recordEvent(userID, userID, models.TwoFactorDisabledAuditAction)
+
+	return nil
+}
+
+// checkSecondFactor checks that user has enabled second factor and code matches it
+func (u *Usecase) checkSecondFactor(userID uuid.UUID, code string) error {
+	userTOTP, err := u.getTOTP(userID)
+	if err != nil {
+		return err
+	}
+	if !userTOTP.Enabled() {
+		return auth.ErrTwoFactorNotEnabled
+	}
+
+	ok, err := u.verifySecondFactor(userTOTP, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return auth.ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// checkPassword returns ErrWrongPassword if password isn't user's one
+func (u *Usecase) checkPassword(userID uuid.UUID, password string) error {
+	passwordHash, err := u.usersRepo.GetPasswordByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)); err != nil {
+		return auth.ErrWrongPassword
+	}
+
+	return nil
+}
+
+// getTOTP returns nil if user has never enrolled
+func (u *Usecase) getTOTP(userID uuid.UUID) (*models.UserTOTP, error) {
+	userTOTP, err := u.usersRepo.GetTOTP(userID)
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return nil, nil
+	}
+
+	return userTOTP, err
+}
+
+// verifySecondFactor accepts TOTP code, which can't be reused, or unused recovery code.
+// Every code is counted by user before it's checked, so codes can't be brute forced
+// even with many pending logins or concurrent requests
+func (u *Usecase) verifySecondFactor(userTOTP *models.UserTOTP, code string) (bool, error) {
+	limitKey := userTOTP.UserID.String()
+	if _, err := hitLimit(u.limiters.TwoFactorByUser, limitKey); err != nil {
+		return false, err
+	}
+
+	ok, err := u.useSecondFactor(userTOTP, code)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return true, u.limiters.TwoFactorByUser.Reset(limitKey)
+}
+
+func (u *Usecase) useSecondFactor(userTOTP *models.UserTOTP, code string) (bool, error) {
+	step, ok, err := totp.Validate(userTOTP.Secret, code, time.Now(), totpSkew)
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		return u.usersRepo.UseTOTPStep(userTOTP.UserID, step)
+	}
+
+	used, err := u.usersRepo.UseRecoveryCode(userTOTP.UserID, hashRecoveryCode(code))
+	if err != nil || !used {
+		return false, err
+	}
+
+	u.recordEvent(userTOTP.UserID, userTOTP.UserID, models.RecoveryCodeUsedAuditAction)
+
+	return true, nil
+}
+
+// generateRecoveryCodes returns codes shown to user and their hashes to store
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		b := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("(usecase) failed to generate recovery code: %w", err)
+		}
+
+		code := base32.StdEncoding.EncodeToString(b)
+		groups := make([]string, 0, len(code)/recoveryCodeGroupLength)
+		for len(code) > 0 {
+			groups = append(groups, code[:recoveryCodeGroupLength])
+			code = code[recoveryCodeGroupLength:]
+		}
+
+		shown := strings.Join(groups, "-")
+		codes = append(codes, shown)
+		hashes = append(hashes, hashRecoveryCode(shown))
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes, so code may be typed as user likes
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	hash := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(hash[:])
+}
+
+// validatePassword checks password policy: length and at least one letter and one digit
+func validatePassword(password string) error {
+	if utf8.RuneCountInString(password) < passwordMinLength {
+		return fmt.Errorf("%w: password must be at least %d characters long", auth.ErrWeakPassword, passwordMinLength)
+	}
+	if len(password) > passwordMaxLength {
+		return fmt.Errorf("%w: password must be at most %d bytes long", auth.ErrWeakPassword, passwordMaxLength)
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		hasLetter = hasLetter || unicode.IsLetter(r)
+		hasDigit = hasDigit || unicode.IsDigit(r)
+	}
+	if !hasLetter || !hasDigit {
+		return fmt.Errorf("%w: password must contain letters and digits", auth.ErrWeakPassword)
+	}
+
+	return nil
+}
+
+func hashResetToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// recordEvent is called after change is applied, so failed recording is only logged
+func (u *Usecase) recordEvent(actorID uuid.UUID, userID uuid.UUID, action string) {
+	if err := u.auditRepo.Create(models.NewAuditEvent(actorID, models.UserAuditTarget, userID.String(), action)); err != nil {
+		u.logger.Errorf("failed to record audit event %s of user %s: %v", action, userID.String(), err)
+	}
 }
@@ -1,12 +1,19 @@
 package http
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	"github.com/go-park-mail-ru/2023_1_Technokaif/pkg/logger"
+	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/http/auth"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
 	"github.com/yarikTri/archipelago-notes-api/internal/pkg/dirs"
-	"net/http"
-	"strconv"
 )
 
 type Handler struct {
@@ -21,6 +28,74 @@ func NewHandler(du dirs.Usecase, l logger.Logger) *Handler {
 	}
 }
 
+func (h *Handler) checkAccess(c *gin.Context, dirID int, method methodName) *models.NoteAccess {
+	accessForbidden := func(userID string, dirID int) {
+		h.logger.Infof("Access forbidden for user %s, dir %d, method %s", userID, dirID, method)
+		c.JSON(http.StatusForbidden, "Forbidden")
+	}
+
+	userID, err := auth.GetUserId(c)
+	if err != nil {
+		h.logger.Infof("Unathorized request for dir %d, method %s", dirID, method)
+		c.JSON(http.StatusUnauthorized, "")
+		return nil
+	}
+
+	access, err := h.dirsUsecase.GetUserAccess(dirID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, "Dir not found")
+		return nil
+	}
+	if err != nil {
+		h.logger.Errorf("Error while check access for user with id %s: %w", userID.String(), err)
+		c.JSON(http.StatusInternalServerError, "Can't check access")
+		return nil
+	}
+
+	for _, a := range methodsAccessMap[method] {
+		if a == access {
+			return &access
+		}
+	}
+
+	accessForbidden(userID.String(), dirID)
+	return nil
+}
+
+// checkMoveAccess checks that user is allowed to move dir under the new parent path.
+// Moving to the root of tree requires manage access to dir itself
+func (h *Handler) checkMoveAccess(c *gin.Context, dirID int, newParentPath string, dirAccess models.NoteAccess) bool {
+	dir, err := h.dirsUsecase.Get(dirID)
+	if err != nil {
+		h.logger.Errorf("Error while getting dir with id %d: %w", dirID, err)
+		c.JSON(http.StatusInternalServerError, "Can't check access")
+		return false
+	}
+
+	if dir.Path == newParentPath {
+		return true
+	}
+
+	if newParentPath == "" {
+		if dirAccess != models.ManageAccessNoteAccess {
+			h.logger.Infof("Access forbidden for moving dir %d to root", dirID)
+			c.JSON(http.StatusForbidden, "Forbidden")
+			return false
+		}
+		return true
+	}
+
+	labels := strings.Split(newParentPath, ".")
+	newParentID, err := strconv.Atoi(labels[len(labels)-1])
+	if err != nil {
+		h.logger.Infof("Invalid dir path '%s'", newParentPath)
+		c.JSON(http.StatusBadRequest, err)
+		return false
+	}
+
+	return h.checkAccess(c, newParentID, createMethodName) != nil
+}
+
 // Get
 // @Summary		Get dir
 // @Tags		Dirs
@@ -34,11 +109,15 @@ func NewHandler(du dirs.Usecase, l logger.Logger) *Handler {
 func (h *Handler) Get(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		h.logger.Infof("Invalid dir id '%d'", id)
+		h.logger.Infof("Invalid dir id '%s'", c.Param("id"))
 		c.JSON(http.StatusBadRequest, err)
 		return
 	}
 
+	if access := h.checkAccess(c, id, getMethodName); access == nil {
+		return
+	}
+
 	dir, err := h.dirsUsecase.Get(id)
 	if err != nil {
 		h.logger.Errorf("Error while getting dir with id %d: %w", id, err)
@@ -61,11 +140,15 @@ func (h *Handler) Get(c *gin.Context) {
 func (h *Handler) GetTree(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		h.logger.Infof("Invalid dir id '%d'", id)
+		h.logger.Infof("Invalid dir id '%s'", c.Param("id"))
 		c.JSON(http.StatusBadRequest, err)
 		return
 	}
 
+	if access := h.checkAccess(c, id, getTreeMethodName); access == nil {
+		return
+	}
+
 	dirTree, err := h.dirsUsecase.GetTree(id)
 	if err != nil {
 		h.logger.Errorf("Error while get tree for dir: %w", err)
@@ -88,6 +171,13 @@ func (h *Handler) GetTree(c *gin.Context) {
 // @Failure		500			{object}	error				"Server error"
 // @Router		/api/dirs [post]
 func (h *Handler) Create(c *gin.Context) {
+	userID, err := auth.GetUserId(c)
+	if err != nil {
+		h.logger.Infof("Unathorized request for creating dir")
+		c.JSON(http.StatusUnauthorized, "")
+		return
+	}
+
 	var req CreateDirRequest
 	c.BindJSON(&req)
 
@@ -97,7 +187,13 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
-	createdDir, err := h.dirsUsecase.Create(req.Name, req.ParentDirID)
+	if req.ParentDirID != 0 {
+		if access := h.checkAccess(c, req.ParentDirID, createMethodName); access == nil {
+			return
+		}
+	}
+
+	createdDir, err := h.dirsUsecase.Create(req.Name, req.ParentDirID, userID)
 	if err != nil {
 		h.logger.Errorf("Error: %w", err)
 		c.JSON(http.StatusInternalServerError, err)
@@ -122,7 +218,7 @@ func (h *Handler) Create(c *gin.Context) {
 func (h *Handler) Update(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		h.logger.Infof("Invalid dir id '%d'", id)
+		h.logger.Infof("Invalid dir id '%s'", c.Param("id"))
 		c.JSON(http.StatusBadRequest, err)
 		return
 	}
@@ -145,7 +241,17 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
-	updatedDir, err := h.dirsUsecase.Update(&reqDir)
+	access := h.checkAccess(c, id, updateMethodName)
+	if access == nil {
+		return
+	}
+
+	if !h.checkMoveAccess(c, id, reqDir.Path, *access) {
+		return
+	}
+
+	userID, _ := auth.GetUserId(c)
+	updatedDir, err := h.dirsUsecase.Update(&reqDir, userID)
 	if err != nil {
 		h.logger.Errorf("Error: %w", err)
 		c.JSON(http.StatusInternalServerError, err)
@@ -158,7 +264,7 @@ func (h *Handler) Update(c *gin.Context) {
 // Delete
 // @Summary		Delete dir
 // @Tags		Dirs
-// @Description	Delete dir by ID
+// @Description	Move dir with its subtree and notes to trash by ID
 // @Produce     json
 // @Param		dirID path int true 			"Dir ID"
 // @Success		200								"Dir deleted"
@@ -168,12 +274,96 @@ func (h *Handler) Update(c *gin.Context) {
 func (h *Handler) Delete(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		h.logger.Infof("Invalid dir id '%d'", id)
+		h.logger.Infof("Invalid dir id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if access := h.checkAccess(c, id, deleteMethodName); access == nil {
+		return
+	}
+
+	userID, _ := auth.GetUserId(c)
+	if err := h.dirsUsecase.Delete(id, userID); err != nil {
+		h.logger.Errorf("Error: %w", err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// GetAccess
+// @Summary		Get Access
+// @Tags		Dirs
+// @Description	Get current user's access to dir
+// @Produce     json
+// @Param		dirID path int true 						"Dir ID"
+// @Success		200			{object}	GetAccessResponse	"Access"
+// @Failure		400			{object}	error				"Incorrect input"
+// @Failure		500			{object}	error				"Server error"
+// @Router		/api/dirs/{dirID}/access [get]
+func (h *Handler) GetAccess(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid dir id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	access := h.checkAccess(c, id, getAccessMethodName)
+	if access == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, GetAccessResponse{
+		Access:         access.String(),
+		AllowedMethods: getAllowedMethods(*access),
+	})
+}
+
+// SetAccess
+// @Summary		Set Access
+// @Tags		Dirs
+// @Description	Set access to dir and all its subtree to user
+// @Accept		json
+// @Produce     json
+// @Param		dirID path int true 					"Dir ID"
+// @Param		userID path string true 				"User to set access ID"
+// @Param		access	body	SetAccessRequest true	"Access info"
+// @Success		200										"Access set"
+// @Failure		400			{object}	error			"Incorrect input"
+// @Failure		500			{object}	error			"Server error"
+// @Router		/api/dirs/{dirID}/access/{userID} [post]
+func (h *Handler) SetAccess(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid dir id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if access := h.checkAccess(c, id, setAccessMethodName); access == nil {
+		return
+	}
+
+	userID, err := uuid.FromString(c.Param("userID"))
+	if err != nil {
+		h.logger.Infof("Invalid user id '%s'", c.Param("userID"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	var req SetAccessRequest
+	c.BindJSON(&req)
+	if err := req.validate(); err != nil {
+		h.logger.Infof("Invalid set access request: %w", err)
 		c.JSON(http.StatusBadRequest, err)
 		return
 	}
 
-	if err := h.dirsUsecase.Delete(id); err != nil {
+	grantedBy, _ := auth.GetUserId(c)
+	if err := h.dirsUsecase.SetUserAccess(id, userID, models.NoteAccessFromString(req.Access), req.WithInvitation, grantedBy); err != nil {
 		h.logger.Errorf("Error: %w", err)
 		c.JSON(http.StatusInternalServerError, err)
 		return
@@ -0,0 +1,62 @@
+package http
+
+import "github.com/yarikTri/archipelago-notes-api/internal/models"
+
+type methodName uint8
+
+const (
+	getMethodName methodName = iota
+	getTreeMethodName
+	createMethodName
+	updateMethodName
+	deleteMethodName
+	getAccessMethodName
+	setAccessMethodName
+)
+
+func (mn *methodName) String() string {
+	switch *mn {
+	case getMethodName:
+		return "get"
+	case getTreeMethodName:
+		return "get_tree"
+	case createMethodName:
+		return "create"
+	case updateMethodName:
+		return "update"
+	case deleteMethodName:
+		return "delete"
+	case getAccessMethodName:
+		return "get_access"
+	case setAccessMethodName:
+		return "set_access"
+	}
+
+	return ""
+}
+
+// createMethodName is checked against parent dir
+var methodsAccessMap = map[methodName][]models.NoteAccess{
+	getMethodName:       {models.ReadNoteAccess, models.WriteNoteAccess, models.ModifyNoteAccess, models.ManageAccessNoteAccess},
+	getTreeMethodName:   {models.ReadNoteAccess, models.WriteNoteAccess, models.ModifyNoteAccess, models.ManageAccessNoteAccess},
+	createMethodName:    {models.ModifyNoteAccess, models.ManageAccessNoteAccess},
+	updateMethodName:    {models.ModifyNoteAccess, models.ManageAccessNoteAccess},
+	deleteMethodName:    {models.ModifyNoteAccess, models.ManageAccessNoteAccess},
+	getAccessMethodName: {models.ReadNoteAccess, models.WriteNoteAccess, models.ModifyNoteAccess, models.ManageAccessNoteAccess},
+	setAccessMethodName: {models.ManageAccessNoteAccess},
+}
+
+func getAllowedMethods(access models.NoteAccess) []string {
+	allowedMethods := make([]string, 0)
+
+	for method, accesses := range methodsAccessMap {
+		for _, a := range accesses {
+			if a == access {
+				allowedMethods = append(allowedMethods, method.String())
+				break
+			}
+		}
+	}
+
+	return allowedMethods
+}
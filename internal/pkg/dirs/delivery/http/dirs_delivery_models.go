@@ -1,6 +1,9 @@
 package http
 
 import (
+	"errors"
+	"fmt"
+
 	valid "github.com/asaskevich/govalidator"
 	"github.com/yarikTri/archipelago-notes-api/internal/models"
 )
@@ -16,20 +19,50 @@ func (cdr *CreateDirRequest) validate() error {
 }
 
 type UpdateDirRequest struct {
-	ID   int    `json:"id" valid:"required"`
-	Name string `json:"name" valid:"required"`
-	Path string `json:"subpath" valid:"required"`
+	ID            int    `json:"id" valid:"required"`
+	Name          string `json:"name" valid:"required"`
+	Path          string `json:"subpath" valid:"required"`
+	DefaultAccess string `json:"default_access"`
 }
 
 func (udr *UpdateDirRequest) validate() error {
+	if udr.DefaultAccess != "" {
+		defaultAccess := models.NoteAccessFromString(udr.DefaultAccess)
+		if defaultAccess == models.UndefinedNoteAccess ||
+			defaultAccess == models.ModifyNoteAccess ||
+			defaultAccess == models.ManageAccessNoteAccess {
+			return errors.New(fmt.Sprintf("Invalid default access: %s", udr.DefaultAccess))
+		}
+	}
+
 	_, err := valid.ValidateStruct(udr)
 	return err
 }
 
 func (udr *UpdateDirRequest) ToDir() models.Dir {
 	return models.Dir{
-		ID:   udr.ID,
-		Name: udr.Name,
-		Path: udr.Path,
+		ID:            udr.ID,
+		Name:          udr.Name,
+		Path:          udr.Path,
+		DefaultAccess: udr.DefaultAccess,
+	}
+}
+
+type SetAccessRequest struct {
+	Access         string `json:"access" valid:"required"`
+	WithInvitation bool   `json:"with_invitation"`
+}
+
+func (sar *SetAccessRequest) validate() error {
+	if models.NoteAccessFromString(sar.Access) == models.UndefinedNoteAccess {
+		return errors.New(fmt.Sprintf("Invalid access: %s", sar.Access))
 	}
+
+	_, err := valid.ValidateStruct(sar)
+	return err
+}
+
+type GetAccessResponse struct {
+	Access         string   `json:"access"`
+	AllowedMethods []string `json:"allowed_methods"`
 }
@@ -1,19 +1,29 @@
 package dirs
 
-import "github.com/yarikTri/archipelago-notes-api/internal/models"
+import (
+	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
 
 type Usecase interface {
 	Get(dirID int) (*models.Dir, error)
 	GetTree(dirID int) (*models.DirTree, error)
-	Create(name string, parentDirID int) (*models.Dir, error)
-	Update(dir *models.Dir) (*models.Dir, error)
-	Delete(dirID int) error
+	Create(name string, parentDirID int, creatorID uuid.UUID) (*models.Dir, error)
+	Update(dir *models.Dir, changedBy uuid.UUID) (*models.Dir, error)
+	Delete(dirID int, deletedBy uuid.UUID) error
+
+	GetUserAccess(dirID int, userID uuid.UUID) (models.NoteAccess, error)
+	SetUserAccess(dirID int, userID uuid.UUID, access models.NoteAccess, sendInvitation bool, grantedBy uuid.UUID) error
 }
 
 type Repository interface {
 	GetByID(dirID int) (*models.Dir, error)
 	GetSubTreeDirsByID(dirID int) ([]*models.Dir, error)
-	Create(parentDirID int, name string) (*models.Dir, error)
+	Create(parentDirID int, name string, creatorID uuid.UUID) (*models.Dir, error)
 	Update(dir *models.Dir) (*models.Dir, error)
-	DeleteByID(dirID int) error
+	DeleteByID(dirID int, deletedBy uuid.UUID) error
+
+	GetUserAccess(dirID int, userID uuid.UUID) (models.NoteAccess, error)
+	GetUserGrant(dirID int, userID uuid.UUID) (models.NoteAccess, error)
+	SetUserAccess(dirID int, userID uuid.UUID, access models.NoteAccess) error
 }
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+
+	"github.com/gofrs/uuid/v5"
 	"github.com/jmoiron/sqlx"
 
 	_ "github.com/lib/pq"
@@ -24,9 +26,9 @@ func NewPostgreSQL(db *sqlx.DB) *PostgreSQL {
 
 func (p *PostgreSQL) GetByID(dirID int) (*models.Dir, error) {
 	query := fmt.Sprint(
-		`SELECT id, name, SUBPATH(path, 0, -1) as subpath
+		`SELECT id, name, SUBPATH(path, 0, -1) as subpath, creator_id, default_access
 			FROM dir
-			WHERE id = $1`,
+			WHERE id = $1 AND deleted_at IS NULL`,
 	)
 
 	var dir models.Dir
@@ -43,9 +45,9 @@ func (p *PostgreSQL) GetByID(dirID int) (*models.Dir, error) {
 
 func (p *PostgreSQL) GetSubTreeDirsByID(dirID int) ([]*models.Dir, error) {
 	query := fmt.Sprint(
-		`SELECT id, name, SUBPATH(path, 0, -1) as subpath
+		`SELECT id, name, SUBPATH(path, 0, -1) as subpath, creator_id, default_access
 			FROM dir
-			WHERE path <@ (SELECT path FROM dir WHERE id = $1)`,
+			WHERE path <@ (SELECT path FROM dir WHERE id = $1) AND deleted_at IS NULL`,
 	)
 
 	var dirs []*models.Dir
@@ -56,63 +58,63 @@ func (p *PostgreSQL) GetSubTreeDirsByID(dirID int) ([]*models.Dir, error) {
 	return dirs, nil
 }
 
-func (p *PostgreSQL) Create(parentDirID int, name string) (*models.Dir, error) {
-	var query string
-
-	var id int
-	var createdName string
-	var path string
-	var row *sql.Row
+func (p *PostgreSQL) Create(parentDirID int, name string, creatorID uuid.UUID) (*models.Dir, error) {
+	var dir models.Dir
 	if parentDirID == 0 {
-		query = fmt.Sprint(
-			`INSERT INTO dir (name)
-			VALUES ($1)
-			RETURNING id, name, SUBPATH(path, 0, -1) as subpath`,
+		query := fmt.Sprint(
+			`INSERT INTO dir (name, creator_id)
+			VALUES ($1, $2)
+			RETURNING id, name, SUBPATH(path, 0, -1) as subpath, creator_id, default_access`,
 		)
-		row = p.db.QueryRow(query, name)
+		if err := p.db.Get(&dir, query, name, creatorID.String()); err != nil {
+			return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+		}
 	} else {
-		query = fmt.Sprint(
-			`INSERT INTO dir (name, path)
-			VALUES ($1, (SELECT path FROM dir WHERE id = $2))
-			RETURNING id, name, SUBPATH(path, 0, -1) as subpath`,
+		query := fmt.Sprint(
+			`INSERT INTO dir (name, path, creator_id)
+			VALUES ($1, (SELECT path FROM dir WHERE id = $2), $3)
+			RETURNING id, name, SUBPATH(path, 0, -1) as subpath, creator_id, default_access`,
 		)
-		row = p.db.QueryRow(query, name, parentDirID)
-	}
-
-	if err := row.Scan(&id, &createdName, &path); err != nil {
-		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+		if err := p.db.Get(&dir, query, name, parentDirID, creatorID.String()); err != nil {
+			return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+		}
 	}
 
-	return &models.Dir{ID: id, Name: createdName, Path: path}, nil
+	return &dir, nil
 }
 
 func (p *PostgreSQL) Update(dir *models.Dir) (*models.Dir, error) {
 	query := fmt.Sprint(
 		`UPDATE dir
-			SET name = $1, path = $2
-			WHERE id = $3
-			RETURNING id, name, SUBPATH(path, 0, -1) as subpath`,
+			SET name = $1, path = $2, default_access = COALESCE(NULLIF($3, ''), default_access)
+			WHERE id = $4
+			RETURNING id, name, SUBPATH(path, 0, -1) as subpath, creator_id, default_access`,
 	)
 
-	var id int
-	var name string
-	var path string
-	row := p.db.QueryRow(query, dir.Name, dir.Path, dir.ID)
-	if err := row.Scan(&id, &name, &path); err != nil {
+	var updatedDir models.Dir
+	if err := p.db.Get(&updatedDir, query, dir.Name, dir.Path, dir.DefaultAccess, dir.ID); err != nil {
 		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
 	}
 
-	return &models.Dir{ID: id, Name: name, Path: path}, nil
+	return &updatedDir, nil
 }
 
-func (p *PostgreSQL) DeleteByID(dirID int) error {
-	query := fmt.Sprint(
-		`DELETE
-		FROM dir
-		WHERE id = $1`,
+// DeleteByID moves dir with its subtree and notes to trash.
+// All of them get the same deleted_at to be restored together
+func (p *PostgreSQL) DeleteByID(dirID int, deletedBy uuid.UUID) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	dirsQuery := fmt.Sprint(
+		`UPDATE dir
+		SET deleted_at = CURRENT_TIMESTAMP, deleted_by = $2
+		WHERE path <@ (SELECT path FROM dir WHERE id = $1 AND deleted_at IS NULL) AND deleted_at IS NULL`,
 	)
 
-	resExec, err := p.db.Exec(query, dirID)
+	resExec, err := tx.Exec(dirsQuery, dirID, deletedBy.String())
 	if err != nil {
 		return fmt.Errorf("(repo) failed to exec query: %w", err)
 	}
@@ -125,5 +127,67 @@ func (p *PostgreSQL) DeleteByID(dirID int) error {
 		return fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: dirID})
 	}
 
+	notesQuery := fmt.Sprint(
+		`UPDATE note
+		SET deleted_at = CURRENT_TIMESTAMP, deleted_by = $2
+		WHERE dir_id IN (SELECT id FROM dir WHERE path <@ (SELECT path FROM dir WHERE id = $1)) AND deleted_at IS NULL`,
+	)
+
+	if _, err := tx.Exec(notesQuery, dirID, deletedBy.String()); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (p *PostgreSQL) GetUserAccess(dirID int, userID uuid.UUID) (models.NoteAccess, error) {
+	query := fmt.Sprint(
+		`SELECT dir_user_access(id, $2)
+			FROM dir
+			WHERE id = $1 AND deleted_at IS NULL`,
+	)
+
+	var access string
+	if err := p.db.Get(&access, query, dirID, userID.String()); err != nil {
+		return models.UndefinedNoteAccess, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return models.NoteAccessFromString(access), nil
+}
+
+// GetUserGrant returns access given directly to user on dir, UndefinedNoteAccess if there is no such
+func (p *PostgreSQL) GetUserGrant(dirID int, userID uuid.UUID) (models.NoteAccess, error) {
+	query := fmt.Sprint(
+		`SELECT access FROM dir_access WHERE dir_id = $1 AND user_id = $2`,
+	)
+
+	var access string
+	if err := p.db.Get(&access, query, dirID, userID.String()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.UndefinedNoteAccess, nil
+		}
+
+		return models.UndefinedNoteAccess, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return models.NoteAccessFromString(access), nil
+}
+
+func (p *PostgreSQL) SetUserAccess(dirID int, userID uuid.UUID, access models.NoteAccess) error {
+	query := fmt.Sprint(
+		`INSERT INTO dir_access
+		(dir_id, user_id, access)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (dir_id, user_id) DO UPDATE SET access = EXCLUDED.access;`,
+	)
+
+	if _, err := p.db.Exec(query, dirID, userID.String(), access.String()); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
 	return nil
 }
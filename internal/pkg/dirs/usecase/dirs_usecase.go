@@ -1,21 +1,40 @@
 package usecase
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/go-park-mail-ru/2023_1_Technokaif/pkg/logger"
+	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/clients/invitations/email"
 	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/audit"
 	"github.com/yarikTri/archipelago-notes-api/internal/pkg/dirs"
 	"github.com/yarikTri/archipelago-notes-api/internal/pkg/notes"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/users"
 )
 
 // Usecase implements dirs.Usecase
 type Usecase struct {
-	dirsRepo  dirs.Repository
-	notesRepo notes.Repository
+	dirsRepo              dirs.Repository
+	notesRepo             notes.Repository
+	usersRepo             users.Repository
+	auditRepo             audit.Repository
+	emailInvitationClient email.IEmailInvitationClient
+	logger                logger.Logger
 }
 
-func NewUsecase(dr dirs.Repository, nr notes.Repository) *Usecase {
+func NewUsecase(dr dirs.Repository, nr notes.Repository, ur users.Repository, ar audit.Repository,
+	eic email.IEmailInvitationClient, l logger.Logger) *Usecase {
+
 	return &Usecase{
-		dirsRepo:  dr,
-		notesRepo: nr,
+		dirsRepo:              dr,
+		notesRepo:             nr,
+		usersRepo:             ur,
+		auditRepo:             ar,
+		emailInvitationClient: eic,
+		logger:                l,
 	}
 }
 
@@ -37,14 +56,106 @@ func (u *Usecase) GetTree(rootID int) (*models.DirTree, error) {
 	return models.ToTree(rootID, dirs), nil
 }
 
-func (u *Usecase) Create(name string, parentDirID int) (*models.Dir, error) {
-	return u.dirsRepo.Create(parentDirID, name)
+func (u *Usecase) Create(name string, parentDirID int, creatorID uuid.UUID) (*models.Dir, error) {
+	dir, err := u.dirsRepo.Create(parentDirID, name, creatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	event := models.NewAuditEvent(creatorID, models.DirAuditTarget, strconv.Itoa(dir.ID), models.CreatedAuditAction).
+		WithValues("", dir.Name)
+	u.recordEvent(event)
+
+	return dir, nil
+}
+
+func (u *Usecase) Update(dir *models.Dir, changedBy uuid.UUID) (*models.Dir, error) {
+	oldDir, err := u.dirsRepo.GetByID(dir.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedDir, err := u.dirsRepo.Update(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	dirID := strconv.Itoa(updatedDir.ID)
+	events := make([]*models.AuditEvent, 0)
+	if oldDir.Name != updatedDir.Name {
+		events = append(events, models.NewAuditEvent(changedBy, models.DirAuditTarget, dirID, models.RenamedAuditAction).
+			WithValues(oldDir.Name, updatedDir.Name))
+	}
+	if oldDir.Path != updatedDir.Path {
+		events = append(events, models.NewAuditEvent(changedBy, models.DirAuditTarget, dirID, models.MovedAuditAction).
+			WithValues(oldDir.Path, updatedDir.Path))
+	}
+	if oldDir.DefaultAccess != updatedDir.DefaultAccess {
+		events = append(events, models.NewAuditEvent(changedBy, models.DirAuditTarget, dirID, models.DefaultAccessChangedAuditAction).
+			WithValues(oldDir.DefaultAccess, updatedDir.DefaultAccess))
+	}
+
+	for _, event := range events {
+		u.recordEvent(event)
+	}
+
+	return updatedDir, nil
+}
+
+func (u *Usecase) Delete(dirID int, deletedBy uuid.UUID) error {
+	if err := u.dirsRepo.DeleteByID(dirID, deletedBy); err != nil {
+		return err
+	}
+
+	u.recordEvent(models.NewAuditEvent(deletedBy, models.DirAuditTarget, strconv.Itoa(dirID), models.DeletedAuditAction))
+
+	return nil
 }
 
-func (u *Usecase) Update(dir *models.Dir) (*models.Dir, error) {
-	return u.dirsRepo.Update(dir)
+func (u *Usecase) GetUserAccess(dirID int, userID uuid.UUID) (models.NoteAccess, error) {
+	return u.dirsRepo.GetUserAccess(dirID, userID)
 }
 
-func (u *Usecase) Delete(dirID int) error {
-	return u.dirsRepo.DeleteByID(dirID)
+func (u *Usecase) SetUserAccess(dirID int, userID uuid.UUID, access models.NoteAccess, sendInvitation bool, grantedBy uuid.UUID) error {
+	if access == models.UndefinedNoteAccess {
+		return errors.New(fmt.Sprintf("(usecase) Invalid access %s", access.String()))
+	}
+
+	oldAccess, err := u.dirsRepo.GetUserGrant(dirID, userID)
+	if err != nil {
+		return err
+	}
+
+	if sendInvitation {
+		if err := u.sendEmailInvitation(dirID, userID); err != nil {
+			return err
+		}
+	}
+
+	if err := u.dirsRepo.SetUserAccess(dirID, userID, access); err != nil {
+		return err
+	}
+
+	event := models.NewAuditEvent(grantedBy, models.DirAuditTarget, strconv.Itoa(dirID), models.AccessSetAuditAction).
+		WithPrincipal(models.UserAuditPrincipal, userID.String()).
+		WithValues(oldAccess.String(), access.String())
+	u.recordEvent(event)
+
+	return nil
+}
+
+// recordEvent is called after change is applied, so failed recording is only logged
+func (u *Usecase) recordEvent(event *models.AuditEvent) {
+	if err := u.auditRepo.Create(event); err != nil {
+		u.logger.Errorf("failed to record audit event %s of %s %s: %v", event.Action, event.TargetType, event.TargetID, err)
+	}
+}
+
+func (u *Usecase) sendEmailInvitation(dirID int, userID uuid.UUID) error {
+	user, err := u.usersRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	return u.emailInvitationClient.SendInvitation(user.Email, email.DirInvitationType, strconv.Itoa(dirID))
 }
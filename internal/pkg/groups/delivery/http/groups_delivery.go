@@ -0,0 +1,377 @@
+package http
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-park-mail-ru/2023_1_Technokaif/pkg/logger"
+	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/http/auth"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/repository"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/groups"
+)
+
+type Handler struct {
+	groupsUsecase groups.Usecase
+	logger        logger.Logger
+}
+
+func NewHandler(gu groups.Usecase, l logger.Logger) *Handler {
+	return &Handler{
+		groupsUsecase: gu,
+		logger:        l,
+	}
+}
+
+// checkAccess returns role of request's user in group if he is allowed to call method on it
+func (h *Handler) checkAccess(c *gin.Context, groupID uuid.UUID, method methodName) *models.GroupRole {
+	userID, err := auth.GetUserId(c)
+	if err != nil || userID == uuid.Nil {
+		h.logger.Infof("Unathorized request for group %s, method %s", groupID.String(), method)
+		c.JSON(http.StatusUnauthorized, "")
+		return nil
+	}
+
+	role, err := h.groupsUsecase.GetMemberRole(groupID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, "Group not found")
+		return nil
+	}
+	if err != nil {
+		h.logger.Errorf("Error while check access for user with id %s: %w", userID.String(), err)
+		c.JSON(http.StatusInternalServerError, "Can't check access")
+		return nil
+	}
+
+	for _, r := range methodsRolesMap[method] {
+		if r == role {
+			return &role
+		}
+	}
+
+	h.logger.Infof("Access forbidden for user %s, group %s, method %s", userID.String(), groupID.String(), method)
+	c.JSON(http.StatusForbidden, "Forbidden")
+	return nil
+}
+
+func (h *Handler) respondError(c *gin.Context, err error) {
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		c.JSON(http.StatusNotFound, "Not found")
+		return
+	}
+	if errors.Is(err, groups.ErrLastAdmin) {
+		c.JSON(http.StatusConflict, err.Error())
+		return
+	}
+
+	h.logger.Errorf("Error: %w", err)
+	c.JSON(http.StatusInternalServerError, err)
+}
+
+// List
+// @Summary		List groups
+// @Tags		Groups
+// @Description	Get groups current user is member of
+// @Produce     json
+// @Success		200			{object}	ListGroupsResponse	"Groups"
+// @Failure		500			{object}	error				"Server error"
+// @Router		/api/groups [get]
+func (h *Handler) List(c *gin.Context) {
+	userID, err := auth.GetUserId(c)
+	if err != nil || userID == uuid.Nil {
+		h.logger.Infof("Unathorized request for listing groups")
+		c.JSON(http.StatusUnauthorized, "")
+		return
+	}
+
+	userGroups, err := h.groupsUsecase.ListByUser(userID)
+	if err != nil {
+		h.logger.Errorf("Error while listing groups for user %s: %w", userID.String(), err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	groupsTransfers := make([]*models.GroupTransfer, 0, len(userGroups))
+	for _, group := range userGroups {
+		groupsTransfers = append(groupsTransfers, group.ToTransfer())
+	}
+
+	c.JSON(http.StatusOK, ListGroupsResponse{Groups: groupsTransfers})
+}
+
+// Get
+// @Summary		Get group
+// @Tags		Groups
+// @Description	Get group by ID with current user's role in it
+// @Produce     json
+// @Param		groupID path string true 						"Group ID"
+// @Success		200			{object}	GetGroupResponse	"Group"
+// @Failure		400			{object}	error				"Incorrect input"
+// @Failure		500			{object}	error				"Server error"
+// @Router		/api/groups/{groupID} [get]
+func (h *Handler) Get(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid group id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	role := h.checkAccess(c, id, getMethodName)
+	if role == nil {
+		return
+	}
+
+	group, err := h.groupsUsecase.Get(id)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, GetGroupResponse{
+		Group:          group.ToTransfer(),
+		Role:           role.String(),
+		AllowedMethods: getAllowedMethods(*role),
+	})
+}
+
+// Create
+// @Summary		Create group
+// @Tags		Groups
+// @Description	Create group with current user as its admin
+// @Accept		json
+// @Produce     json
+// @Param		groupInfo	body		CreateGroupRequest		true	"Group info"
+// @Success		200			{object}	models.GroupTransfer	"Group created"
+// @Failure		400			{object}	error					"Incorrect input"
+// @Failure		500			{object}	error					"Server error"
+// @Router		/api/groups [post]
+func (h *Handler) Create(c *gin.Context) {
+	userID, err := auth.GetUserId(c)
+	if err != nil || userID == uuid.Nil {
+		h.logger.Infof("Unathorized request for creating group")
+		c.JSON(http.StatusUnauthorized, "")
+		return
+	}
+
+	var req CreateGroupRequest
+	c.BindJSON(&req)
+	if err := req.validate(); err != nil {
+		h.logger.Infof("Invalid create group request: %w", err)
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	group, err := h.groupsUsecase.Create(req.Name, userID)
+	if err != nil {
+		h.logger.Errorf("Error: %w", err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group.ToTransfer())
+}
+
+// Update
+// @Summary		Update group
+// @Tags		Groups
+// @Description	Rename group
+// @Accept		json
+// @Produce     json
+// @Param		groupID path string true 						"Group ID"
+// @Param		groupInfo	body		UpdateGroupRequest		true	"Group info"
+// @Success		200			{object}	models.GroupTransfer	"Updated group"
+// @Failure		400			{object}	error					"Incorrect input"
+// @Failure		500			{object}	error					"Server error"
+// @Router		/api/groups/{groupID} [post]
+func (h *Handler) Update(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid group id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	var req UpdateGroupRequest
+	c.BindJSON(&req)
+	if err := req.validate(); err != nil {
+		h.logger.Infof("Invalid update group request: %w", err)
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if role := h.checkAccess(c, id, updateMethodName); role == nil {
+		return
+	}
+
+	group, err := h.groupsUsecase.Update(models.Group{ID: id, Name: req.Name})
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group.ToTransfer())
+}
+
+// Delete
+// @Summary		Delete group
+// @Tags		Groups
+// @Description	Delete group with all accesses given to it
+// @Produce     json
+// @Param		groupID path string true 		"Group ID"
+// @Success		200								"Group deleted"
+// @Failure		400			{object}	error	"Incorrect input"
+// @Failure		500			{object}	error	"Server error"
+// @Router		/api/groups/{groupID} [delete]
+func (h *Handler) Delete(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid group id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if role := h.checkAccess(c, id, deleteMethodName); role == nil {
+		return
+	}
+
+	if err := h.groupsUsecase.Delete(id); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// ListMembers
+// @Summary		List group members
+// @Tags		Groups
+// @Description	Get members of group with their roles
+// @Produce     json
+// @Param		groupID path string true 						"Group ID"
+// @Success		200			{object}	ListMembersResponse	"Members"
+// @Failure		400			{object}	error				"Incorrect input"
+// @Failure		500			{object}	error				"Server error"
+// @Router		/api/groups/{groupID}/members [get]
+func (h *Handler) ListMembers(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid group id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if role := h.checkAccess(c, id, listMembersMethodName); role == nil {
+		return
+	}
+
+	members, err := h.groupsUsecase.ListMembers(id)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	membersTransfers := make([]*models.GroupMemberTransfer, 0, len(members))
+	for _, member := range members {
+		membersTransfers = append(membersTransfers, member.ToTransfer())
+	}
+
+	c.JSON(http.StatusOK, ListMembersResponse{Members: membersTransfers})
+}
+
+// SetMember
+// @Summary		Set group member
+// @Tags		Groups
+// @Description	Add user to group or change his role in it
+// @Accept		json
+// @Produce     json
+// @Param		groupID path string true 				"Group ID"
+// @Param		userID path string true 				"User ID"
+// @Param		member	body	SetMemberRequest true	"Member info"
+// @Success		200										"Member set"
+// @Failure		400			{object}	error			"Incorrect input"
+// @Failure		409			{object}	error			"Group would be left without admin"
+// @Failure		500			{object}	error			"Server error"
+// @Router		/api/groups/{groupID}/members/{userID} [post]
+func (h *Handler) SetMember(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid group id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	userID, err := uuid.FromString(c.Param("userID"))
+	if err != nil {
+		h.logger.Infof("Invalid user id '%s'", c.Param("userID"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	var req SetMemberRequest
+	c.BindJSON(&req)
+	if err := req.validate(); err != nil {
+		h.logger.Infof("Invalid set member request: %w", err)
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if role := h.checkAccess(c, id, setMemberMethodName); role == nil {
+		return
+	}
+
+	if err := h.groupsUsecase.SetMember(id, userID, models.GroupRoleFromString(req.Role)); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// RemoveMember
+// @Summary		Remove group member
+// @Tags		Groups
+// @Description	Remove user from group. Any member is allowed to remove himself
+// @Produce     json
+// @Param		groupID path string true 		"Group ID"
+// @Param		userID path string true 		"User ID"
+// @Success		200								"Member removed"
+// @Failure		400			{object}	error	"Incorrect input"
+// @Failure		409			{object}	error	"Group would be left without admin"
+// @Failure		500			{object}	error	"Server error"
+// @Router		/api/groups/{groupID}/members/{userID} [delete]
+func (h *Handler) RemoveMember(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid group id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	userID, err := uuid.FromString(c.Param("userID"))
+	if err != nil {
+		h.logger.Infof("Invalid user id '%s'", c.Param("userID"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	method := removeMemberMethodName
+	if callerID, err := auth.GetUserId(c); err == nil && callerID == userID {
+		method = leaveMethodName
+	}
+
+	if role := h.checkAccess(c, id, method); role == nil {
+		return
+	}
+
+	if err := h.groupsUsecase.RemoveMember(id, userID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,61 @@
+package http
+
+import "github.com/yarikTri/archipelago-notes-api/internal/models"
+
+type methodName uint8
+
+const (
+	getMethodName methodName = iota
+	updateMethodName
+	deleteMethodName
+	listMembersMethodName
+	setMemberMethodName
+	removeMemberMethodName
+	leaveMethodName
+)
+
+func (mn *methodName) String() string {
+	switch *mn {
+	case getMethodName:
+		return "get"
+	case updateMethodName:
+		return "update"
+	case deleteMethodName:
+		return "delete"
+	case listMembersMethodName:
+		return "list_members"
+	case setMemberMethodName:
+		return "set_member"
+	case removeMemberMethodName:
+		return "remove_member"
+	case leaveMethodName:
+		return "leave"
+	}
+
+	return ""
+}
+
+var methodsRolesMap = map[methodName][]models.GroupRole{
+	getMethodName:          {models.MemberGroupRole, models.AdminGroupRole},
+	updateMethodName:       {models.AdminGroupRole},
+	deleteMethodName:       {models.AdminGroupRole},
+	listMembersMethodName:  {models.MemberGroupRole, models.AdminGroupRole},
+	setMemberMethodName:    {models.AdminGroupRole},
+	removeMemberMethodName: {models.AdminGroupRole},
+	leaveMethodName:        {models.MemberGroupRole, models.AdminGroupRole},
+}
+
+func getAllowedMethods(role models.GroupRole) []string {
+	allowedMethods := make([]string, 0)
+
+	for method, roles := range methodsRolesMap {
+		for _, r := range roles {
+			if r == role {
+				allowedMethods = append(allowedMethods, method.String())
+				break
+			}
+		}
+	}
+
+	return allowedMethods
+}
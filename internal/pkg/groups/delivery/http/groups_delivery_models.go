@@ -0,0 +1,54 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+
+	valid "github.com/asaskevich/govalidator"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
+
+type CreateGroupRequest struct {
+	Name string `json:"name" valid:"required"`
+}
+
+func (cgr *CreateGroupRequest) validate() error {
+	_, err := valid.ValidateStruct(cgr)
+	return err
+}
+
+type UpdateGroupRequest struct {
+	Name string `json:"name" valid:"required"`
+}
+
+func (ugr *UpdateGroupRequest) validate() error {
+	_, err := valid.ValidateStruct(ugr)
+	return err
+}
+
+type SetMemberRequest struct {
+	Role string `json:"role" valid:"required"`
+}
+
+func (smr *SetMemberRequest) validate() error {
+	if models.GroupRoleFromString(smr.Role) == models.UndefinedGroupRole {
+		return errors.New(fmt.Sprintf("Invalid group role: %s", smr.Role))
+	}
+
+	_, err := valid.ValidateStruct(smr)
+	return err
+}
+
+type GetGroupResponse struct {
+	Group          *models.GroupTransfer `json:"group"`
+	Role           string                `json:"role"`
+	AllowedMethods []string              `json:"allowed_methods"`
+}
+
+type ListGroupsResponse struct {
+	Groups []*models.GroupTransfer `json:"groups"`
+}
+
+type ListMembersResponse struct {
+	Members []*models.GroupMemberTransfer `json:"members"`
+}
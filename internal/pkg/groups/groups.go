@@ -0,0 +1,38 @@
+package groups
+
+import (
+	"errors"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
+
+// ErrLastAdmin is returned on attempt to remove or demote the only admin of group
+var ErrLastAdmin = errors.New("group must have at least one admin")
+
+type Usecase interface {
+	Get(groupID uuid.UUID) (*models.Group, error)
+	ListByUser(userID uuid.UUID) ([]*models.Group, error)
+	Create(name string, creatorID uuid.UUID) (*models.Group, error)
+	Update(group models.Group) (*models.Group, error)
+	Delete(groupID uuid.UUID) error
+
+	GetMemberRole(groupID uuid.UUID, userID uuid.UUID) (models.GroupRole, error)
+	ListMembers(groupID uuid.UUID) ([]*models.GroupMember, error)
+	SetMember(groupID uuid.UUID, userID uuid.UUID, role models.GroupRole) error
+	RemoveMember(groupID uuid.UUID, userID uuid.UUID) error
+}
+
+type Repository interface {
+	GetByID(groupID uuid.UUID) (*models.Group, error)
+	ListByUser(userID uuid.UUID) ([]*models.Group, error)
+	Create(name string, creatorID uuid.UUID) (*models.Group, error)
+	Update(group models.Group) (*models.Group, error)
+	DeleteByID(groupID uuid.UUID) error
+
+	GetMemberRole(groupID uuid.UUID, userID uuid.UUID) (models.GroupRole, error)
+	ListMembers(groupID uuid.UUID) ([]*models.GroupMember, error)
+	// SetMember and DeleteMember return ErrLastAdmin on demoting or removing the only admin of group
+	SetMember(groupID uuid.UUID, userID uuid.UUID, role models.GroupRole) error
+	DeleteMember(groupID uuid.UUID, userID uuid.UUID) error
+}
@@ -0,0 +1,270 @@
+package postgresql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/repository"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/groups"
+)
+
+// PostgreSQL implements groups.Repository
+type PostgreSQL struct {
+	db *sqlx.DB
+}
+
+func NewPostgreSQL(db *sqlx.DB) *PostgreSQL {
+	return &PostgreSQL{
+		db: db,
+	}
+}
+
+func (p *PostgreSQL) GetByID(groupID uuid.UUID) (*models.Group, error) {
+	query := fmt.Sprint(
+		`SELECT id, name, creator_id, created_at
+			FROM user_group
+			WHERE id = $1`,
+	)
+
+	var group models.Group
+	if err := p.db.Get(&group, query, groupID.String()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: groupID}, err)
+		}
+
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return &group, nil
+}
+
+func (p *PostgreSQL) ListByUser(userID uuid.UUID) ([]*models.Group, error) {
+	query := fmt.Sprint(
+		`SELECT g.id, g.name, g.creator_id, g.created_at
+			FROM user_group g
+				INNER JOIN user_group_member gm ON g.id = gm.group_id
+			WHERE gm.user_id = $1
+			ORDER BY g.name, g.id`,
+	)
+
+	var groups []*models.Group
+	if err := p.db.Select(&groups, query, userID.String()); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return groups, nil
+}
+
+func (p *PostgreSQL) Create(name string, creatorID uuid.UUID) (*models.Group, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprint(
+		`INSERT INTO user_group (name, creator_id)
+			VALUES ($1, $2)
+			RETURNING id, name, creator_id, created_at`,
+	)
+
+	var group models.Group
+	if err := tx.Get(&group, query, name, creatorID.String()); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	adminRole := models.AdminGroupRole
+	if _, err := tx.Exec(
+		`INSERT INTO user_group_member (group_id, user_id, role) VALUES ($1, $2, $3)`,
+		group.ID.String(), creatorID.String(), adminRole.String(),
+	); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return &group, nil
+}
+
+func (p *PostgreSQL) Update(group models.Group) (*models.Group, error) {
+	query := fmt.Sprint(
+		`UPDATE user_group
+			SET name = $1
+			WHERE id = $2
+			RETURNING id, name, creator_id, created_at`,
+	)
+
+	var updated models.Group
+	if err := p.db.Get(&updated, query, group.Name, group.ID.String()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: group.ID}, err)
+		}
+
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return &updated, nil
+}
+
+// DeleteByID deletes group with its memberships and accesses given to it
+func (p *PostgreSQL) DeleteByID(groupID uuid.UUID) error {
+	query := fmt.Sprint(
+		`DELETE FROM user_group WHERE id = $1`,
+	)
+
+	resExec, err := p.db.Exec(query, groupID.String())
+	if err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	deleted, err := resExec.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to check RowsAffected: %w", err)
+	}
+
+	if deleted == 0 {
+		return fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: groupID})
+	}
+
+	return nil
+}
+
+// GetMemberRole returns UndefinedGroupRole if user isn't member of existing group
+// and sql.ErrNoRows if group doesn't exist
+func (p *PostgreSQL) GetMemberRole(groupID uuid.UUID, userID uuid.UUID) (models.GroupRole, error) {
+	query := fmt.Sprint(
+		`SELECT COALESCE(
+				(SELECT role FROM user_group_member WHERE group_id = g.id AND user_id = $2),
+				''
+			)
+			FROM user_group g
+			WHERE g.id = $1`,
+	)
+
+	var role string
+	if err := p.db.Get(&role, query, groupID.String(), userID.String()); err != nil {
+		return models.UndefinedGroupRole, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return models.GroupRoleFromString(role), nil
+}
+
+func (p *PostgreSQL) ListMembers(groupID uuid.UUID) ([]*models.GroupMember, error) {
+	query := fmt.Sprint(
+		`SELECT gm.group_id, gm.user_id, u.email, u.name, gm.role, gm.added_at
+			FROM user_group_member gm
+				INNER JOIN "user" u ON gm.user_id = u.id
+			WHERE gm.group_id = $1
+			ORDER BY gm.added_at, gm.user_id`,
+	)
+
+	var members []*models.GroupMember
+	if err := p.db.Select(&members, query, groupID.String()); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return members, nil
+}
+
+// SetMember returns groups.ErrLastAdmin on demoting the only admin of group
+func (p *PostgreSQL) SetMember(groupID uuid.UUID, userID uuid.UUID, role models.GroupRole) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if role != models.AdminGroupRole {
+		if err := checkNotLastAdmin(tx, groupID, userID); err != nil {
+			return err
+		}
+	}
+
+	query := fmt.Sprint(
+		`INSERT INTO user_group_member
+		(group_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (group_id, user_id) DO UPDATE SET role = EXCLUDED.role;`,
+	)
+
+	if _, err := tx.Exec(query, groupID.String(), userID.String(), role.String()); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == repository.ForeignKeyViolationCode {
+			return fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: userID}, err)
+		}
+
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteMember returns groups.ErrLastAdmin on removing the only admin of group
+func (p *PostgreSQL) DeleteMember(groupID uuid.UUID, userID uuid.UUID) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkNotLastAdmin(tx, groupID, userID); err != nil {
+		return err
+	}
+
+	query := fmt.Sprint(
+		`DELETE FROM user_group_member WHERE group_id = $1 AND user_id = $2`,
+	)
+
+	resExec, err := tx.Exec(query, groupID.String(), userID.String())
+	if err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	deleted, err := resExec.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to check RowsAffected: %w", err)
+	}
+
+	if deleted == 0 {
+		return fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: userID})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// checkNotLastAdmin locks admins of group, so concurrent demotions of admins are checked one by one
+// and can't leave group without admin
+func checkNotLastAdmin(tx *sqlx.Tx, groupID uuid.UUID, userID uuid.UUID) error {
+	query := fmt.Sprint(
+		`SELECT user_id
+		FROM user_group_member
+		WHERE group_id = $1 AND role = 'admin'
+		FOR UPDATE`,
+	)
+
+	var adminIDs []uuid.UUID
+	if err := tx.Select(&adminIDs, query, groupID.String()); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	if len(adminIDs) == 1 && adminIDs[0] == userID {
+		return groups.ErrLastAdmin
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/groups"
+)
+
+// Usecase implements groups.Usecase
+type Usecase struct {
+	repo groups.Repository
+}
+
+func NewUsecase(gr groups.Repository) *Usecase {
+	return &Usecase{
+		repo: gr,
+	}
+}
+
+func (u *Usecase) Get(groupID uuid.UUID) (*models.Group, error) {
+	return u.repo.GetByID(groupID)
+}
+
+func (u *Usecase) ListByUser(userID uuid.UUID) ([]*models.Group, error) {
+	return u.repo.ListByUser(userID)
+}
+
+// Create creates group with its creator as admin
+func (u *Usecase) Create(name string, creatorID uuid.UUID) (*models.Group, error) {
+	return u.repo.Create(name, creatorID)
+}
+
+func (u *Usecase) Update(group models.Group) (*models.Group, error) {
+	return u.repo.Update(group)
+}
+
+func (u *Usecase) Delete(groupID uuid.UUID) error {
+	return u.repo.DeleteByID(groupID)
+}
+
+func (u *Usecase) GetMemberRole(groupID uuid.UUID, userID uuid.UUID) (models.GroupRole, error) {
+	return u.repo.GetMemberRole(groupID, userID)
+}
+
+func (u *Usecase) ListMembers(groupID uuid.UUID) ([]*models.GroupMember, error) {
+	return u.repo.ListMembers(groupID)
+}
+
+// SetMember adds user to group or changes their role in it
+func (u *Usecase) SetMember(groupID uuid.UUID, userID uuid.UUID, role models.GroupRole) error {
+	if role == models.UndefinedGroupRole {
+		return errors.New(fmt.Sprintf("(usecase) Invalid group role %s", role.String()))
+	}
+
+	return u.repo.SetMember(groupID, userID, role)
+}
+
+func (u *Usecase) RemoveMember(groupID uuid.UUID, userID uuid.UUID) error {
+	return u.repo.DeleteMember(groupID, userID)
+}
@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-park-mail-ru/2023_1_Technokaif/pkg/logger"
 	"github.com/gofrs/uuid/v5"
 	"github.com/yarikTri/archipelago-notes-api/internal/common/http/auth"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/repository"
 	"github.com/yarikTri/archipelago-notes-api/internal/common/utils"
 	"github.com/yarikTri/archipelago-notes-api/internal/models"
 	"github.com/yarikTri/archipelago-notes-api/internal/pkg/notes"
@@ -27,18 +29,26 @@ func NewHandler(nu notes.Usecase, l logger.Logger) *Handler {
 	}
 }
 
+// checkAccess resolves the highest of user's access and access given by share link token,
+// share link doesn't apply to user black listed on note. Anonymous user is allowed only with a valid share link token
 func (h *Handler) checkAccess(c *gin.Context, noteID uuid.UUID, method methodName) *models.NoteAccess {
 	accessForbidden := func(userID, noteID string) {
 		h.logger.Infof("Access forbidden for user %s, note %s, method %s", userID, noteID, method)
 		c.JSON(http.StatusForbidden, "Forbidden")
 	}
 
+	token, password := auth.GetShareToken(c)
+
 	userID, err := auth.GetUserId(c)
-	if err != nil {
+	anonymous := err != nil || userID == uuid.Nil
+	if anonymous && token == "" {
 		h.logger.Infof("Unathorized request for note %s, method %s", noteID.String(), method)
 		c.JSON(http.StatusUnauthorized, "")
 		return nil
 	}
+	if anonymous {
+		userID = uuid.Nil
+	}
 
 	access, err := h.notesUsecase.GetUserAccess(noteID, userID)
 	if errors.Is(err, sql.ErrNoRows) {
@@ -50,6 +60,28 @@ func (h *Handler) checkAccess(c *gin.Context, noteID uuid.UUID, method methodNam
 		c.JSON(http.StatusInternalServerError, "Can't check access")
 		return nil
 	}
+	if anonymous {
+		access = models.EmptyNoteAccess
+	}
+
+	if token != "" {
+		shareLinkAccess, err := h.notesUsecase.GetShareLinkAccess(noteID, userID, token, password)
+		if err != nil {
+			h.logger.Errorf("Error while check share link access for note %s: %w", noteID.String(), err)
+			c.JSON(http.StatusInternalServerError, "Can't check access")
+			return nil
+		}
+
+		if shareLinkAccess > access {
+			access = shareLinkAccess
+		}
+	}
+
+	if anonymous && access == models.EmptyNoteAccess {
+		h.logger.Infof("Invalid share link for note %s, method %s", noteID.String(), method)
+		c.JSON(http.StatusUnauthorized, "")
+		return nil
+	}
 
 	for _, a := range methodsAccessMap[method] {
 		if a == access {
@@ -97,9 +129,17 @@ func (h *Handler) Get(c *gin.Context) {
 // List
 // @Summary		List notes
 // @Tags		Notes
-// @Description	Get all notes user has access to
+// @Description	Get page of notes user has access to
 // @Accept 		json
 // @Produce     json
+// @Param		sort query string false 					"Sort field: title, created, updated (default)"
+// @Param		order query string false 					"Sort order: asc, desc"
+// @Param		limit query int false 						"Page size"
+// @Param		cursor query string false 					"Cursor of page from previous response"
+// @Param		dir_id query int false 						"Dir to list subtree of"
+// @Param		creator_id query string false 				"Creator ID"
+// @Param		access query string false 					"Access level: owned, shared"
+// @Param		has_summary query bool false 				"Whether note has attached summaries"
 // @Success		200			{object}	ListNotesResponse	"Notes"
 // @Failure		400			{object}	error				"Incorrect input"
 // @Failure		500			{object}	error				"Server error"
@@ -112,7 +152,21 @@ func (h *Handler) List(c *gin.Context) {
 		return
 	}
 
-	notes, err := h.notesUsecase.List(userID)
+	var req ListNotesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Infof("Invalid list notes request: %w", err)
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	opts, err := req.ToOptions()
+	if err != nil {
+		h.logger.Infof("Invalid list notes request: %w", err)
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	notes, nextCursor, err := h.notesUsecase.List(userID, opts)
 	if err != nil {
 		h.logger.Errorf("Error while listing notes: %w", err)
 		c.JSON(http.StatusInternalServerError, err)
@@ -125,7 +179,13 @@ func (h *Handler) List(c *gin.Context) {
 		notesTransfers = append(notesTransfers, note.ToTransfer(getAllowedMethods(access)))
 	}
 
-	c.JSON(http.StatusOK, ListNotesResponse{notesTransfers})
+	var encodedNextCursor *string
+	if nextCursor != nil {
+		encoded := nextCursor.Encode()
+		encodedNextCursor = &encoded
+	}
+
+	c.JSON(http.StatusOK, ListNotesResponse{Notes: notesTransfers, NextCursor: encodedNextCursor})
 }
 
 // Create
@@ -137,6 +197,7 @@ func (h *Handler) List(c *gin.Context) {
 // @Param		noteInfo	body		CreateNoteRequest		true	"Note info"
 // @Success		200			{object}	models.NoteTransfer				"Note created"
 // @Failure		400			{object}	error							"Incorrect input"
+// @Failure		403			{object}	error							"Forbidden to create note in dir"
 // @Failure		500			{object}	error							"Server error"
 // @Router		/api/notes [post]
 func (h *Handler) Create(c *gin.Context) {
@@ -157,6 +218,14 @@ func (h *Handler) Create(c *gin.Context) {
 	}
 
 	createdNote, err := h.notesUsecase.Create(req.DirID, req.AutomergeURL, req.Title, userID)
+	if errors.Is(err, notes.ErrDirForbidden) {
+		c.JSON(http.StatusForbidden, "Forbidden to create note in dir")
+		return
+	}
+	if errors.Is(err, notes.ErrDirNotFound) {
+		c.JSON(http.StatusBadRequest, "Dir not found")
+		return
+	}
 	if err != nil {
 		h.logger.Errorf("Error: %w", err)
 		c.JSON(http.StatusInternalServerError, err)
@@ -176,6 +245,7 @@ func (h *Handler) Create(c *gin.Context) {
 // @Param		noteInfo	body		UpdateNoteRequest		true	"Note info"
 // @Success		200			{object}	models.NoteTransfer		"Updated note"
 // @Failure		400			{object}	error					"Incorrect input"
+// @Failure		403			{object}	error					"Forbidden to move note to dir"
 // @Failure		500			{object}	error					"Server error"
 // @Router		/api/notes/{noteID} [post]
 func (h *Handler) Update(c *gin.Context) {
@@ -209,7 +279,16 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
-	updatedNote, err := h.notesUsecase.Update(reqNote)
+	userID, _ := auth.GetUserId(c)
+	updatedNote, err := h.notesUsecase.Update(reqNote, userID)
+	if errors.Is(err, notes.ErrDirForbidden) {
+		c.JSON(http.StatusForbidden, "Forbidden to move note to dir")
+		return
+	}
+	if errors.Is(err, notes.ErrDirNotFound) {
+		c.JSON(http.StatusBadRequest, "Dir not found")
+		return
+	}
 	if err != nil {
 		h.logger.Errorf("Error: %w", err)
 		c.JSON(http.StatusInternalServerError, err)
@@ -222,7 +301,7 @@ func (h *Handler) Update(c *gin.Context) {
 // Delete
 // @Summary		Delete note
 // @Tags		Notes
-// @Description	Delete note by ID
+// @Description	Move note to trash by ID
 // @Produce     json
 // @Param		noteID path string true 		"Note ID"
 // @Success		200								"Note deleted"
@@ -242,7 +321,8 @@ func (h *Handler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.notesUsecase.DeleteByID(id); err != nil {
+	userID, _ := auth.GetUserId(c)
+	if err := h.notesUsecase.DeleteByID(id, userID); err != nil {
 		h.logger.Errorf("Error: %w", err)
 		c.JSON(http.StatusInternalServerError, err)
 		return
@@ -251,6 +331,100 @@ func (h *Handler) Delete(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+// GetHistory
+// @Summary		Get history
+// @Tags		Notes
+// @Description	Get revisions of note, newest first
+// @Produce     json
+// @Param		noteID path string true 						"Note ID"
+// @Success		200			{object}	NoteHistoryResponse		"Revisions"
+// @Failure		400			{object}	error					"Incorrect input"
+// @Failure		500			{object}	error					"Server error"
+// @Router		/api/notes/{noteID}/history [get]
+func (h *Handler) GetHistory(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid note id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if access := h.checkAccess(c, id, getHistoryMethodName); access == nil {
+		return
+	}
+
+	revisions, err := h.notesUsecase.GetHistory(id)
+	if err != nil {
+		h.logger.Errorf("Error while getting history of note with id %s: %w", id.String(), err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	revisionsTransfers := make([]*models.NoteRevisionTransfer, 0, len(revisions))
+	for _, r := range revisions {
+		revisionsTransfers = append(revisionsTransfers, r.ToTransfer())
+	}
+
+	c.JSON(http.StatusOK, NoteHistoryResponse{Revisions: revisionsTransfers})
+}
+
+// Revert
+// @Summary		Revert note
+// @Tags		Notes
+// @Description	Revert note's title, dir and default access to revision
+// @Produce     json
+// @Param		noteID path string true 						"Note ID"
+// @Param		revision path int true 							"Revision"
+// @Success		200			{object}	models.NoteTransfer		"Reverted note"
+// @Failure		400			{object}	error					"Incorrect input"
+// @Failure		403			{object}	error					"Revision's dir is forbidden"
+// @Failure		404			{object}	error					"Revision not found"
+// @Failure		500			{object}	error					"Server error"
+// @Router		/api/notes/{noteID}/history/{revision}/revert [post]
+func (h *Handler) Revert(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid note id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil {
+		h.logger.Infof("Invalid revision '%s'", c.Param("revision"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	access := h.checkAccess(c, id, revertMethodName)
+	if access == nil {
+		return
+	}
+
+	userID, _ := auth.GetUserId(c)
+	revertedNote, err := h.notesUsecase.Revert(id, revision, userID)
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		c.JSON(http.StatusNotFound, "Revision not found")
+		return
+	}
+	if errors.Is(err, notes.ErrDirForbidden) {
+		c.JSON(http.StatusForbidden, "Forbidden to move note to revision's dir")
+		return
+	}
+	if errors.Is(err, notes.ErrDirNotFound) {
+		c.JSON(http.StatusBadRequest, "Revision's dir not found")
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("Error while reverting note with id %s: %w", id.String(), err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revertedNote.ToTransfer(getAllowedMethods(*access)))
+}
+
 // SetAccess
 // @Summary		Set Access
 // @Tags		Notes
@@ -291,7 +465,8 @@ func (h *Handler) SetAccess(c *gin.Context) {
 		return
 	}
 
-	if err := h.notesUsecase.SetUserAccess(id, userID, models.NoteAccessFromString(req.Access), req.WithInvitation); err != nil {
+	grantedBy, _ := auth.GetUserId(c)
+	if err := h.notesUsecase.SetUserAccess(id, userID, models.NoteAccessFromString(req.Access), req.WithInvitation, grantedBy); err != nil {
 		h.logger.Errorf("Error: %w", err)
 		c.JSON(http.StatusInternalServerError, err)
 		return
@@ -300,6 +475,430 @@ func (h *Handler) SetAccess(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+// ListAccess
+// @Summary		List collaborators
+// @Tags		Notes
+// @Description	Get note's owner, users and groups with accesses given directly on note
+// @Produce     json
+// @Param		noteID path string true 							"Note ID"
+// @Success		200			{object}	ListCollaboratorsResponse	"Collaborators"
+// @Failure		400			{object}	error						"Incorrect input"
+// @Failure		403			{object}	error						"Forbidden"
+// @Failure		500			{object}	error						"Server error"
+// @Router		/api/notes/{noteID}/access [get]
+func (h *Handler) ListAccess(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid note id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if access := h.checkAccess(c, id, listAccessMethodName); access == nil {
+		return
+	}
+
+	note, err := h.notesUsecase.GetByID(id)
+	if err != nil {
+		h.logger.Errorf("Error while getting note with id %s: %w", id.String(), err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	userGrants, groupGrants, err := h.notesUsecase.ListCollaborators(id)
+	if err != nil {
+		h.logger.Errorf("Error while listing collaborators of note with id %s: %w", id.String(), err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	users := make([]*models.NoteCollaboratorTransfer, 0, len(userGrants))
+	for _, grant := range userGrants {
+		users = append(users, grant.ToTransfer())
+	}
+
+	groups := make([]*models.NoteGroupCollaboratorTransfer, 0, len(groupGrants))
+	for _, grant := range groupGrants {
+		groups = append(groups, grant.ToTransfer())
+	}
+
+	c.JSON(http.StatusOK, ListCollaboratorsResponse{
+		OwnerID: note.CreatorID.String(),
+		Users:   users,
+		Groups:  groups,
+	})
+}
+
+// RemoveAccess
+// @Summary		Remove access
+// @Tags		Notes
+// @Description	Revoke access to note given directly to user
+// @Produce     json
+// @Param		noteID path string true 		"Note ID"
+// @Param		userID path string true 		"User ID"
+// @Success		200								"Access removed"
+// @Failure		400			{object}	error	"Incorrect input"
+// @Failure		404			{object}	error	"User access not found"
+// @Failure		500			{object}	error	"Server error"
+// @Router		/api/notes/{noteID}/access/{userID} [delete]
+func (h *Handler) RemoveAccess(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid note id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	userID, err := uuid.FromString(c.Param("userID"))
+	if err != nil {
+		h.logger.Infof("Invalid user id '%s'", c.Param("userID"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if access := h.checkAccess(c, id, setAccessMethodName); access == nil {
+		return
+	}
+
+	removedBy, _ := auth.GetUserId(c)
+	err = h.notesUsecase.RemoveUserAccess(id, userID, removedBy)
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		c.JSON(http.StatusNotFound, "User access not found")
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("Error while removing access to note %s for user %s: %w", id.String(), userID.String(), err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// TransferOwnership
+// @Summary		Transfer ownership
+// @Tags		Notes
+// @Description	Make user owner of note. Allowed only for the current owner, who keeps manage access
+// @Produce     json
+// @Param		noteID path string true 		"Note ID"
+// @Param		userID path string true 		"New owner ID"
+// @Success		200								"Ownership transferred"
+// @Failure		400			{object}	error	"Incorrect input"
+// @Failure		403			{object}	error	"User isn't owner of note"
+// @Failure		404			{object}	error	"User not found"
+// @Failure		500			{object}	error	"Server error"
+// @Router		/api/notes/{noteID}/transfer_ownership/{userID} [post]
+func (h *Handler) TransferOwnership(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid note id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	newOwnerID, err := uuid.FromString(c.Param("userID"))
+	if err != nil {
+		h.logger.Infof("Invalid user id '%s'", c.Param("userID"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if access := h.checkAccess(c, id, transferOwnershipMethodName); access == nil {
+		return
+	}
+
+	userID, _ := auth.GetUserId(c)
+	err = h.notesUsecase.TransferOwnership(id, newOwnerID, userID)
+	if errors.Is(err, notes.ErrNotOwner) {
+		h.logger.Infof("User %s isn't owner of note %s, ownership can't be transferred", userID.String(), id.String())
+		c.JSON(http.StatusForbidden, "Forbidden")
+		return
+	}
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		c.JSON(http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("Error while transferring ownership of note %s: %w", id.String(), err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// SetGroupAccess
+// @Summary		Set group access
+// @Tags		Notes
+// @Description	Set access to note to every member of group
+// @Accept		json
+// @Produce     json
+// @Param		noteID path string true 						"Note ID"
+// @Param		groupID path string true 						"Group to set access ID"
+// @Param		access	body	SetGroupAccessRequest true		"Access info"
+// @Success		200												"Access set"
+// @Failure		400			{object}	error					"Incorrect input"
+// @Failure		404			{object}	error					"Group not found"
+// @Failure		500			{object}	error					"Server error"
+// @Router		/api/notes/{noteID}/group_access/{groupID} [post]
+func (h *Handler) SetGroupAccess(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid note id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	groupID, err := uuid.FromString(c.Param("groupID"))
+	if err != nil {
+		h.logger.Infof("Invalid group id '%s'", c.Param("groupID"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	var req SetGroupAccessRequest
+	c.BindJSON(&req)
+	if err := req.validate(); err != nil {
+		h.logger.Infof("Invalid set group access request: %w", err)
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if access := h.checkAccess(c, id, setAccessMethodName); access == nil {
+		return
+	}
+
+	grantedBy, _ := auth.GetUserId(c)
+	err = h.notesUsecase.SetGroupAccess(id, groupID, models.NoteAccessFromString(req.Access), grantedBy)
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		c.JSON(http.StatusNotFound, "Group not found")
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("Error while setting access to note %s for group %s: %w", id.String(), groupID.String(), err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// RemoveGroupAccess
+// @Summary		Remove group access
+// @Tags		Notes
+// @Description	Revoke access to note given to group
+// @Produce     json
+// @Param		noteID path string true 		"Note ID"
+// @Param		groupID path string true 		"Group ID"
+// @Success		200								"Access removed"
+// @Failure		400			{object}	error	"Incorrect input"
+// @Failure		404			{object}	error	"Group access not found"
+// @Failure		500			{object}	error	"Server error"
+// @Router		/api/notes/{noteID}/group_access/{groupID} [delete]
+func (h *Handler) RemoveGroupAccess(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid note id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	groupID, err := uuid.FromString(c.Param("groupID"))
+	if err != nil {
+		h.logger.Infof("Invalid group id '%s'", c.Param("groupID"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if access := h.checkAccess(c, id, setAccessMethodName); access == nil {
+		return
+	}
+
+	removedBy, _ := auth.GetUserId(c)
+	err = h.notesUsecase.RemoveGroupAccess(id, groupID, removedBy)
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		c.JSON(http.StatusNotFound, "Group access not found")
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("Error while removing access to note %s for group %s: %w", id.String(), groupID.String(), err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// CreateShareLink
+// @Summary		Create share link
+// @Tags		Notes
+// @Description	Create link giving access to note to anyone with its token. Token is returned only once
+// @Accept		json
+// @Produce     json
+// @Param		noteID path string true 							"Note ID"
+// @Param		link	body	CreateShareLinkRequest true			"Share link info"
+// @Success		200			{object}	models.NoteShareLinkTransfer	"Share link with token"
+// @Failure		400			{object}	error						"Incorrect input"
+// @Failure		500			{object}	error						"Server error"
+// @Router		/api/notes/{noteID}/share_links [post]
+func (h *Handler) CreateShareLink(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid note id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if access := h.checkAccess(c, id, manageShareLinksMethodName); access == nil {
+		return
+	}
+
+	var req CreateShareLinkRequest
+	c.BindJSON(&req)
+	if err := req.validate(); err != nil {
+		h.logger.Infof("Invalid create share link request: %w", err)
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, _ := auth.GetUserId(c)
+	link, token, err := h.notesUsecase.CreateShareLink(id, userID, models.NoteAccessFromString(req.Access), req.ExpiresAt, req.Password)
+	if err != nil {
+		h.logger.Errorf("Error while creating share link for note %s: %w", id.String(), err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	linkTransfer := link.ToTransfer()
+	linkTransfer.Token = token
+
+	c.JSON(http.StatusOK, linkTransfer)
+}
+
+// ListShareLinks
+// @Summary		List share links
+// @Tags		Notes
+// @Description	List share links of note including revoked and expired ones
+// @Produce     json
+// @Param		noteID path string true 						"Note ID"
+// @Success		200			{object}	ListShareLinksResponse	"Share links"
+// @Failure		400			{object}	error					"Incorrect input"
+// @Failure		500			{object}	error					"Server error"
+// @Router		/api/notes/{noteID}/share_links [get]
+func (h *Handler) ListShareLinks(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid note id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if access := h.checkAccess(c, id, manageShareLinksMethodName); access == nil {
+		return
+	}
+
+	links, err := h.notesUsecase.ListShareLinks(id)
+	if err != nil {
+		h.logger.Errorf("Error while listing share links of note %s: %w", id.String(), err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	linksTransfers := make([]*models.NoteShareLinkTransfer, 0, len(links))
+	for _, l := range links {
+		linksTransfers = append(linksTransfers, l.ToTransfer())
+	}
+
+	c.JSON(http.StatusOK, ListShareLinksResponse{ShareLinks: linksTransfers})
+}
+
+// RevokeShareLink
+// @Summary		Revoke share link
+// @Tags		Notes
+// @Description	Revoke share link of note
+// @Param		noteID path string true 		"Note ID"
+// @Param		linkID path string true 		"Share link ID"
+// @Success		200								"Share link revoked"
+// @Failure		400			{object}	error	"Incorrect input"
+// @Failure		404			{object}	error	"Share link not found"
+// @Failure		500			{object}	error	"Server error"
+// @Router		/api/notes/{noteID}/share_links/{linkID} [delete]
+func (h *Handler) RevokeShareLink(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid note id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	linkID, err := uuid.FromString(c.Param("linkID"))
+	if err != nil {
+		h.logger.Infof("Invalid share link id '%s'", c.Param("linkID"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if access := h.checkAccess(c, id, manageShareLinksMethodName); access == nil {
+		return
+	}
+
+	revokedBy, _ := auth.GetUserId(c)
+	err = h.notesUsecase.RevokeShareLink(id, linkID, revokedBy)
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		c.JSON(http.StatusNotFound, "Share link not found")
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("Error while revoking share link %s: %w", linkID.String(), err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// GetAuditLog
+// @Summary		Get audit log
+// @Tags		Notes
+// @Description	Get events of note and its accesses from the newest to the oldest
+// @Produce     json
+// @Param		noteID path string true 						"Note ID"
+// @Success		200			{object}	AuditLogResponse	"Audit events"
+// @Failure		400			{object}	error				"Incorrect input"
+// @Failure		500			{object}	error				"Server error"
+// @Router		/api/notes/{noteID}/audit [get]
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid note id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if access := h.checkAccess(c, id, getAuditLogMethodName); access == nil {
+		return
+	}
+
+	events, err := h.notesUsecase.GetAuditLog(id)
+	if err != nil {
+		h.logger.Errorf("Error while getting audit log of note with id %s: %w", id.String(), err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	eventsTransfers := make([]*models.AuditEventTransfer, 0, len(events))
+	for _, event := range events {
+		eventsTransfers = append(eventsTransfers, event.ToTransfer())
+	}
+
+	c.JSON(http.StatusOK, AuditLogResponse{Events: eventsTransfers})
+}
+
 func (h *Handler) CheckOwner(c *gin.Context) {
 	noteID, err := uuid.FromString(c.Param("id"))
 	if err != nil {
@@ -340,12 +939,14 @@ func (h *Handler) AttachNoteToSummary(c *gin.Context) {
 	if err != nil {
 		h.logger.Errorf("Failed to cast summ_id to uuid %s: %w", summID, err)
 		c.JSON(http.StatusBadRequest, err)
+		return
 	}
 
 	noteID, err := uuid.FromString(c.Param("id"))
 	if err != nil {
 		h.logger.Errorf("Failed to cast note_id to uuid %s: %w", noteID, err)
 		c.JSON(http.StatusBadRequest, err)
+		return
 	}
 
 	access := h.checkAccess(c, noteID, attachSummaryMethodName)
@@ -353,8 +954,20 @@ func (h *Handler) AttachNoteToSummary(c *gin.Context) {
 		return
 	}
 
-	err = h.notesUsecase.AttachNoteToSummary(summID, noteID)
+	userID, _ := auth.GetUserId(c)
+
+	err = h.notesUsecase.AttachNoteToSummary(summID, noteID, userID)
 	if err != nil {
+		var notFoundErr *repository.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			c.JSON(http.StatusNotFound, "Summary not found")
+			return
+		}
+		if errors.Is(err, notes.ErrSummaryAccessForbidden) {
+			c.JSON(http.StatusForbidden, err.Error())
+			return
+		}
+
 		h.logger.Errorf("Error while attaching note to summary: %w", err)
 		c.JSON(http.StatusInternalServerError, err)
 		return
@@ -11,6 +11,12 @@ const (
 	setAccessMethodName
 	attachSummaryMethodName
 	getSummaryListMethodName
+	getHistoryMethodName
+	revertMethodName
+	manageShareLinksMethodName
+	getAuditLogMethodName
+	listAccessMethodName
+	transferOwnershipMethodName
 )
 
 func (mn *methodName) String() string {
@@ -27,6 +33,18 @@ func (mn *methodName) String() string {
 		return "attach_summary"
 	case getSummaryListMethodName:
 		return "get_summary_list"
+	case getHistoryMethodName:
+		return "get_history"
+	case revertMethodName:
+		return "revert"
+	case manageShareLinksMethodName:
+		return "manage_share_links"
+	case getAuditLogMethodName:
+		return "get_audit_log"
+	case listAccessMethodName:
+		return "list_access"
+	case transferOwnershipMethodName:
+		return "transfer_ownership"
 	}
 
 	return ""
@@ -39,6 +57,16 @@ var methodsAccessMap = map[methodName][]models.NoteAccess{
 	setAccessMethodName:      {models.ManageAccessNoteAccess},
 	attachSummaryMethodName:  {models.WriteNoteAccess, models.ModifyNoteAccess, models.ManageAccessNoteAccess},
 	getSummaryListMethodName: {models.ReadNoteAccess, models.WriteNoteAccess, models.ModifyNoteAccess, models.ManageAccessNoteAccess},
+	getHistoryMethodName:     {models.ReadNoteAccess, models.WriteNoteAccess, models.ModifyNoteAccess, models.ManageAccessNoteAccess},
+	revertMethodName:         {models.WriteNoteAccess, models.ModifyNoteAccess, models.ManageAccessNoteAccess},
+
+	manageShareLinksMethodName: {models.ManageAccessNoteAccess},
+	getAuditLogMethodName:      {models.ManageAccessNoteAccess},
+
+	// collaborators' emails are exposed only to those who manage access, not to share link holders
+	listAccessMethodName: {models.ManageAccessNoteAccess},
+	// checked against note's owner in usecase as well
+	transferOwnershipMethodName: {models.ManageAccessNoteAccess},
 }
 
 func getAllowedMethods(access models.NoteAccess) []string {
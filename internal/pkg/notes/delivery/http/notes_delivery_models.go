@@ -3,6 +3,7 @@ package http
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	valid "github.com/asaskevich/govalidator"
 	"github.com/gofrs/uuid/v5"
@@ -51,8 +52,84 @@ func (unr *UpdateNoteRequest) ToNote() models.Note {
 	}
 }
 
+const (
+	defaultListLimit = 100
+	maxListLimit     = 500
+)
+
+type ListNotesRequest struct {
+	Sort       string `form:"sort"`
+	Order      string `form:"order"`
+	Limit      int    `form:"limit"`
+	Cursor     string `form:"cursor"`
+	DirID      *int   `form:"dir_id"`
+	CreatorID  string `form:"creator_id"`
+	Access     string `form:"access"`
+	HasSummary *bool  `form:"has_summary"`
+}
+
+func (lnr *ListNotesRequest) ToOptions() (models.NoteListOptions, error) {
+	var opts models.NoteListOptions
+
+	sortField, err := models.NoteSortFieldFromString(lnr.Sort)
+	if err != nil {
+		return opts, err
+	}
+	opts.SortField = sortField
+
+	switch lnr.Order {
+	case "":
+		opts.SortDesc = sortField != models.TitleNoteSortField
+	case "asc":
+		opts.SortDesc = false
+	case "desc":
+		opts.SortDesc = true
+	default:
+		return opts, errors.New(fmt.Sprintf("Invalid order: %s", lnr.Order))
+	}
+
+	opts.Limit = lnr.Limit
+	if opts.Limit == 0 {
+		opts.Limit = defaultListLimit
+	}
+	if opts.Limit < 0 || opts.Limit > maxListLimit {
+		return opts, errors.New(fmt.Sprintf("Invalid limit: %d", lnr.Limit))
+	}
+
+	if lnr.Cursor != "" {
+		cursor, err := models.DecodeNoteListCursor(lnr.Cursor)
+		if err != nil {
+			return opts, err
+		}
+		if cursor.SortField != sortField {
+			return opts, errors.New("Cursor doesn't match sort field")
+		}
+		opts.Cursor = cursor
+	}
+
+	if lnr.CreatorID != "" {
+		creatorID, err := uuid.FromString(lnr.CreatorID)
+		if err != nil {
+			return opts, err
+		}
+		opts.CreatorID = &creatorID
+	}
+
+	ownership, err := models.NoteOwnershipFromString(lnr.Access)
+	if err != nil {
+		return opts, err
+	}
+	opts.Ownership = ownership
+
+	opts.DirID = lnr.DirID
+	opts.HasSummary = lnr.HasSummary
+
+	return opts, nil
+}
+
 type ListNotesResponse struct {
-	Notes []*models.NoteTransfer `json:"notes"`
+	Notes      []*models.NoteTransfer `json:"notes"`
+	NextCursor *string                `json:"next_cursor"`
 }
 
 type SetAccessRequest struct {
@@ -65,6 +142,29 @@ func (sar *SetAccessRequest) validate() error {
 	return err
 }
 
+type SetGroupAccessRequest struct {
+	Access string `json:"access" valid:"required"`
+}
+
+func (sgar *SetGroupAccessRequest) validate() error {
+	if models.NoteAccessFromString(sgar.Access) == models.UndefinedNoteAccess {
+		return errors.New(fmt.Sprintf("Invalid access: %s", sgar.Access))
+	}
+
+	_, err := valid.ValidateStruct(sgar)
+	return err
+}
+
+type ListCollaboratorsResponse struct {
+	OwnerID string                                  `json:"owner_id"`
+	Users   []*models.NoteCollaboratorTransfer      `json:"users"`
+	Groups  []*models.NoteGroupCollaboratorTransfer `json:"groups"`
+}
+
+type AuditLogResponse struct {
+	Events []*models.AuditEventTransfer `json:"events"`
+}
+
 type ListSummaryResponse struct {
 	NonActiveSummaryIds []string `json:"non_active_summary_ids"`
 	ActiveSummaryIds    []string `json:"active_summary_ids"`
@@ -73,3 +173,35 @@ type ListSummaryResponse struct {
 type IsOwnerResponse struct {
 	IsOwner bool `json:"is_owner"`
 }
+
+type NoteHistoryResponse struct {
+	Revisions []*models.NoteRevisionTransfer `json:"revisions"`
+}
+
+type CreateShareLinkRequest struct {
+	Access    string     `json:"access" valid:"required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	Password  string     `json:"password"`
+}
+
+func (cslr *CreateShareLinkRequest) validate() error {
+	access := models.NoteAccessFromString(cslr.Access)
+	if access != models.ReadNoteAccess && access != models.WriteNoteAccess {
+		return errors.New(fmt.Sprintf("Invalid share link access: %s", cslr.Access))
+	}
+
+	if cslr.ExpiresAt != nil && cslr.ExpiresAt.Before(time.Now()) {
+		return errors.New("Share link expiration is in the past")
+	}
+
+	if len(cslr.Password) > 72 {
+		return errors.New("Too long password")
+	}
+
+	_, err := valid.ValidateStruct(cslr)
+	return err
+}
+
+type ListShareLinksResponse struct {
+	ShareLinks []*models.NoteShareLinkTransfer `json:"share_links"`
+}
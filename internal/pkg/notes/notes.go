@@ -1,36 +1,87 @@
 package notes
 
 import (
+	"errors"
+	"time"
+
 	"github.com/gofrs/uuid/v5"
 	"github.com/yarikTri/archipelago-notes-api/internal/models"
 )
 
+// ErrNotOwner is returned on attempt to transfer ownership of note by user which isn't its owner
+var ErrNotOwner = errors.New("user is not owner of note")
+
+// ErrSummaryAccessForbidden is returned on attempt to attach summary, which user can't read, to note
+var ErrSummaryAccessForbidden = errors.New("access to summary forbidden")
+
+// ErrDirForbidden is returned when note is created in, moved or reverted to dir user can't create notes in
+var ErrDirForbidden = errors.New("access to dir forbidden")
+
+// ErrDirNotFound is returned when note is created in or moved to dir, which doesn't exist or is in trash
+var ErrDirNotFound = errors.New("dir not found")
+
 type Usecase interface {
 	GetByID(noteID uuid.UUID) (*models.Note, error)
-	List(userID uuid.UUID) ([]*models.Note, error)
+	List(userID uuid.UUID, opts models.NoteListOptions) ([]*models.Note, *models.NoteListCursor, error)
 	Create(dirID int, automergeURL, title string, creatorID uuid.UUID) (*models.Note, error)
-	Update(note models.Note) (*models.Note, error)
-	DeleteByID(noteID uuid.UUID) error
+	// CreateFromSummary creates note with summary attached, note isn't created if summary can't be attached
+	CreateFromSummary(summID uuid.UUID, dirID int, automergeURL, title string, creatorID uuid.UUID) (*models.Note, error)
+	Update(note models.Note, changedBy uuid.UUID) (*models.Note, error)
+	DeleteByID(noteID uuid.UUID, deletedBy uuid.UUID) error
+
+	GetHistory(noteID uuid.UUID) ([]*models.NoteRevision, error)
+	Revert(noteID uuid.UUID, revision int, changedBy uuid.UUID) (*models.Note, error)
 
 	GetUserAccess(noteID uuid.UUID, userID uuid.UUID) (models.NoteAccess, error)
-	SetUserAccess(noteID uuid.UUID, userID uuid.UUID, access models.NoteAccess, sendInvitation bool) error
+	SetUserAccess(noteID uuid.UUID, userID uuid.UUID, access models.NoteAccess, sendInvitation bool, grantedBy uuid.UUID) error
+	SetGroupAccess(noteID uuid.UUID, groupID uuid.UUID, access models.NoteAccess, grantedBy uuid.UUID) error
+	RemoveGroupAccess(noteID uuid.UUID, groupID uuid.UUID, removedBy uuid.UUID) error
+	RemoveUserAccess(noteID uuid.UUID, userID uuid.UUID, removedBy uuid.UUID) error
+	ListCollaborators(noteID uuid.UUID) ([]*models.NoteCollaborator, []*models.NoteGroupCollaborator, error)
 	CheckOwner(noteID uuid.UUID, userID uuid.UUID) (bool, error)
+	TransferOwnership(noteID uuid.UUID, newOwnerID uuid.UUID, transferredBy uuid.UUID) error
 
-	AttachNoteToSummary(summID, noteID uuid.UUID) error
+	CreateShareLink(noteID uuid.UUID, createdBy uuid.UUID, access models.NoteAccess, expiresAt *time.Time, password string) (*models.NoteShareLink, string, error)
+	ListShareLinks(noteID uuid.UUID) ([]*models.NoteShareLink, error)
+	RevokeShareLink(noteID uuid.UUID, linkID uuid.UUID, revokedBy uuid.UUID) error
+	GetShareLinkAccess(noteID uuid.UUID, userID uuid.UUID, token, password string) (models.NoteAccess, error)
+
+	AttachNoteToSummary(summID, noteID uuid.UUID, userID uuid.UUID) error
 	DettachNoteFromSummary(summID, noteID uuid.UUID) error
 	GetSummaryListByNote(noteID uuid.UUID) ([]uuid.UUID, []uuid.UUID, error)
+
+	GetAuditLog(noteID uuid.UUID) ([]*models.AuditEvent, error)
 }
 
 type Repository interface {
 	GetByID(noteID uuid.UUID) (*models.Note, error)
-	List(userID uuid.UUID) ([]*models.Note, error)
+	List(userID uuid.UUID, opts models.NoteListOptions) ([]*models.Note, error)
 	Create(dirID int, automergeURL, title string, creatorID uuid.UUID) (*models.Note, error)
-	Update(note models.Note) (*models.Note, error)
-	DeleteByID(noteID uuid.UUID) error
+	CreateAttachedToSummary(summID uuid.UUID, dirID int, automergeURL, title string, creatorID uuid.UUID) (*models.Note, error)
+	Update(note models.Note, changedBy uuid.UUID) (*models.Note, error)
+	DeleteByID(noteID uuid.UUID, deletedBy uuid.UUID) error
 
+	ListRevisions(noteID uuid.UUID) ([]*models.NoteRevision, error)
+	GetRevision(noteID uuid.UUID, revision int) (*models.NoteRevision, error)
+
+	GetDirUserAccess(dirID int, userID uuid.UUID) (models.NoteAccess, error)
 	GetUserAccess(noteID uuid.UUID, userID uuid.UUID) (models.NoteAccess, error)
-	SetUserAccess(noteID uuid.UUID, userID uuid.UUID, access models.NoteAccess) error
+	GetUserGrant(noteID uuid.UUID, userID uuid.UUID) (models.NoteAccess, error)
+	GetGroupGrant(noteID uuid.UUID, groupID uuid.UUID) (models.NoteAccess, error)
+	SetUserAccess(noteID uuid.UUID, userID uuid.UUID, access models.NoteAccess, grantedBy uuid.UUID) error
+	SetGroupAccess(noteID uuid.UUID, groupID uuid.UUID, access models.NoteAccess, grantedBy uuid.UUID) error
+	RemoveUserAccess(noteID uuid.UUID, userID uuid.UUID) error
+	RemoveGroupAccess(noteID uuid.UUID, groupID uuid.UUID) error
+	ListUserGrants(noteID uuid.UUID) ([]*models.NoteCollaborator, error)
+	ListGroupGrants(noteID uuid.UUID) ([]*models.NoteGroupCollaborator, error)
+	TransferOwnership(noteID uuid.UUID, fromUserID uuid.UUID, toUserID uuid.UUID) error
+
+	CreateShareLink(link models.NoteShareLink, tokenHash string) (*models.NoteShareLink, error)
+	ListShareLinks(noteID uuid.UUID) ([]*models.NoteShareLink, error)
+	GetShareLinkByTokenHash(noteID uuid.UUID, tokenHash string) (*models.NoteShareLink, error)
+	RevokeShareLink(noteID uuid.UUID, linkID uuid.UUID) error
 
+	CanAttachSummary(summID uuid.UUID, userID uuid.UUID) (bool, error)
 	AttachNoteToSummary(summID, noteID uuid.UUID) error
 	DettachNoteFromSummary(summID, noteID uuid.UUID) error
 	GetSummaryListByNote(noteID uuid.UUID) ([]models.SummaryIDStatus, error)
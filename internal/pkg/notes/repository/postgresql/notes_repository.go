@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/gofrs/uuid/v5"
 	"github.com/jmoiron/sqlx"
@@ -11,6 +12,7 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/yarikTri/archipelago-notes-api/internal/common/repository"
 	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/notes"
 )
 
 // PostgreSQL implements notes.Repository
@@ -26,9 +28,9 @@ func NewPostgreSQL(db *sqlx.DB) *PostgreSQL {
 
 func (p *PostgreSQL) GetByID(noteID uuid.UUID) (*models.Note, error) {
 	query := fmt.Sprint(
-		`SELECT id, dir_id, automerge_url, title, creator_id, default_access
+		`SELECT id, dir_id, automerge_url, title, creator_id, default_access, created_at, updated_at
 			FROM note
-			WHERE id = $1`,
+			WHERE id = $1 AND deleted_at IS NULL`,
 	)
 
 	var note models.Note
@@ -43,33 +45,100 @@ func (p *PostgreSQL) GetByID(noteID uuid.UUID) (*models.Note, error) {
 	return &note, nil
 }
 
-func (p *PostgreSQL) List(userID uuid.UUID) ([]*models.Note, error) {
-	query := fmt.Sprint(
-		`SELECT
-				id as id,
-				dir_id as dir_id,
-				automerge_url as automerge_url,
-				title as title,
-				creator_id as creator_id,
-				default_access as default_access,
-				'ma' as access
-			FROM note
-			WHERE creator_id = $1
-			UNION ALL
-			SELECT
-			    n.id as id,
-			    n.dir_id as dir_id,
-			    n.automerge_url as automerge_url,
-			    n.title as title,
-			    n.creator_id as creator_id,
-			    n.default_access as default_access,
-			    na.access as access
-			FROM note n INNER JOIN note_access na ON n.id = na.note_id
-			WHERE na.user_id = $1 AND na.access <> 'e'`,
+var noteSortColumns = map[models.NoteSortField]string{
+	models.UpdatedNoteSortField: "updated_at",
+	models.CreatedNoteSortField: "created_at",
+	models.TitleNoteSortField:   "title",
+}
+
+// List returns page of notes user has access to, filtered and sorted by opts.
+// Keyset pagination by (sort column, id) is used
+func (p *PostgreSQL) List(userID uuid.UUID, opts models.NoteListOptions) ([]*models.Note, error) {
+	args := []any{userID.String()}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{"access <> 'e'"}
+
+	if opts.DirID != nil {
+		conditions = append(conditions, fmt.Sprintf(
+			"dir_id IN (SELECT id FROM dir WHERE path <@ (SELECT path FROM dir WHERE id = %s))", arg(*opts.DirID),
+		))
+	}
+	if opts.CreatorID != nil {
+		conditions = append(conditions, fmt.Sprintf("creator_id = %s", arg(opts.CreatorID.String())))
+	}
+	switch opts.Ownership {
+	case models.OwnedNoteOwnership:
+		conditions = append(conditions, "creator_id = $1")
+	case models.SharedNoteOwnership:
+		conditions = append(conditions, "creator_id <> $1")
+	}
+	if opts.HasSummary != nil {
+		hasSummaryCondition := "EXISTS (SELECT 1 FROM summ_to_note sn WHERE sn.note_id = id)"
+		if !*opts.HasSummary {
+			hasSummaryCondition = "NOT " + hasSummaryCondition
+		}
+		conditions = append(conditions, hasSummaryCondition)
+	}
+
+	sortColumn := noteSortColumns[opts.SortField]
+	order, cmp := "ASC", ">"
+	if opts.SortDesc {
+		order, cmp = "DESC", "<"
+	}
+
+	if opts.Cursor != nil {
+		var sortValue string
+		if opts.SortField == models.TitleNoteSortField {
+			sortValue = arg(opts.Cursor.Title)
+		} else {
+			sortValue = arg(opts.Cursor.Time) + "::timestamptz"
+		}
+		conditions = append(conditions, fmt.Sprintf(
+			"(%s, id) %s (%s, %s)", sortColumn, cmp, sortValue, arg(opts.Cursor.ID.String()),
+		))
+	}
+
+	query := fmt.Sprintf(
+		`SELECT id, dir_id, automerge_url, title, creator_id, default_access, access, created_at, updated_at
+			FROM (
+				SELECT
+					n.id as id,
+					n.dir_id as dir_id,
+					n.automerge_url as automerge_url,
+					n.title as title,
+					n.creator_id as creator_id,
+					n.default_access as default_access,
+					note_user_access(n.id, $1) as access,
+					n.created_at as created_at,
+					n.updated_at as updated_at
+				FROM note n
+				WHERE n.deleted_at IS NULL AND (n.creator_id = $1
+					OR n.id IN (SELECT note_id FROM note_access WHERE user_id = $1)
+					OR n.id IN (
+						SELECT na.note_id
+						FROM note_access na INNER JOIN user_group_member gm ON na.group_id = gm.group_id
+						WHERE gm.user_id = $1
+					)
+					OR n.dir_id IN (
+						SELECT d.id
+						FROM dir d
+							INNER JOIN dir sd ON d.path <@ sd.path
+							LEFT JOIN dir_access da ON sd.id = da.dir_id AND da.user_id = $1
+						WHERE sd.creator_id = $1 OR da.user_id IS NOT NULL
+					))
+			) accessible
+			WHERE %s
+			ORDER BY %s %s, id %s
+			LIMIT %s`,
+		strings.Join(conditions, " AND "), sortColumn, order, order, arg(opts.Limit),
 	)
 
 	var notes []*models.Note
-	if err := p.db.Select(&notes, query, userID.String()); err != nil {
+	if err := p.db.Select(&notes, query, args...); err != nil {
 		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
 	}
 
@@ -78,9 +147,9 @@ func (p *PostgreSQL) List(userID uuid.UUID) ([]*models.Note, error) {
 
 func (p *PostgreSQL) ListByDirIds(dirIDs []int) ([]*models.Note, error) {
 	query := fmt.Sprint(
-		`SELECT id, dir_id, automerge_url, title, creator_id, default_access
+		`SELECT id, dir_id, automerge_url, title, creator_id, default_access, created_at, updated_at
 			FROM note
-			WHERE dir_id = ANY($1)`,
+			WHERE dir_id = ANY($1) AND deleted_at IS NULL`,
 	)
 
 	var notes []*models.Note
@@ -91,55 +160,228 @@ func (p *PostgreSQL) ListByDirIds(dirIDs []int) ([]*models.Note, error) {
 	return notes, nil
 }
 
+// saveRevision saves current state of note as its next revision.
+// Note is locked first, so concurrent changes can't take the same revision number
+func saveRevision(tx *sqlx.Tx, noteID uuid.UUID, changedBy uuid.UUID) error {
+	lockQuery := fmt.Sprint(
+		`SELECT id
+			FROM note
+			WHERE id = $1
+			FOR UPDATE`,
+	)
+
+	if _, err := tx.Exec(lockQuery, noteID.String()); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	query := fmt.Sprint(
+		`INSERT INTO note_revision (note_id, revision, title, dir_id, automerge_url, default_access, changed_by)
+		SELECT
+			n.id,
+			COALESCE((SELECT MAX(revision) FROM note_revision WHERE note_id = n.id), 0) + 1,
+			n.title,
+			n.dir_id,
+			n.automerge_url,
+			n.default_access,
+			$2
+		FROM note n
+		WHERE n.id = $1`,
+	)
+
+	if _, err := tx.Exec(query, noteID.String(), nullableUUID(changedBy)); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+// nullableUUID converts uuid.Nil of anonymous user to NULL
+func nullableUUID(id uuid.UUID) *string {
+	if id == uuid.Nil {
+		return nil
+	}
+
+	idStr := id.String()
+	return &idStr
+}
+
+// lockAliveDir locks dir, so it can't be moved to trash until note is saved in it,
+// returns notes.ErrDirNotFound if dir doesn't exist or is already in trash
+func lockAliveDir(tx *sqlx.Tx, dirID int) error {
+	query := fmt.Sprint(
+		`SELECT id
+			FROM dir
+			WHERE id = $1 AND deleted_at IS NULL
+			FOR SHARE`,
+	)
+
+	var id int
+	if err := tx.Get(&id, query, dirID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notes.ErrDirNotFound
+		}
+
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
 func (p *PostgreSQL) Create(dirID int, automergeUrl, title string, creatorID uuid.UUID) (*models.Note, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	note, err := insertNote(tx, dirID, automergeUrl, title, creatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return note, nil
+}
+
+// CreateAttachedToSummary creates note and attaches summary to it in one transaction,
+// so note isn't left without summary if attaching fails
+func (p *PostgreSQL) CreateAttachedToSummary(summID uuid.UUID, dirID int, automergeUrl, title string, creatorID uuid.UUID) (*models.Note, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	note, err := insertNote(tx, dirID, automergeUrl, title, creatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := attachNoteToSummary(tx, summID, note.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return note, nil
+}
+
+// insertNote creates note with its first revision in alive dir
+func insertNote(tx *sqlx.Tx, dirID int, automergeUrl, title string, creatorID uuid.UUID) (*models.Note, error) {
+	if err := lockAliveDir(tx, dirID); err != nil {
+		return nil, err
+	}
+
 	query := fmt.Sprint(
 		`INSERT INTO note (dir_id, automerge_url, title, creator_id, default_access)
 			VALUES ($1, $2, $3, $4, $5)
-			RETURNING id`,
+			RETURNING id, dir_id, automerge_url, title, creator_id, default_access, created_at, updated_at`,
 	)
 
 	defaultDefaultAccess := models.EmptyNoteAccess
 
-	var noteID string
-	row := p.db.QueryRow(query, dirID, automergeUrl, title, creatorID.String(), defaultDefaultAccess.String())
-	if err := row.Scan(&noteID); err != nil {
+	var note models.Note
+	if err := tx.Get(&note, query, dirID, automergeUrl, title, creatorID.String(), defaultDefaultAccess.String()); err != nil {
 		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
 	}
 
-	_uuid, _ := uuid.FromString(noteID)
+	if err := saveRevision(tx, note.ID, creatorID); err != nil {
+		return nil, err
+	}
 
-	return &models.Note{
-		ID:            _uuid,
-		DirID:         dirID,
-		AutomergeURL:  automergeUrl,
-		Title:         title,
-		CreatorID:     creatorID,
-		DefaultAccess: defaultDefaultAccess.String(),
-	}, nil
+	return &note, nil
 }
 
-func (p *PostgreSQL) Update(note models.Note) (*models.Note, error) {
+func (p *PostgreSQL) Update(note models.Note, changedBy uuid.UUID) (*models.Note, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockAliveDir(tx, note.DirID); err != nil {
+		return nil, err
+	}
+
 	query := fmt.Sprint(
 		`UPDATE note
-			SET dir_id = $1, automerge_url = $2, title = $3, default_access = $4
-			WHERE id = $5`,
+			SET dir_id = $1, automerge_url = $2, title = $3, default_access = $4, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $5 AND deleted_at IS NULL`,
 	)
 
-	if _, err := p.db.Exec(query, note.DirID, note.AutomergeURL, note.Title, note.DefaultAccess, note.ID.String()); err != nil {
+	resExec, err := tx.Exec(query, note.DirID, note.AutomergeURL, note.Title, note.DefaultAccess, note.ID.String())
+	if err != nil {
 		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
 	}
+	updated, err := resExec.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("(repo) failed to check RowsAffected: %w", err)
+	}
+
+	if updated == 0 {
+		return nil, fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: note.ID})
+	}
+
+	if err := saveRevision(tx, note.ID, changedBy); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
 
 	return p.GetByID(note.ID)
 }
 
-func (p *PostgreSQL) DeleteByID(noteID uuid.UUID) error {
+func (p *PostgreSQL) ListRevisions(noteID uuid.UUID) ([]*models.NoteRevision, error) {
+	query := fmt.Sprint(
+		`SELECT note_id, revision, title, dir_id, automerge_url, default_access, changed_by, changed_at
+			FROM note_revision
+			WHERE note_id = $1
+			ORDER BY revision DESC`,
+	)
+
+	var revisions []*models.NoteRevision
+	if err := p.db.Select(&revisions, query, noteID.String()); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (p *PostgreSQL) GetRevision(noteID uuid.UUID, revision int) (*models.NoteRevision, error) {
 	query := fmt.Sprint(
-		`DELETE
-		FROM note
-		WHERE id = $1`,
+		`SELECT note_id, revision, title, dir_id, automerge_url, default_access, changed_by, changed_at
+			FROM note_revision
+			WHERE note_id = $1 AND revision = $2`,
 	)
 
-	resExec, err := p.db.Exec(query, noteID.String())
+	var noteRevision models.NoteRevision
+	if err := p.db.Get(&noteRevision, query, noteID.String(), revision); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: revision}, err)
+		}
+
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return &noteRevision, nil
+}
+
+// DeleteByID moves note to trash
+func (p *PostgreSQL) DeleteByID(noteID uuid.UUID, deletedBy uuid.UUID) error {
+	query := fmt.Sprint(
+		`UPDATE note
+		SET deleted_at = CURRENT_TIMESTAMP, deleted_by = $2
+		WHERE id = $1 AND deleted_at IS NULL`,
+	)
+
+	resExec, err := p.db.Exec(query, noteID.String(), nullableUUID(deletedBy))
 	if err != nil {
 		return fmt.Errorf("(repo) failed to exec query: %w", err)
 	}
@@ -155,63 +397,255 @@ func (p *PostgreSQL) DeleteByID(noteID uuid.UUID) error {
 	return nil
 }
 
-type noteAccessInfo struct {
-	CreatorID     string  `db:"creator_id"`
-	DefaultAccess string  `db:"default_access"`
-	Access        *string `db:"access"`
+// GetDirUserAccess resolves effective access to dir alive or in trash, see dir_user_access
+func (p *PostgreSQL) GetDirUserAccess(dirID int, userID uuid.UUID) (models.NoteAccess, error) {
+	query := fmt.Sprint(
+		`SELECT dir_user_access($1, $2)`,
+	)
+
+	var access *string
+	if err := p.db.Get(&access, query, dirID, userID.String()); err != nil {
+		return models.UndefinedNoteAccess, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+	if access == nil {
+		return models.UndefinedNoteAccess, fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: dirID})
+	}
+
+	return models.NoteAccessFromString(*access), nil
 }
 
+// GetUserAccess resolves effective access walking up the dirs path, see note_user_access
 func (p *PostgreSQL) GetUserAccess(noteID uuid.UUID, userID uuid.UUID) (models.NoteAccess, error) {
 	query := fmt.Sprint(
-		`SELECT
-			n.creator_id AS creator_id,
-			n.default_access AS default_access,
-			a.access AS access
-		FROM note n LEFT JOIN (
-			SELECT note_id, access
-			FROM note_access
-			WHERE note_id = $1 AND user_id = $2
-		) a ON n.id = a.note_id
-		WHERE n.id = $1`,
+		`SELECT note_user_access(id, $2)
+			FROM note
+			WHERE id = $1 AND deleted_at IS NULL`,
 	)
 
-	var access noteAccessInfo
+	var access string
 	if err := p.db.Get(&access, query, noteID.String(), userID.String()); err != nil {
 		return models.UndefinedNoteAccess, fmt.Errorf("(repo) failed to exec query: %w", err)
 	}
 
-	if access.CreatorID == userID.String() {
-		return models.ManageAccessNoteAccess, nil
+	return models.NoteAccessFromString(access), nil
+}
+
+// GetUserGrant returns access given directly to user on note, UndefinedNoteAccess if there is no such
+func (p *PostgreSQL) GetUserGrant(noteID uuid.UUID, userID uuid.UUID) (models.NoteAccess, error) {
+	return p.getGrant(`SELECT access FROM note_access WHERE note_id = $1 AND user_id = $2`, noteID, userID)
+}
+
+// GetGroupGrant returns access given to group on note, UndefinedNoteAccess if there is no such
+func (p *PostgreSQL) GetGroupGrant(noteID uuid.UUID, groupID uuid.UUID) (models.NoteAccess, error) {
+	return p.getGrant(`SELECT access FROM note_access WHERE note_id = $1 AND group_id = $2`, noteID, groupID)
+}
+
+func (p *PostgreSQL) getGrant(query string, noteID uuid.UUID, principalID uuid.UUID) (models.NoteAccess, error) {
+	var access string
+	if err := p.db.Get(&access, query, noteID.String(), principalID.String()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.UndefinedNoteAccess, nil
+		}
+
+		return models.UndefinedNoteAccess, fmt.Errorf("(repo) failed to exec query: %w", err)
 	}
 
-	if access.Access != nil {
-		return models.NoteAccessFromString(*access.Access), nil
+	return models.NoteAccessFromString(access), nil
+}
+
+func (p *PostgreSQL) SetUserAccess(noteID uuid.UUID, userID uuid.UUID, access models.NoteAccess, grantedBy uuid.UUID) error {
+	query := fmt.Sprint(
+		`INSERT INTO note_access
+		(note_id, user_id, access, granted_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (note_id, user_id) DO UPDATE
+			SET access = EXCLUDED.access, granted_by = EXCLUDED.granted_by, granted_at = CURRENT_TIMESTAMP;`,
+	)
+
+	if _, err := p.db.Exec(query, noteID.String(), userID.String(), access.String(), nullableUUID(grantedBy)); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
 	}
 
-	return models.NoteAccessFromString(access.DefaultAccess), nil
+	return nil
 }
 
-func (p *PostgreSQL) SetUserAccess(noteID uuid.UUID, userID uuid.UUID, access models.NoteAccess) error {
+func (p *PostgreSQL) SetGroupAccess(noteID uuid.UUID, groupID uuid.UUID, access models.NoteAccess, grantedBy uuid.UUID) error {
 	query := fmt.Sprint(
 		`INSERT INTO note_access
-		(note_id, user_id, access)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (note_id, user_id) DO UPDATE SET access = EXCLUDED.access;`,
+		(note_id, group_id, access, granted_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (note_id, group_id) DO UPDATE
+			SET access = EXCLUDED.access, granted_by = EXCLUDED.granted_by, granted_at = CURRENT_TIMESTAMP;`,
+	)
+
+	if _, err := p.db.Exec(query, noteID.String(), groupID.String(), access.String(), nullableUUID(grantedBy)); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == repository.ForeignKeyViolationCode {
+			return fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: groupID}, err)
+		}
+
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p *PostgreSQL) RemoveUserAccess(noteID uuid.UUID, userID uuid.UUID) error {
+	return p.removeGrant(`DELETE FROM note_access WHERE note_id = $1 AND user_id = $2`, noteID, userID)
+}
+
+func (p *PostgreSQL) RemoveGroupAccess(noteID uuid.UUID, groupID uuid.UUID) error {
+	return p.removeGrant(`DELETE FROM note_access WHERE note_id = $1 AND group_id = $2`, noteID, groupID)
+}
+
+func (p *PostgreSQL) removeGrant(query string, noteID uuid.UUID, principalID uuid.UUID) error {
+	resExec, err := p.db.Exec(query, noteID.String(), principalID.String())
+	if err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	deleted, err := resExec.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to check RowsAffected: %w", err)
+	}
+
+	if deleted == 0 {
+		return fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: principalID})
+	}
+
+	return nil
+}
+
+func (p *PostgreSQL) ListUserGrants(noteID uuid.UUID) ([]*models.NoteCollaborator, error) {
+	query := fmt.Sprint(
+		`SELECT u.id, u.email, u.email_confirmed, u.name, urd.root_dir_id as root_dir_id,
+				na.access, na.granted_by, na.granted_at
+			FROM note_access na
+				INNER JOIN "user" u ON na.user_id = u.id
+				LEFT JOIN user_root_dir urd ON u.id = urd.user_id
+			WHERE na.note_id = $1
+			ORDER BY na.granted_at, u.id`,
 	)
 
-	if _, err := p.db.Exec(query, noteID.String(), userID.String(), access.String()); err != nil {
+	var collaborators []*models.NoteCollaborator
+	if err := p.db.Select(&collaborators, query, noteID.String()); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return collaborators, nil
+}
+
+func (p *PostgreSQL) ListGroupGrants(noteID uuid.UUID) ([]*models.NoteGroupCollaborator, error) {
+	query := fmt.Sprint(
+		`SELECT g.id, g.name, g.creator_id, g.created_at, na.access, na.granted_by, na.granted_at
+			FROM note_access na
+				INNER JOIN user_group g ON na.group_id = g.id
+			WHERE na.note_id = $1
+			ORDER BY na.granted_at, g.id`,
+	)
+
+	var collaborators []*models.NoteGroupCollaborator
+	if err := p.db.Select(&collaborators, query, noteID.String()); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return collaborators, nil
+}
+
+// TransferOwnership makes toUserID creator of note if it's still owned by fromUserID.
+// Previous owner keeps manage access, direct access of the new owner becomes redundant and is dropped
+func (p *PostgreSQL) TransferOwnership(noteID uuid.UUID, fromUserID uuid.UUID, toUserID uuid.UUID) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	resExec, err := tx.Exec(
+		`UPDATE note
+			SET creator_id = $3, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND creator_id = $2 AND deleted_at IS NULL`,
+		noteID.String(), fromUserID.String(), toUserID.String(),
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == repository.ForeignKeyViolationCode {
+			return fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: toUserID}, err)
+		}
+
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+	updated, err := resExec.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to check RowsAffected: %w", err)
+	}
+
+	if updated == 0 {
+		return fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: noteID})
+	}
+
+	if _, err := tx.Exec(
+		`DELETE FROM note_access WHERE note_id = $1 AND user_id = $2`,
+		noteID.String(), toUserID.String(),
+	); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	manageAccess := models.ManageAccessNoteAccess
+	if _, err := tx.Exec(
+		`INSERT INTO note_access
+		(note_id, user_id, access, granted_by)
+		VALUES ($1, $2, $3, $2)
+		ON CONFLICT (note_id, user_id) DO UPDATE
+			SET access = EXCLUDED.access, granted_by = EXCLUDED.granted_by, granted_at = CURRENT_TIMESTAMP;`,
+		noteID.String(), fromUserID.String(), manageAccess.String(),
+	); err != nil {
 		return fmt.Errorf("(repo) failed to exec query: %w", err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
+// CanAttachSummary checks that user owns summary or has read access to it,
+// summary without owner can't be attached
+func (p *PostgreSQL) CanAttachSummary(summID uuid.UUID, userID uuid.UUID) (bool, error) {
+	query := fmt.Sprint(
+		`SELECT access_rank(summ_user_access(id, $2)) >= access_rank('r')
+			FROM summ
+			WHERE id = $1`,
+	)
+
+	var canAttach bool
+	if err := p.db.Get(&canAttach, query, summID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: summID}, err)
+		}
+
+		return false, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return canAttach, nil
+}
+
 func (p *PostgreSQL) AttachNoteToSummary(summID, noteID uuid.UUID) error {
+	return attachNoteToSummary(p.db, summID, noteID)
+}
+
+func attachNoteToSummary(db sqlx.Execer, summID, noteID uuid.UUID) error {
 	query := fmt.Sprint(
 		`INSERT INTO summ_to_note (summ_id, note_id)
 		VALUES ($1, $2);`,
 	)
-	if _, err := p.db.Exec(query, summID, noteID); err != nil {
+	if _, err := db.Exec(query, summID, noteID); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == repository.ForeignKeyViolationCode {
+			return fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: summID}, err)
+		}
+
 		return fmt.Errorf("(repo) failed to exec query: %w", err)
 	}
 
@@ -231,7 +665,7 @@ func (p *PostgreSQL) DettachNoteFromSummary(summID, noteID uuid.UUID) error {
 
 func (p *PostgreSQL) GetSummaryListByNote(noteID uuid.UUID) ([]models.SummaryIDStatus, error) {
 	query := fmt.Sprint(
-		`SELECT summ.id, summ.active
+		`SELECT summ.id, summ.status
 		FROM summ_to_note
 			INNER JOIN summ ON summ_to_note.summ_id = summ.id
 		WHERE summ_to_note.note_id = $1;`,
@@ -249,3 +683,78 @@ func (p *PostgreSQL) GetSummaryListByNote(noteID uuid.UUID) ([]models.SummaryIDS
 
 	return notes, nil
 }
+
+func (p *PostgreSQL) CreateShareLink(link models.NoteShareLink, tokenHash string) (*models.NoteShareLink, error) {
+	query := fmt.Sprint(
+		`INSERT INTO note_share_link (note_id, token_hash, access, password_hash, expires_at, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, note_id, access, password_hash, expires_at, created_by, created_at, revoked_at`,
+	)
+
+	var createdLink models.NoteShareLink
+	if err := p.db.Get(&createdLink, query,
+		link.NoteID.String(), tokenHash, link.Access, link.PasswordHash, link.ExpiresAt, link.CreatedBy,
+	); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return &createdLink, nil
+}
+
+func (p *PostgreSQL) ListShareLinks(noteID uuid.UUID) ([]*models.NoteShareLink, error) {
+	query := fmt.Sprint(
+		`SELECT id, note_id, access, password_hash, expires_at, created_by, created_at, revoked_at
+			FROM note_share_link
+			WHERE note_id = $1
+			ORDER BY created_at DESC`,
+	)
+
+	var links []*models.NoteShareLink
+	if err := p.db.Select(&links, query, noteID.String()); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return links, nil
+}
+
+func (p *PostgreSQL) GetShareLinkByTokenHash(noteID uuid.UUID, tokenHash string) (*models.NoteShareLink, error) {
+	query := fmt.Sprint(
+		`SELECT id, note_id, access, password_hash, expires_at, created_by, created_at, revoked_at
+			FROM note_share_link
+			WHERE note_id = $1 AND token_hash = $2`,
+	)
+
+	var link models.NoteShareLink
+	if err := p.db.Get(&link, query, noteID.String(), tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: noteID}, err)
+		}
+
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return &link, nil
+}
+
+func (p *PostgreSQL) RevokeShareLink(noteID uuid.UUID, linkID uuid.UUID) error {
+	query := fmt.Sprint(
+		`UPDATE note_share_link
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND note_id = $2 AND revoked_at IS NULL`,
+	)
+
+	resExec, err := p.db.Exec(query, linkID.String(), noteID.String())
+	if err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+	revoked, err := resExec.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to check RowsAffected: %w", err)
+	}
+
+	if revoked == 0 {
+		return fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: linkID})
+	}
+
+	return nil
+}
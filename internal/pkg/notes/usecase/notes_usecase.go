@@ -1,28 +1,44 @@
 package usecase
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
+	"github.com/go-park-mail-ru/2023_1_Technokaif/pkg/logger"
 	"github.com/gofrs/uuid/v5"
 	"github.com/yarikTri/archipelago-notes-api/internal/clients/invitations/email"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/repository"
 	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/audit"
 	"github.com/yarikTri/archipelago-notes-api/internal/pkg/notes"
 	"github.com/yarikTri/archipelago-notes-api/internal/pkg/users"
+	"golang.org/x/crypto/bcrypt"
 )
 
+const shareLinkTokenLength = 32
+
 // Usecase implements notes.Usecase
 type Usecase struct {
 	noteRepo              notes.Repository
 	userRepo              users.Repository
+	auditRepo             audit.Repository
 	emailInvitationClient email.IEmailInvitationClient
+	logger                logger.Logger
 }
 
-func NewUsecase(nr notes.Repository, ur users.Repository, eic email.IEmailInvitationClient) *Usecase {
+func NewUsecase(nr notes.Repository, ur users.Repository, ar audit.Repository, eic email.IEmailInvitationClient,
+	l logger.Logger) *Usecase {
 	return &Usecase{
 		noteRepo:              nr,
 		userRepo:              ur,
+		auditRepo:             ar,
 		emailInvitationClient: eic,
+		logger:                l,
 	}
 }
 
@@ -30,38 +46,266 @@ func (u *Usecase) GetByID(noteID uuid.UUID) (*models.Note, error) {
 	return u.noteRepo.GetByID(noteID)
 }
 
-func (u *Usecase) List(userID uuid.UUID) ([]*models.Note, error) {
-	return u.noteRepo.List(userID)
+// List returns page of notes and cursor of the next page, which is nil for the last page
+func (u *Usecase) List(userID uuid.UUID, opts models.NoteListOptions) ([]*models.Note, *models.NoteListCursor, error) {
+	if opts.Cursor != nil && opts.Cursor.SortField != opts.SortField {
+		return nil, nil, errors.New("(usecase) cursor doesn't match sort field")
+	}
+
+	pageSize := opts.Limit
+	opts.Limit++
+
+	notes, err := u.noteRepo.List(userID, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(notes) <= pageSize {
+		return notes, nil, nil
+	}
+
+	notes = notes[:pageSize]
+	return notes, models.NewNoteListCursor(opts.SortField, notes[pageSize-1]), nil
 }
 
 func (u *Usecase) Create(dirID int, automergeURL, title string, creatorID uuid.UUID) (*models.Note, error) {
-	return u.noteRepo.Create(dirID, automergeURL, title, creatorID)
+	if err := u.checkDirAccess(dirID, creatorID); err != nil {
+		return nil, err
+	}
+
+	note, err := u.noteRepo.Create(dirID, automergeURL, title, creatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	event := models.NewAuditEvent(creatorID, models.NoteAuditTarget, note.ID.String(), models.CreatedAuditAction).
+		WithValues("", note.Title)
+	u.recordEvent(event)
+
+	return note, nil
+}
+
+func (u *Usecase) CreateFromSummary(summID uuid.UUID, dirID int, automergeURL, title string, creatorID uuid.UUID) (*models.Note, error) {
+	canAttach, err := u.noteRepo.CanAttachSummary(summID, creatorID)
+	if err != nil {
+		return nil, err
+	}
+	if !canAttach {
+		return nil, notes.ErrSummaryAccessForbidden
+	}
+	if err := u.checkDirAccess(dirID, creatorID); err != nil {
+		return nil, err
+	}
+
+	note, err := u.noteRepo.CreateAttachedToSummary(summID, dirID, automergeURL, title, creatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	event := models.NewAuditEvent(creatorID, models.NoteAuditTarget, note.ID.String(), models.CreatedAuditAction).
+		WithValues("", note.Title)
+	u.recordEvent(event)
+
+	return note, nil
+}
+
+// Update moves note to another dir only if user may create notes there,
+// otherwise note would inherit access of dir user manages
+func (u *Usecase) Update(note models.Note, changedBy uuid.UUID) (*models.Note, error) {
+	oldNote, err := u.noteRepo.GetByID(note.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if note.DirID != oldNote.DirID {
+		if err := u.checkDirAccess(note.DirID, changedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	updatedNote, err := u.noteRepo.Update(note, changedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	noteID := updatedNote.ID.String()
+	events := make([]*models.AuditEvent, 0)
+	if oldNote.Title != updatedNote.Title {
+		events = append(events, models.NewAuditEvent(changedBy, models.NoteAuditTarget, noteID, models.RenamedAuditAction).
+			WithValues(oldNote.Title, updatedNote.Title))
+	}
+	if oldNote.DirID != updatedNote.DirID {
+		events = append(events, models.NewAuditEvent(changedBy, models.NoteAuditTarget, noteID, models.MovedAuditAction).
+			WithValues(strconv.Itoa(oldNote.DirID), strconv.Itoa(updatedNote.DirID)))
+	}
+	if oldNote.DefaultAccess != updatedNote.DefaultAccess {
+		events = append(events, models.NewAuditEvent(changedBy, models.NoteAuditTarget, noteID, models.DefaultAccessChangedAuditAction).
+			WithValues(oldNote.DefaultAccess, updatedNote.DefaultAccess))
+	}
+
+	for _, event := range events {
+		u.recordEvent(event)
+	}
+
+	return updatedNote, nil
+}
+
+func (u *Usecase) DeleteByID(noteID uuid.UUID, deletedBy uuid.UUID) error {
+	if err := u.noteRepo.DeleteByID(noteID, deletedBy); err != nil {
+		return err
+	}
+
+	u.recordEvent(models.NewAuditEvent(deletedBy, models.NoteAuditTarget, noteID.String(), models.DeletedAuditAction))
+
+	return nil
+}
+
+func (u *Usecase) GetHistory(noteID uuid.UUID) ([]*models.NoteRevision, error) {
+	return u.noteRepo.ListRevisions(noteID)
 }
 
-func (u *Usecase) Update(note models.Note) (*models.Note, error) {
-	return u.noteRepo.Update(note)
+// Revert restores note's title, dir, automerge url and default access of revision as a new revision.
+// Note is moved back to revision's dir only if user may create notes there, see Update
+func (u *Usecase) Revert(noteID uuid.UUID, revision int, changedBy uuid.UUID) (*models.Note, error) {
+	noteRevision, err := u.noteRepo.GetRevision(noteID, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	note, err := u.noteRepo.GetByID(noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	note.Title = noteRevision.Title
+	note.DirID = noteRevision.DirID
+	note.AutomergeURL = noteRevision.AutomergeURL
+	note.DefaultAccess = noteRevision.DefaultAccess
+
+	return u.Update(*note, changedBy)
 }
 
-func (u *Usecase) DeleteByID(noteID uuid.UUID) error {
-	return u.noteRepo.DeleteByID(noteID)
+// checkDirAccess returns ErrDirForbidden if user may not create notes in dir
+func (u *Usecase) checkDirAccess(dirID int, userID uuid.UUID) error {
+	dirAccess, err := u.noteRepo.GetDirUserAccess(dirID, userID)
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return notes.ErrDirNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if dirAccess < models.ModifyNoteAccess {
+		return notes.ErrDirForbidden
+	}
+
+	return nil
 }
 
 func (u *Usecase) GetUserAccess(noteID uuid.UUID, userID uuid.UUID) (models.NoteAccess, error) {
 	return u.noteRepo.GetUserAccess(noteID, userID)
 }
 
-func (u *Usecase) SetUserAccess(noteID uuid.UUID, userID uuid.UUID, access models.NoteAccess, sendInvitation bool) error {
+func (u *Usecase) SetUserAccess(noteID uuid.UUID, userID uuid.UUID, access models.NoteAccess, sendInvitation bool, grantedBy uuid.UUID) error {
 	if access == models.UndefinedNoteAccess {
 		return errors.New(fmt.Sprintf("(usecase) Invalid access %s", access.String()))
 	}
 
+	oldAccess, err := u.noteRepo.GetUserGrant(noteID, userID)
+	if err != nil {
+		return err
+	}
+
 	if sendInvitation {
 		if err := u.sendEmailInvitation(noteID, userID); err != nil {
 			return err
 		}
 	}
 
-	return u.noteRepo.SetUserAccess(noteID, userID, access)
+	if err := u.noteRepo.SetUserAccess(noteID, userID, access, grantedBy); err != nil {
+		return err
+	}
+
+	event := models.NewAuditEvent(grantedBy, models.NoteAuditTarget, noteID.String(), models.AccessSetAuditAction).
+		WithPrincipal(models.UserAuditPrincipal, userID.String()).
+		WithValues(oldAccess.String(), access.String())
+	u.recordEvent(event)
+
+	return nil
+}
+
+// SetGroupAccess gives access to note to every member of group
+func (u *Usecase) SetGroupAccess(noteID uuid.UUID, groupID uuid.UUID, access models.NoteAccess, grantedBy uuid.UUID) error {
+	if access == models.UndefinedNoteAccess {
+		return errors.New(fmt.Sprintf("(usecase) Invalid access %s", access.String()))
+	}
+
+	oldAccess, err := u.noteRepo.GetGroupGrant(noteID, groupID)
+	if err != nil {
+		return err
+	}
+
+	if err := u.noteRepo.SetGroupAccess(noteID, groupID, access, grantedBy); err != nil {
+		return err
+	}
+
+	event := models.NewAuditEvent(grantedBy, models.NoteAuditTarget, noteID.String(), models.AccessSetAuditAction).
+		WithPrincipal(models.GroupAuditPrincipal, groupID.String()).
+		WithValues(oldAccess.String(), access.String())
+	u.recordEvent(event)
+
+	return nil
+}
+
+func (u *Usecase) RemoveGroupAccess(noteID uuid.UUID, groupID uuid.UUID, removedBy uuid.UUID) error {
+	oldAccess, err := u.noteRepo.GetGroupGrant(noteID, groupID)
+	if err != nil {
+		return err
+	}
+
+	if err := u.noteRepo.RemoveGroupAccess(noteID, groupID); err != nil {
+		return err
+	}
+
+	event := models.NewAuditEvent(removedBy, models.NoteAuditTarget, noteID.String(), models.AccessRemovedAuditAction).
+		WithPrincipal(models.GroupAuditPrincipal, groupID.String()).
+		WithValues(oldAccess.String(), "")
+	u.recordEvent(event)
+
+	return nil
+}
+
+func (u *Usecase) RemoveUserAccess(noteID uuid.UUID, userID uuid.UUID, removedBy uuid.UUID) error {
+	oldAccess, err := u.noteRepo.GetUserGrant(noteID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := u.noteRepo.RemoveUserAccess(noteID, userID); err != nil {
+		return err
+	}
+
+	event := models.NewAuditEvent(removedBy, models.NoteAuditTarget, noteID.String(), models.AccessRemovedAuditAction).
+		WithPrincipal(models.UserAuditPrincipal, userID.String()).
+		WithValues(oldAccess.String(), "")
+	u.recordEvent(event)
+
+	return nil
+}
+
+// ListCollaborators returns users and groups with accesses given directly on note
+func (u *Usecase) ListCollaborators(noteID uuid.UUID) ([]*models.NoteCollaborator, []*models.NoteGroupCollaborator, error) {
+	userGrants, err := u.noteRepo.ListUserGrants(noteID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groupGrants, err := u.noteRepo.ListGroupGrants(noteID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return userGrants, groupGrants, nil
 }
 
 func (u *Usecase) CheckOwner(noteID uuid.UUID, userID uuid.UUID) (bool, error) {
@@ -73,11 +317,160 @@ func (u *Usecase) CheckOwner(noteID uuid.UUID, userID uuid.UUID) (bool, error) {
 	return note.CreatorID == userID, nil
 }
 
+// TransferOwnership makes newOwnerID creator of note, only current owner is allowed to do it
+func (u *Usecase) TransferOwnership(noteID uuid.UUID, newOwnerID uuid.UUID, transferredBy uuid.UUID) error {
+	isOwner, err := u.CheckOwner(noteID, transferredBy)
+	if err != nil {
+		return err
+	}
+	if !isOwner {
+		return notes.ErrNotOwner
+	}
+
+	if newOwnerID == transferredBy {
+		return nil
+	}
+
+	if _, err := u.userRepo.GetByID(newOwnerID); err != nil {
+		return err
+	}
+
+	if err := u.noteRepo.TransferOwnership(noteID, transferredBy, newOwnerID); err != nil {
+		return err
+	}
+
+	event := models.NewAuditEvent(transferredBy, models.NoteAuditTarget, noteID.String(), models.OwnerTransferredAuditAction).
+		WithPrincipal(models.UserAuditPrincipal, newOwnerID.String()).
+		WithValues(transferredBy.String(), newOwnerID.String())
+	u.recordEvent(event)
+
+	return nil
+}
+
+func (u *Usecase) CreateShareLink(noteID uuid.UUID, createdBy uuid.UUID, access models.NoteAccess,
+	expiresAt *time.Time, password string) (*models.NoteShareLink, string, error) {
+
+	if access != models.ReadNoteAccess && access != models.WriteNoteAccess {
+		return nil, "", errors.New(fmt.Sprintf("(usecase) Invalid share link access %s", access.String()))
+	}
+
+	if expiresAt != nil && expiresAt.Before(time.Now()) {
+		return nil, "", errors.New("(usecase) Share link expiration is in the past")
+	}
+
+	token, err := generateShareLinkToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	link := models.NoteShareLink{
+		NoteID:    noteID,
+		Access:    access.String(),
+		ExpiresAt: expiresAt,
+		CreatedBy: &createdBy,
+	}
+
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, "", fmt.Errorf("(usecase) failed to hash share link password: %w", err)
+		}
+		passwordHash := string(hash)
+		link.PasswordHash = &passwordHash
+	}
+
+	createdLink, err := u.noteRepo.CreateShareLink(link, hashShareLinkToken(token))
+	if err != nil {
+		return nil, "", err
+	}
+
+	event := models.NewAuditEvent(createdBy, models.NoteAuditTarget, noteID.String(), models.ShareLinkCreatedAuditAction).
+		WithPrincipal(models.LinkAuditPrincipal, createdLink.ID.String()).
+		WithValues("", createdLink.Access)
+	u.recordEvent(event)
+
+	return createdLink, token, nil
+}
+
+func (u *Usecase) ListShareLinks(noteID uuid.UUID) ([]*models.NoteShareLink, error) {
+	return u.noteRepo.ListShareLinks(noteID)
+}
+
+func (u *Usecase) RevokeShareLink(noteID uuid.UUID, linkID uuid.UUID, revokedBy uuid.UUID) error {
+	if err := u.noteRepo.RevokeShareLink(noteID, linkID); err != nil {
+		return err
+	}
+
+	event := models.NewAuditEvent(revokedBy, models.NoteAuditTarget, noteID.String(), models.ShareLinkRevokedAuditAction).
+		WithPrincipal(models.LinkAuditPrincipal, linkID.String())
+	u.recordEvent(event)
+
+	return nil
+}
+
+// GetShareLinkAccess returns access given by share link token to user, who is uuid.Nil if anonymous.
+// It's EmptyNoteAccess if link is unknown, revoked, expired, password doesn't match or user is black listed on note
+func (u *Usecase) GetShareLinkAccess(noteID uuid.UUID, userID uuid.UUID, token, password string) (models.NoteAccess, error) {
+	if userID != uuid.Nil {
+		grant, err := u.noteRepo.GetUserGrant(noteID, userID)
+		if err != nil {
+			return models.UndefinedNoteAccess, err
+		}
+		if grant == models.EmptyNoteAccess {
+			return models.EmptyNoteAccess, nil
+		}
+	}
+
+	link, err := u.noteRepo.GetShareLinkByTokenHash(noteID, hashShareLinkToken(token))
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return models.EmptyNoteAccess, nil
+	}
+	if err != nil {
+		return models.UndefinedNoteAccess, err
+	}
+
+	if !link.IsActive(time.Now()) {
+		return models.EmptyNoteAccess, nil
+	}
+
+	if link.PasswordHash != nil {
+		if err := bcrypt.CompareHashAndPassword([]byte(*link.PasswordHash), []byte(password)); err != nil {
+			return models.EmptyNoteAccess, nil
+		}
+	}
+
+	return models.NoteAccessFromString(link.Access), nil
+}
+
+func generateShareLinkToken() (string, error) {
+	b := make([]byte, shareLinkTokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("(usecase) failed to generate share link token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashShareLinkToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
 func (u *Usecase) DettachNoteFromSummary(summID, noteID uuid.UUID) error {
 	return u.noteRepo.DettachNoteFromSummary(summID, noteID)
 }
 
-func (u *Usecase) AttachNoteToSummary(summID, noteID uuid.UUID) error {
+// AttachNoteToSummary requires user to be able to read summary,
+// otherwise summary would be exposed to everyone with access to note
+func (u *Usecase) AttachNoteToSummary(summID, noteID uuid.UUID, userID uuid.UUID) error {
+	canAttach, err := u.noteRepo.CanAttachSummary(summID, userID)
+	if err != nil {
+		return err
+	}
+	if !canAttach {
+		return notes.ErrSummaryAccessForbidden
+	}
+
 	return u.noteRepo.AttachNoteToSummary(summID, noteID)
 }
 
@@ -91,7 +484,7 @@ func (u *Usecase) GetSummaryListByNote(noteID uuid.UUID) ([]uuid.UUID, []uuid.UU
 	activeNotesIDS := make([]uuid.UUID, 0, len(notes)/2)
 
 	for _, note := range notes {
-		if note.Active {
+		if note.Status.IsActive() {
 			activeNotesIDS = append(activeNotesIDS, note.ID)
 		} else {
 			nonActiveNotesIDS = append(nonActiveNotesIDS, note.ID)
@@ -101,6 +494,18 @@ func (u *Usecase) GetSummaryListByNote(noteID uuid.UUID) ([]uuid.UUID, []uuid.UU
 	return nonActiveNotesIDS, activeNotesIDS, nil
 }
 
+// GetAuditLog returns events of note from the newest to the oldest
+func (u *Usecase) GetAuditLog(noteID uuid.UUID) ([]*models.AuditEvent, error) {
+	return u.auditRepo.ListByTarget(models.NoteAuditTarget, noteID.String())
+}
+
+// recordEvent is called after change is applied, so failed recording is only logged
+func (u *Usecase) recordEvent(event *models.AuditEvent) {
+	if err := u.auditRepo.Create(event); err != nil {
+		u.logger.Errorf("failed to record audit event %s of %s %s: %v", event.Action, event.TargetType, event.TargetID, err)
+	}
+}
+
 func (u *Usecase) sendEmailInvitation(noteID uuid.UUID, userID uuid.UUID) error {
 	user, err := u.userRepo.GetByID(userID)
 	if err != nil {
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-park-mail-ru/2023_1_Technokaif/pkg/logger"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/http/auth"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/search"
+)
+
+type Handler struct {
+	searchUsecase search.Usecase
+	logger        logger.Logger
+}
+
+func NewHandler(su search.Usecase, l logger.Logger) *Handler {
+	return &Handler{
+		searchUsecase: su,
+		logger:        l,
+	}
+}
+
+// Search
+// @Summary		Search
+// @Tags		Search
+// @Description	Full-text search across titles of notes, names and texts of summaries user has access to
+// @Produce     json
+// @Param		q query string true 						"Query of search"
+// @Param		limit query int false 						"Page size"
+// @Param		offset query int false 						"Page offset"
+// @Success		200			{object}	SearchResponse		"Found notes and summaries"
+// @Failure		400			{object}	error				"Incorrect input"
+// @Failure		500			{object}	error				"Server error"
+// @Router		/api/search [get]
+func (h *Handler) Search(c *gin.Context) {
+	userID, err := auth.GetUserId(c)
+	if err != nil {
+		h.logger.Infof("Unathorized request for search")
+		c.JSON(http.StatusUnauthorized, "")
+		return
+	}
+
+	req, err := parseSearchRequest(c.Query("q"), c.Query("limit"), c.Query("offset"))
+	if err != nil {
+		h.logger.Infof("Invalid search request: %w", err)
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	results, total, err := h.searchUsecase.Search(userID, req.Query, req.Limit, req.Offset)
+	if err != nil {
+		h.logger.Errorf("Error while searching by query %s: %w", req.Query, err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	resultTransfers := make([]*models.SearchResultTransfer, 0, len(results))
+	for _, r := range results {
+		resultTransfers = append(resultTransfers, r.ToTransfer())
+	}
+
+	c.JSON(http.StatusOK, SearchResponse{
+		Results: resultTransfers,
+		Total:   total,
+		Limit:   req.Limit,
+		Offset:  req.Offset,
+	})
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+type SearchRequest struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+func parseSearchRequest(query, limit, offset string) (*SearchRequest, error) {
+	req := SearchRequest{
+		Query: strings.TrimSpace(query),
+		Limit: defaultLimit,
+	}
+
+	if req.Query == "" {
+		return nil, errors.New("Empty search query")
+	}
+
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l <= 0 || l > maxLimit {
+			return nil, errors.New("Invalid limit")
+		}
+		req.Limit = l
+	}
+
+	if offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil || o < 0 {
+			return nil, errors.New("Invalid offset")
+		}
+		req.Offset = o
+	}
+
+	return &req, nil
+}
+
+type SearchResponse struct {
+	Results []*models.SearchResultTransfer `json:"results"`
+	Total   int                            `json:"total"`
+	Limit   int                            `json:"limit"`
+	Offset  int                            `json:"offset"`
+}
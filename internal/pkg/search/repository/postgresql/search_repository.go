@@ -0,0 +1,104 @@
+package postgresql
+
+import (
+	"fmt"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
+
+const headlineOptions = "StartSel=<b>, StopSel=</b>, MaxWords=35, MinWords=15, MaxFragments=2"
+
+// PostgreSQL implements search.Repository
+type PostgreSQL struct {
+	db *sqlx.DB
+}
+
+func NewPostgreSQL(db *sqlx.DB) *PostgreSQL {
+	return &PostgreSQL{
+		db: db,
+	}
+}
+
+type searchResultRaw struct {
+	models.SearchResult
+	Total int `db:"total"`
+}
+
+// Search finds notes by title and by texts of attached summaries.
+// Candidates are notes user is related to, the same as notes.Repository.List ones, so search cost grows
+// with notes user can see, not with all notes. Hits are filtered by note_user_access then,
+// the same access resolution as notes.Repository.GetUserAccess
+func (p *PostgreSQL) Search(userID uuid.UUID, searchQuery string, limit, offset int) ([]*models.SearchResult, int, error) {
+	query := fmt.Sprintf(
+		`WITH q AS (
+				SELECT websearch_to_tsquery('russian', $2) || websearch_to_tsquery('english', $2) AS query
+			),
+			candidates AS (
+				SELECT n.id AS id, n.search_vector AS search_vector, n.title AS title
+				FROM note n
+				WHERE n.deleted_at IS NULL AND (n.creator_id = $1
+					OR n.id IN (SELECT note_id FROM note_access WHERE user_id = $1)
+					OR n.id IN (
+						SELECT na.note_id
+						FROM note_access na INNER JOIN user_group_member gm ON na.group_id = gm.group_id
+						WHERE gm.user_id = $1
+					)
+					OR n.dir_id IN (
+						SELECT d.id
+						FROM dir d
+							INNER JOIN dir sd ON d.path <@ sd.path
+							LEFT JOIN dir_access da ON sd.id = da.dir_id AND da.user_id = $1
+						WHERE sd.creator_id = $1 OR da.user_id IS NOT NULL
+					))
+			),
+			hits AS (
+				SELECT c.id AS note_id, NULL::UUID AS summ_id, c.title AS document, ts_rank(c.search_vector, q.query) AS rank
+				FROM candidates c, q
+				WHERE c.search_vector @@ q.query
+				UNION ALL
+				SELECT sn.note_id AS note_id, s.id AS summ_id, concat_ws(E'\n', s.name, s.text, s.text_with_role) AS document,
+					ts_rank(s.search_vector, q.query) AS rank
+				FROM candidates c
+					INNER JOIN summ_to_note sn ON c.id = sn.note_id
+					INNER JOIN summ s ON sn.summ_id = s.id, q
+				WHERE s.search_vector @@ q.query
+			),
+			page AS (
+				SELECT note_id, summ_id, document, rank, COUNT(*) OVER() AS total
+				FROM hits
+				WHERE note_user_access(note_id, $1) <> 'e'
+				ORDER BY rank DESC, note_id, summ_id
+				LIMIT $3 OFFSET $4
+			)
+			SELECT
+				p.note_id AS note_id,
+				n.title AS note_title,
+				p.summ_id AS summ_id,
+				s.name AS summ_name,
+				ts_headline('russian', p.document, q.query, '%s') AS headline,
+				p.rank AS rank,
+				p.total AS total
+			FROM page p
+				INNER JOIN note n ON p.note_id = n.id
+				LEFT JOIN summ s ON p.summ_id = s.id, q
+			ORDER BY p.rank DESC, p.note_id, p.summ_id`,
+		headlineOptions,
+	)
+
+	var rows []*searchResultRaw
+	if err := p.db.Select(&rows, query, userID.String(), searchQuery, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	total := 0
+	results := make([]*models.SearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = &row.SearchResult
+		total = row.Total
+	}
+
+	return results, total, nil
+}
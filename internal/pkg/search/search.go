@@ -0,0 +1,14 @@
+package search
+
+import (
+	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
+
+type Usecase interface {
+	Search(userID uuid.UUID, query string, limit, offset int) ([]*models.SearchResult, int, error)
+}
+
+type Repository interface {
+	Search(userID uuid.UUID, query string, limit, offset int) ([]*models.SearchResult, int, error)
+}
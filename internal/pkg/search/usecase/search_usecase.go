@@ -0,0 +1,22 @@
+package usecase
+
+import (
+	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/search"
+)
+
+// Usecase implements search.Usecase
+type Usecase struct {
+	repo search.Repository
+}
+
+func NewUsecase(sr search.Repository) *Usecase {
+	return &Usecase{
+		repo: sr,
+	}
+}
+
+func (u *Usecase) Search(userID uuid.UUID, query string, limit, offset int) ([]*models.SearchResult, int, error) {
+	return u.repo.Search(userID, query, limit, offset)
+}
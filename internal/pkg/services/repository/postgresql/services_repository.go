@@ -0,0 +1,187 @@
+package postgresql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/repository"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
+
+// PostgreSQL implements services.Repository
+type PostgreSQL struct {
+	db *sqlx.DB
+}
+
+func NewPostgreSQL(db *sqlx.DB) *PostgreSQL {
+	return &PostgreSQL{
+		db: db,
+	}
+}
+
+func (p *PostgreSQL) CreateClient(name string, scopes []string) (*models.ServiceClient, error) {
+	query := fmt.Sprint(
+		`INSERT INTO service_client (name, scopes)
+			VALUES ($1, $2)
+			RETURNING id, name, scopes, created_at, disabled_at`,
+	)
+
+	var client models.ServiceClient
+	if err := p.db.Get(&client, query, name, pq.StringArray(scopes)); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return &client, nil
+}
+
+func (p *PostgreSQL) GetClientByID(clientID uuid.UUID) (*models.ServiceClient, error) {
+	return p.getClient(`SELECT id, name, scopes, created_at, disabled_at FROM service_client WHERE id = $1`, clientID.String())
+}
+
+func (p *PostgreSQL) GetClientByName(name string) (*models.ServiceClient, error) {
+	return p.getClient(`SELECT id, name, scopes, created_at, disabled_at FROM service_client WHERE name = $1`, name)
+}
+
+func (p *PostgreSQL) getClient(query string, arg string) (*models.ServiceClient, error) {
+	var client models.ServiceClient
+	if err := p.db.Get(&client, query, arg); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: arg}, err)
+		}
+
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return &client, nil
+}
+
+func (p *PostgreSQL) ListClients() ([]*models.ServiceClient, error) {
+	query := fmt.Sprint(
+		`SELECT id, name, scopes, created_at, disabled_at
+			FROM service_client
+			ORDER BY name`,
+	)
+
+	var clients []*models.ServiceClient
+	if err := p.db.Select(&clients, query); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return clients, nil
+}
+
+func (p *PostgreSQL) SetClientScopes(clientID uuid.UUID, scopes []string) error {
+	query := fmt.Sprint(
+		`UPDATE service_client SET scopes = $2 WHERE id = $1`,
+	)
+
+	if _, err := p.db.Exec(query, clientID.String(), pq.StringArray(scopes)); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p *PostgreSQL) DisableClient(clientID uuid.UUID) error {
+	query := fmt.Sprint(
+		`UPDATE service_client SET disabled_at = CURRENT_TIMESTAMP WHERE id = $1 AND disabled_at IS NULL`,
+	)
+
+	if _, err := p.db.Exec(query, clientID.String()); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p *PostgreSQL) CreateKey(clientID uuid.UUID, keyHash string) (*models.ServiceKey, error) {
+	query := fmt.Sprint(
+		`INSERT INTO service_key (client_id, key_hash)
+			VALUES ($1, $2)
+			RETURNING id, client_id, key_hash, created_at, expires_at, revoked_at`,
+	)
+
+	var key models.ServiceKey
+	if err := p.db.Get(&key, query, clientID.String(), keyHash); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return &key, nil
+}
+
+func (p *PostgreSQL) GetKeyByID(keyID uuid.UUID) (*models.ServiceKey, error) {
+	query := fmt.Sprint(
+		`SELECT id, client_id, key_hash, created_at, expires_at, revoked_at
+			FROM service_key
+			WHERE id = $1`,
+	)
+
+	var key models.ServiceKey
+	if err := p.db.Get(&key, query, keyID.String()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: keyID}, err)
+		}
+
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return &key, nil
+}
+
+func (p *PostgreSQL) ListKeys(clientID uuid.UUID) ([]*models.ServiceKey, error) {
+	query := fmt.Sprint(
+		`SELECT id, client_id, key_hash, created_at, expires_at, revoked_at
+			FROM service_key
+			WHERE client_id = $1
+			ORDER BY created_at DESC`,
+	)
+
+	var keys []*models.ServiceKey
+	if err := p.db.Select(&keys, query, clientID.String()); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (p *PostgreSQL) ExpireKeys(clientID uuid.UUID, exceptKeyID uuid.UUID, expiresAt time.Time) error {
+	query := fmt.Sprint(
+		`UPDATE service_key
+			SET expires_at = $3
+			WHERE client_id = $1 AND id <> $2 AND revoked_at IS NULL
+				AND (expires_at IS NULL OR expires_at > $3)`,
+	)
+
+	if _, err := p.db.Exec(query, clientID.String(), exceptKeyID.String(), expiresAt); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p *PostgreSQL) RevokeKey(keyID uuid.UUID) error {
+	query := fmt.Sprint(
+		`UPDATE service_key SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL`,
+	)
+
+	resExec, err := p.db.Exec(query, keyID.String())
+	if err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	revoked, err := resExec.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to check RowsAffected: %w", err)
+	}
+
+	if revoked == 0 {
+		return fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: keyID})
+	}
+
+	return nil
+}
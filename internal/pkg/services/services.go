@@ -0,0 +1,42 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
+
+// ErrInvalidServiceKey is returned for malformed, unknown, revoked or expired keys and disabled clients
+var ErrInvalidServiceKey = errors.New("invalid service key")
+
+type Usecase interface {
+	// Authenticate returns client owning API key
+	Authenticate(key string) (*models.ServiceClient, error)
+
+	CreateClient(name string, scopes []string) (*models.ServiceClient, string, error)
+	ListClients() ([]*models.ServiceClient, error)
+	SetClientScopes(name string, scopes []string) error
+	DisableClient(name string) error
+
+	ListKeys(clientName string) ([]*models.ServiceKey, error)
+	RotateKey(clientName string, gracePeriod time.Duration) (string, error)
+	RevokeKey(keyID uuid.UUID) error
+}
+
+type Repository interface {
+	CreateClient(name string, scopes []string) (*models.ServiceClient, error)
+	GetClientByID(clientID uuid.UUID) (*models.ServiceClient, error)
+	GetClientByName(name string) (*models.ServiceClient, error)
+	ListClients() ([]*models.ServiceClient, error)
+	SetClientScopes(clientID uuid.UUID, scopes []string) error
+	DisableClient(clientID uuid.UUID) error
+
+	CreateKey(clientID uuid.UUID, keyHash string) (*models.ServiceKey, error)
+	GetKeyByID(keyID uuid.UUID) (*models.ServiceKey, error)
+	ListKeys(clientID uuid.UUID) ([]*models.ServiceKey, error)
+	// ExpireKeys sets expiration of client's active keys except exceptKeyID, if they expire later
+	ExpireKeys(clientID uuid.UUID, exceptKeyID uuid.UUID, expiresAt time.Time) error
+	RevokeKey(keyID uuid.UUID) error
+}
@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/repository"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/services"
+)
+
+const (
+	keySecretLength = 32
+	// API key is "<key id>.<secret>"
+	keySeparator = "."
+)
+
+// Usecase implements services.Usecase
+type Usecase struct {
+	repo services.Repository
+}
+
+func NewUsecase(sr services.Repository) *Usecase {
+	return &Usecase{
+		repo: sr,
+	}
+}
+
+func (u *Usecase) Authenticate(key string) (*models.ServiceClient, error) {
+	keyIDStr, secret, found := strings.Cut(key, keySeparator)
+	if !found {
+		return nil, services.ErrInvalidServiceKey
+	}
+
+	keyID, err := uuid.FromString(keyIDStr)
+	if err != nil {
+		return nil, services.ErrInvalidServiceKey
+	}
+
+	serviceKey, err := u.repo.GetKeyByID(keyID)
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return nil, services.ErrInvalidServiceKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !serviceKey.IsActive(time.Now()) {
+		return nil, services.ErrInvalidServiceKey
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashKeySecret(secret)), []byte(serviceKey.KeyHash)) != 1 {
+		return nil, services.ErrInvalidServiceKey
+	}
+
+	client, err := u.repo.GetClientByID(serviceKey.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if client.DisabledAt != nil {
+		return nil, services.ErrInvalidServiceKey
+	}
+
+	return client, nil
+}
+
+// CreateClient creates service client and issues its first API key
+func (u *Usecase) CreateClient(name string, scopes []string) (*models.ServiceClient, string, error) {
+	if err := validateScopes(scopes); err != nil {
+		return nil, "", err
+	}
+
+	client, err := u.repo.CreateClient(name, scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	_, key, err := u.createKey(client.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return client, key, nil
+}
+
+func (u *Usecase) ListClients() ([]*models.ServiceClient, error) {
+	return u.repo.ListClients()
+}
+
+func (u *Usecase) SetClientScopes(name string, scopes []string) error {
+	if err := validateScopes(scopes); err != nil {
+		return err
+	}
+
+	client, err := u.repo.GetClientByName(name)
+	if err != nil {
+		return err
+	}
+
+	return u.repo.SetClientScopes(client.ID, scopes)
+}
+
+func (u *Usecase) DisableClient(name string) error {
+	client, err := u.repo.GetClientByName(name)
+	if err != nil {
+		return err
+	}
+
+	return u.repo.DisableClient(client.ID)
+}
+
+func (u *Usecase) ListKeys(clientName string) ([]*models.ServiceKey, error) {
+	client, err := u.repo.GetClientByName(clientName)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.repo.ListKeys(client.ID)
+}
+
+// RotateKey issues new API key of client. Previous keys stay valid during gracePeriod,
+// so client can be redeployed with the new key without downtime
+func (u *Usecase) RotateKey(clientName string, gracePeriod time.Duration) (string, error) {
+	client, err := u.repo.GetClientByName(clientName)
+	if err != nil {
+		return "", err
+	}
+
+	keyID, key, err := u.createKey(client.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := u.repo.ExpireKeys(client.ID, keyID, time.Now().Add(gracePeriod)); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+func (u *Usecase) RevokeKey(keyID uuid.UUID) error {
+	return u.repo.RevokeKey(keyID)
+}
+
+func (u *Usecase) createKey(clientID uuid.UUID) (uuid.UUID, string, error) {
+	b := make([]byte, keySecretLength)
+	if _, err := rand.Read(b); err != nil {
+		return uuid.Nil, "", fmt.Errorf("(usecase) failed to generate service key: %w", err)
+	}
+	secret := hex.EncodeToString(b)
+
+	serviceKey, err := u.repo.CreateKey(clientID, hashKeySecret(secret))
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	return serviceKey.ID, serviceKey.ID.String() + keySeparator + secret, nil
+}
+
+func hashKeySecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+func validateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if !models.IsValidServiceScope(scope) {
+			return errors.New(fmt.Sprintf("(usecase) Invalid service scope %s", scope))
+		}
+	}
+
+	return nil
+}
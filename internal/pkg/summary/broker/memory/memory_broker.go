@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
+
+// subscriberBufferSize is enough for slow clients, events are full snapshots,
+// so skipping intermediate ones loses nothing
+const subscriberBufferSize = 16
+
+// Broker implements summary.Broker, fans out events inside one process
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan models.SummaryEvent]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: make(map[string]map[chan models.SummaryEvent]struct{}),
+	}
+}
+
+// Publish never blocks: if subscriber's buffer is full, its oldest event is dropped
+func (b *Broker) Publish(event models.SummaryEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[event.Summary.ID] {
+		select {
+		case ch <- event:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- event:
+			default:
+			}
+		}
+	}
+
+	return nil
+}
+
+func (b *Broker) Subscribe(ID uuid.UUID) (<-chan models.SummaryEvent, func()) {
+	ch := make(chan models.SummaryEvent, subscriberBufferSize)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := ID.String()
+	if _, ok := b.subscribers[key]; !ok {
+		b.subscribers[key] = make(map[chan models.SummaryEvent]struct{})
+	}
+	b.subscribers[key][ch] = struct{}{}
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			delete(b.subscribers[key], ch)
+			if len(b.subscribers[key]) == 0 {
+				delete(b.subscribers, key)
+			}
+		})
+	}
+
+	return ch, unsubscribe
+}
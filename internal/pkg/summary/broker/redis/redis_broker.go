@@ -0,0 +1,112 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-park-mail-ru/2023_1_Technokaif/pkg/logger"
+	"github.com/gofrs/uuid/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/summary/broker/memory"
+)
+
+const summaryEventsChannel = "summary_events"
+
+// Subscription to channel is retried with exponential backoff
+const (
+	minResubscribeDelay = time.Second
+	maxResubscribeDelay = 30 * time.Second
+)
+
+// Broker implements summary.Broker, events are published to redis channel
+// and delivered to subscribers of every API replica through local broker
+type Broker struct {
+	db     *redis.Client
+	local  *memory.Broker
+	logger logger.Logger
+}
+
+func NewBroker(db *redis.Client, l logger.Logger) *Broker {
+	return &Broker{
+		db:     db,
+		local:  memory.NewBroker(),
+		logger: l,
+	}
+}
+
+func (b *Broker) Publish(event models.SummaryEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("(broker) failed to marshal event: %w", err)
+	}
+
+	if err := b.db.Publish(context.TODO(), summaryEventsChannel, payload).Err(); err != nil {
+		return fmt.Errorf("(broker) failed to publish event: %w", err)
+	}
+
+	return nil
+}
+
+func (b *Broker) Subscribe(ID uuid.UUID) (<-chan models.SummaryEvent, func()) {
+	return b.local.Subscribe(ID)
+}
+
+// Run delivers events from redis channel to local subscribers until ctx is done,
+// failed subscription is retried, so replica doesn't stop streaming after redis outage
+func (b *Broker) Run(ctx context.Context) {
+	delay := minResubscribeDelay
+	for {
+		subscribed, err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if subscribed {
+			delay = minResubscribeDelay
+		}
+		b.logger.Errorf("summary broker subscription lost, retrying in %s: %v", delay, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > maxResubscribeDelay {
+			delay = maxResubscribeDelay
+		}
+	}
+}
+
+// listen delivers events until subscription is lost or ctx is done,
+// it reports whether subscription was made
+func (b *Broker) listen(ctx context.Context) (bool, error) {
+	pubsub := b.db.Subscribe(ctx, summaryEventsChannel)
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return false, fmt.Errorf("(broker) failed to subscribe: %w", err)
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return true, nil
+		case msg, ok := <-messages:
+			if !ok {
+				return true, fmt.Errorf("(broker) channel closed")
+			}
+
+			var event models.SummaryEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				b.logger.Errorf("summary broker failed to decode event: %v", err)
+				continue
+			}
+			b.local.Publish(event)
+		}
+	}
+}
@@ -1,36 +1,54 @@
 package http
 
 import (
+	"database/sql"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	valid "github.com/asaskevich/govalidator"
 	"github.com/gin-gonic/gin"
 	"github.com/go-park-mail-ru/2023_1_Technokaif/pkg/logger"
 	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/http/auth"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/repository"
 	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/notes"
 	"github.com/yarikTri/archipelago-notes-api/internal/pkg/summary"
 )
 
 type Handler struct {
 	sumUsecase summary.Usecase
-	logger     logger.Logger
+	// streamMaxDuration keeps stream shorter than server's write timeout,
+	// clients reconnect after it (EventSource does it automatically) and get fresh state
+	streamMaxDuration time.Duration
+	logger            logger.Logger
 }
 
-func NewHandler(nu summary.Usecase, l logger.Logger) *Handler {
+func NewHandler(nu summary.Usecase, streamMaxDuration time.Duration, l logger.Logger) *Handler {
 	return &Handler{
-		sumUsecase: nu,
-		logger:     l,
+		sumUsecase:        nu,
+		streamMaxDuration: streamMaxDuration,
+		logger:            l,
 	}
 }
 
-// TODO: add token for microservice communication
+// SaveSummaryText is called by service clients with summary:write scope
 func (h *Handler) SaveSummaryText(c *gin.Context) {
 	type SaveSummaryRequest struct {
 		ID           string `json:"id" valid:"required"`
 		Text         string `json:"text"`
-		Active       bool   `json:"active" valid:"required"`
+		Active       bool   `json:"active"`
 		Platform     string `json:"platform" valid:"required"`
 		Detalization string `json:"detalization" valid:"required"`
+		CreatorID    string `json:"creator_id" valid:"required"`
+		// Platform's metadata, not passed fields keep saved values
+		MeetingURL        *string  `json:"meeting_url"`
+		ExternalMeetingID *string  `json:"external_meeting_id"`
+		Participants      []string `json:"participants"`
+		DurationSeconds   *int     `json:"duration_seconds"`
 	}
 
 	var req SaveSummaryRequest
@@ -50,17 +68,44 @@ func (h *Handler) SaveSummaryText(c *gin.Context) {
 		return
 	}
 
-	summ, err := h.sumUsecase.SaveSummaryText(id, req.Text, req.Active, models.DetalizationFromString(req.Detalization), req.Platform)
+	// Summary without owner can't be attached to notes, so creator is required
+	creatorID, err := uuid.FromString(req.CreatorID)
+	if err != nil || creatorID == uuid.Nil {
+		h.logger.Errorf("Invalid creator id %s: %v", req.CreatorID, err)
+		c.JSON(http.StatusBadRequest, "Invalid creator id")
+		return
+	}
+
+	platform, ok := models.PlatformFromString(req.Platform)
+	if !ok {
+		h.logger.Errorf("Unknown platform %s", req.Platform)
+		c.JSON(http.StatusBadRequest, "Unknown platform")
+		return
+	}
+
+	meta := models.PlatformMeta{
+		MeetingURL:        req.MeetingURL,
+		ExternalMeetingID: req.ExternalMeetingID,
+		Participants:      req.Participants,
+		DurationSeconds:   req.DurationSeconds,
+	}
+
+	// Recorder reports inactive summary when recording is stopped, but final text isn't ready yet
+	status := models.RecordingSummaryStatus
+	if !req.Active {
+		status = models.FinishingSummaryStatus
+	}
+
+	summ, err := h.sumUsecase.SaveSummaryText(id, req.Text, status, models.DetalizationFromString(req.Detalization), platform, meta, creatorID)
 	if err != nil {
-		h.logger.Errorf("Error while saving summary: %w", err)
-		c.JSON(http.StatusInternalServerError, err)
+		h.respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, summ.ToTransfer())
 }
 
-// TODO: add token for microservice communication
+// UpdateSummaryTextRole is called by service clients with summary:write scope
 func (h *Handler) UpdateSummaryTextRole(c *gin.Context) {
 	type UpdateSummaryTextRoleRequest struct {
 		ID           string `json:"id" valid:"required"`
@@ -80,51 +125,336 @@ func (h *Handler) UpdateSummaryTextRole(c *gin.Context) {
 
 	err = h.sumUsecase.UpdateSummaryTextRole(id, req.TextWithRole, req.Role)
 	if err != nil {
-		h.logger.Errorf("Error while saving summary: %w", err)
-		c.JSON(http.StatusInternalServerError, err)
+		h.respondError(c, err)
 		return
 	}
 
 	c.Status(http.StatusOK)
 }
 
-// TODO: add token for microservice communication
+// AppendChunk is called by service clients with summary:write scope.
+// Repeated chunk responds 200 instead of 201, so it's safe to retry
+func (h *Handler) AppendChunk(c *gin.Context) {
+	type AppendChunkRequest struct {
+		Seq        int        `json:"seq" valid:"required"`
+		Text       string     `json:"text"`
+		RecordedAt *time.Time `json:"recorded_at"`
+	}
+
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Errorf("Failed to cast id to uuid %s: %w", id, err)
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	var req AppendChunkRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.logger.Error(err.Error())
+		return
+	}
+
+	if _, err := valid.ValidateStruct(req); err != nil || req.Seq < 1 {
+		h.logger.Errorf("Invalid chunk of summary %s: %v", id.String(), err)
+		c.JSON(http.StatusBadRequest, "seq must be positive")
+		return
+	}
+
+	recordedAt := time.Now()
+	if req.RecordedAt != nil {
+		recordedAt = *req.RecordedAt
+	}
+
+	chunk, created, err := h.sumUsecase.AppendChunk(models.SummaryChunk{
+		SummaryID:  id,
+		Seq:        req.Seq,
+		Text:       req.Text,
+		RecordedAt: recordedAt,
+	})
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, chunk.ToTransfer())
+}
+
+// FinishSummary is called by service clients with summary:write scope.
+// Optional "status" query param is done (default) or failed, "reason" describes failure
 func (h *Handler) FinishSummary(c *gin.Context) {
 	id, err := uuid.FromString(c.Param("id"))
 	if err != nil {
 		h.logger.Errorf("Failed to cast id to uuid %s: %w", id, err)
 		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	status := models.DoneSummaryStatus
+	if c.Query("status") != "" {
+		status = models.SummaryStatus(c.Query("status"))
 	}
 
-	err = h.sumUsecase.FinishSummary(id)
+	err = h.sumUsecase.FinishSummary(id, status, c.Query("reason"))
 	if err != nil {
-		h.logger.Errorf("Error while finishing summary: %w", err)
-		c.JSON(http.StatusInternalServerError, err)
+		h.respondError(c, err)
 		return
 	}
 
 	c.Status(http.StatusOK)
 }
 
-// TODO: check permissions by user
+// respondError maps usecase errors to response statuses
+func (h *Handler) respondError(c *gin.Context, err error) {
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) || errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, "Not found")
+		return
+	}
+	if errors.Is(err, summary.ErrForbidden) || errors.Is(err, notes.ErrSummaryAccessForbidden) ||
+		errors.Is(err, notes.ErrDirForbidden) {
+		c.JSON(http.StatusForbidden, "")
+		return
+	}
+	if errors.Is(err, notes.ErrDirNotFound) {
+		c.JSON(http.StatusBadRequest, "Dir not found")
+		return
+	}
+	if errors.Is(err, summary.ErrInvalidStatus) || errors.Is(err, summary.ErrInvalidTemplate) ||
+		errors.Is(err, summary.ErrInvalidPlatform) {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, summary.ErrChunkOutOfOrder) || errors.Is(err, summary.ErrChunkConflict) ||
+		errors.Is(err, summary.ErrNotFinished) || errors.Is(err, summary.ErrFinished) ||
+		errors.Is(err, summary.ErrChunkedText) {
+		c.JSON(http.StatusConflict, err.Error())
+		return
+	}
+
+	h.logger.Errorf("Error: %w", err)
+	c.JSON(http.StatusInternalServerError, err)
+}
+
+// GetSummary is allowed for summary's owner and users with read access to any note it's attached to
 func (h *Handler) GetSummary(c *gin.Context) {
 	id, err := uuid.FromString(c.Param("id"))
 	if err != nil {
 		h.logger.Errorf("Failed to cast id to uuid %s: %w", id, err)
 		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	userID, err := auth.GetUserId(c)
+	if err != nil || userID == uuid.Nil {
+		h.logger.Infof("Unathorized request for summary %s", id.String())
+		c.JSON(http.StatusUnauthorized, "")
+		return
 	}
 
-	summ, err := h.sumUsecase.GetSummary(id)
+	summ, err := h.sumUsecase.GetSummary(id, userID)
 	if err != nil {
-		h.logger.Errorf("Error while getting summary: %w", err)
-		c.JSON(http.StatusInternalServerError, err)
+		h.respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, summ.ToTransfer())
 }
 
-// TODO: add token for microservice communication
+// Stream sends summary's current state and then its updates as server-sent events,
+// stream ends with finish event
+func (h *Handler) Stream(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Errorf("Failed to cast id to uuid %s: %w", id, err)
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	userID, err := auth.GetUserId(c)
+	if err != nil || userID == uuid.Nil {
+		h.logger.Infof("Unathorized request for streaming summary %s", id.String())
+		c.JSON(http.StatusUnauthorized, "")
+		return
+	}
+
+	summ, events, unsubscribe, err := h.sumUsecase.Subscribe(id, userID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	defer unsubscribe()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	if summ.Status.IsFinished() {
+		c.SSEvent(string(models.SummaryFinishedEvent), summ.ToTransfer())
+		return
+	}
+	c.SSEvent(string(models.SummaryUpdatedEvent), summ.ToTransfer())
+
+	timeout := time.NewTimer(h.streamMaxDuration)
+	defer timeout.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-timeout.C:
+			return false
+		case event := <-events:
+			c.SSEvent(string(event.Type), event.Summary)
+			return event.Type != models.SummaryFinishedEvent
+		}
+	})
+}
+
+// ListVersions returns base texts and role rewrites of summary without their text
+func (h *Handler) ListVersions(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Errorf("Failed to cast id to uuid %s: %w", id, err)
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	userID, err := auth.GetUserId(c)
+	if err != nil || userID == uuid.Nil {
+		h.logger.Infof("Unathorized request for versions of summary %s", id.String())
+		c.JSON(http.StatusUnauthorized, "")
+		return
+	}
+
+	versions, err := h.sumUsecase.ListVersions(id, userID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	versionsTransfers := make([]*models.SummaryVersionTransfer, 0, len(versions))
+	for _, version := range versions {
+		versionsTransfers = append(versionsTransfers, version.ToTransfer())
+	}
+
+	c.JSON(http.StatusOK, ListSummaryVersionsResponse{Versions: versionsTransfers})
+}
+
+func (h *Handler) GetVersion(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Errorf("Failed to cast id to uuid %s: %w", id, err)
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		h.logger.Infof("Invalid summary version '%s'", c.Param("version"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	userID, err := auth.GetUserId(c)
+	if err != nil || userID == uuid.Nil {
+		h.logger.Infof("Unathorized request for version of summary %s", id.String())
+		c.JSON(http.StatusUnauthorized, "")
+		return
+	}
+
+	summVersion, err := h.sumUsecase.GetVersion(id, version, userID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summVersion.ToTransfer())
+}
+
+// DiffVersions compares versions passed in "from" and "to" query params
+func (h *Handler) DiffVersions(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Errorf("Failed to cast id to uuid %s: %w", id, err)
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil {
+		h.logger.Infof("Invalid summary version '%s'", c.Query("from"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil {
+		h.logger.Infof("Invalid summary version '%s'", c.Query("to"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	userID, err := auth.GetUserId(c)
+	if err != nil || userID == uuid.Nil {
+		h.logger.Infof("Unathorized request for diff of summary %s", id.String())
+		c.JSON(http.StatusUnauthorized, "")
+		return
+	}
+
+	diff, err := h.sumUsecase.DiffVersions(id, from, to, userID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, DiffSummaryVersionsResponse{
+		From: from,
+		To:   to,
+		Diff: diff,
+	})
+}
+
+// ToNote creates note from finished summary in chosen dir, content of note's document
+// is rendered by client from returned Markdown
+func (h *Handler) ToNote(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Errorf("Failed to cast id to uuid %s: %w", id, err)
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	userID, err := auth.GetUserId(c)
+	if err != nil || userID == uuid.Nil {
+		h.logger.Infof("Unathorized request for converting summary %s to note", id.String())
+		c.JSON(http.StatusUnauthorized, "")
+		return
+	}
+
+	var req SummaryToNoteRequest
+	c.BindJSON(&req)
+	if err := req.validate(); err != nil {
+		h.logger.Infof("Invalid summary to note request: %w", err)
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	note, content, err := h.sumUsecase.ToNote(id, req.DirID, req.AutomergeURL, req.Template, userID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SummaryToNoteResponse{
+		NoteID:  note.ID.String(),
+		Title:   note.Title,
+		Content: content,
+	})
+}
+
+// GetActiveSummaries is called by service clients with summary:read_active scope
 func (h *Handler) GetActiveSummaries(c *gin.Context) {
 	summaries, err := h.sumUsecase.GetActiveSummaries()
 	if err != nil {
@@ -141,7 +471,115 @@ func (h *Handler) GetActiveSummaries(c *gin.Context) {
 	c.JSON(http.StatusOK, summaryTransferList)
 }
 
-// TODO: check permissions by user
+// List returns page of summaries user can read, see ListSummariesRequest for filters
+func (h *Handler) List(c *gin.Context) {
+	userID, err := auth.GetUserId(c)
+	if err != nil || userID == uuid.Nil {
+		h.logger.Infof("Unathorized request for listing summaries")
+		c.JSON(http.StatusUnauthorized, "")
+		return
+	}
+
+	var req ListSummariesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Infof("Invalid list summaries request: %w", err)
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	opts, err := req.ToOptions()
+	if err != nil {
+		h.logger.Infof("Invalid list summaries request: %w", err)
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	summaries, nextCursor, err := h.sumUsecase.List(userID, opts)
+	if err != nil {
+		h.logger.Errorf("Error while listing summaries: %w", err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	summaryTransfers := make([]*models.SummaryTransfer, 0, len(summaries))
+	for i := range summaries {
+		summaryTransfers = append(summaryTransfers, summaries[i].ToTransfer())
+	}
+
+	var encodedNextCursor *string
+	if nextCursor != nil {
+		encoded := nextCursor.Encode()
+		encodedNextCursor = &encoded
+	}
+
+	c.JSON(http.StatusOK, ListSummariesResponse{Summaries: summaryTransfers, NextCursor: encodedNextCursor})
+}
+
+// Delete is allowed for summary's owner and users managing access to any note it's attached to,
+// the latter only detach summary from notes they manage
+func (h *Handler) Delete(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Errorf("Failed to cast id to uuid %s: %w", id, err)
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	userID, err := auth.GetUserId(c)
+	if err != nil || userID == uuid.Nil {
+		h.logger.Infof("Unathorized request for deleting summary %s", id.String())
+		c.JSON(http.StatusUnauthorized, "")
+		return
+	}
+
+	if err := h.sumUsecase.DeleteByID(id, userID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// ListPlatforms returns platforms summaries can be recorded on
+func (h *Handler) ListPlatforms(c *gin.Context) {
+	c.JSON(http.StatusOK, models.ListPlatforms())
+}
+
+// GetMine returns summaries recorded by current user, optionally filtered by ?platform=
+func (h *Handler) GetMine(c *gin.Context) {
+	userID, err := auth.GetUserId(c)
+	if err != nil || userID == uuid.Nil {
+		h.logger.Infof("Unathorized request for listing own summaries")
+		c.JSON(http.StatusUnauthorized, "")
+		return
+	}
+
+	var platform models.Platform
+	if rawPlatform := c.Query("platform"); rawPlatform != "" {
+		var ok bool
+		if platform, ok = models.PlatformFromString(rawPlatform); !ok {
+			h.logger.Errorf("Unknown platform %s", rawPlatform)
+			c.JSON(http.StatusBadRequest, "Unknown platform")
+			return
+		}
+	}
+
+	summaries, err := h.sumUsecase.ListByCreator(userID, platform)
+	if err != nil {
+		h.logger.Errorf("Error while listing summaries: %w", err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	summaryTransferList := make([]models.SummaryTransfer, len(summaries))
+	for i, summary := range summaries {
+		summaryTransferList[i] = *summary.ToTransfer()
+	}
+
+	c.JSON(http.StatusOK, summaryTransferList)
+}
+
+// UpdateName is allowed for summary's owner and users with write access to any note it's attached to
 func (h *Handler) UpdateName(c *gin.Context) {
 	type UpdateSummaryNameRequest struct {
 		ID   string `json:"id" valid:"required"`
@@ -161,12 +599,18 @@ func (h *Handler) UpdateName(c *gin.Context) {
 	if err != nil {
 		h.logger.Errorf("Failed to cast id to uuid %s: %w", id, err)
 		c.JSON(http.StatusBadRequest, err)
+		return
 	}
 
-	// TODO: return not found
-	if err := h.sumUsecase.UpdateName(id, req.Name); err != nil {
-		h.logger.Errorf("Error while finishing summary: %w", err)
-		c.JSON(http.StatusBadRequest, err)
+	userID, err := auth.GetUserId(c)
+	if err != nil || userID == uuid.Nil {
+		h.logger.Infof("Unathorized request for renaming summary %s", id.String())
+		c.JSON(http.StatusUnauthorized, "")
+		return
+	}
+
+	if err := h.sumUsecase.UpdateName(id, req.Name, userID); err != nil {
+		h.respondError(c, err)
 		return
 	}
 
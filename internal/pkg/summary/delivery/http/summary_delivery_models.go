@@ -0,0 +1,137 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	valid "github.com/asaskevich/govalidator"
+	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/utils"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
+
+type ListSummaryVersionsResponse struct {
+	Versions []*models.SummaryVersionTransfer `json:"versions"`
+}
+
+type DiffSummaryVersionsResponse struct {
+	From int              `json:"from"`
+	To   int              `json:"to"`
+	Diff []utils.DiffLine `json:"diff"`
+}
+
+type SummaryToNoteRequest struct {
+	DirID        int    `json:"dir_id" valid:"required"`
+	AutomergeURL string `json:"automerge_url" valid:"required"`
+	// Template is note's Markdown content with placeholders {{title}}, {{name}}, {{platform}}, {{started_at}},
+	// {{detalization}}, {{text}} and {{roles}}, default one is used if empty
+	Template string `json:"template"`
+}
+
+func (r *SummaryToNoteRequest) validate() error {
+	_, err := valid.ValidateStruct(r)
+	return err
+}
+
+type SummaryToNoteResponse struct {
+	NoteID  string `json:"note_id"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+type ListSummariesRequest struct {
+	Limit        int    `form:"limit"`
+	Cursor       string `form:"cursor"`
+	Platform     string `form:"platform"`
+	Detalization string `form:"detalization"`
+	Status       string `form:"status"`
+	// From and To are RFC 3339 bounds of summary's start time, To is exclusive
+	From    string `form:"from"`
+	To      string `form:"to"`
+	Query   string `form:"q"`
+	NoteID  string `form:"note_id"`
+	HasNote *bool  `form:"has_note"`
+}
+
+func (lsr *ListSummariesRequest) ToOptions() (models.SummaryListOptions, error) {
+	var opts models.SummaryListOptions
+
+	opts.Limit = lsr.Limit
+	if opts.Limit == 0 {
+		opts.Limit = defaultListLimit
+	}
+	if opts.Limit < 0 || opts.Limit > maxListLimit {
+		return opts, errors.New(fmt.Sprintf("Invalid limit: %d", lsr.Limit))
+	}
+
+	if lsr.Cursor != "" {
+		cursor, err := models.DecodeSummaryListCursor(lsr.Cursor)
+		if err != nil {
+			return opts, err
+		}
+		opts.Cursor = cursor
+	}
+
+	if lsr.Platform != "" {
+		platform, ok := models.PlatformFromString(lsr.Platform)
+		if !ok {
+			return opts, errors.New(fmt.Sprintf("Unknown platform: %s", lsr.Platform))
+		}
+		opts.Platform = platform
+	}
+
+	if lsr.Detalization != "" {
+		detalization := models.DetalizationFromString(lsr.Detalization)
+		if detalization.String() != lsr.Detalization {
+			return opts, errors.New(fmt.Sprintf("Invalid detalization: %s", lsr.Detalization))
+		}
+		opts.Detalization = &detalization
+	}
+
+	switch status := models.SummaryStatus(lsr.Status); {
+	case status == "":
+	case status.IsActive() || status.IsFinished():
+		opts.Status = status
+	default:
+		return opts, errors.New(fmt.Sprintf("Invalid status: %s", lsr.Status))
+	}
+
+	if lsr.From != "" {
+		from, err := time.Parse(time.RFC3339, lsr.From)
+		if err != nil {
+			return opts, errors.New(fmt.Sprintf("Invalid from: %s", lsr.From))
+		}
+		opts.StartedFrom = &from
+	}
+	if lsr.To != "" {
+		to, err := time.Parse(time.RFC3339, lsr.To)
+		if err != nil {
+			return opts, errors.New(fmt.Sprintf("Invalid to: %s", lsr.To))
+		}
+		opts.StartedTo = &to
+	}
+
+	if lsr.NoteID != "" {
+		noteID, err := uuid.FromString(lsr.NoteID)
+		if err != nil {
+			return opts, err
+		}
+		opts.NoteID = &noteID
+	}
+
+	opts.NameQuery = lsr.Query
+	opts.HasNote = lsr.HasNote
+
+	return opts, nil
+}
+
+type ListSummariesResponse struct {
+	Summaries  []*models.SummaryTransfer `json:"summaries"`
+	NextCursor *string                   `json:"next_cursor"`
+}
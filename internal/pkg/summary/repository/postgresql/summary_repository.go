@@ -4,14 +4,21 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/gofrs/uuid/v5"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/yarikTri/archipelago-notes-api/internal/common/repository"
 	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/summary"
 )
 
+// summaryColumns are selected to get models.Summary
+const summaryColumns = `id, text, status, finish_reason, finished_at, updated_at, text_with_role, role, platform,
+	meeting_url, external_meeting_id, participants, duration_seconds, started_at, detalization, name, creator_id`
+
 // PostgreSQL implements notes.Repository
 type PostgreSQL struct {
 	db *sqlx.DB
@@ -37,36 +44,236 @@ func (p *PostgreSQL) UpdateSummaryTextRole(ID uuid.UUID, textWithRole, role stri
 	return nil
 }
 
-func (p *PostgreSQL) SaveSummaryText(ID uuid.UUID, text string, active bool, detalization models.Detalization, platform string) (*models.Summary, error) {
+// SaveSummaryText upserts summary text, creator is set only if summary doesn't have one yet.
+// Platform's metadata is changed only if passed. Text of finished summary can't be changed,
+// text assembled from chunks can't be replaced, so it's accepted only unchanged
+func (p *PostgreSQL) SaveSummaryText(ID uuid.UUID, text string, status models.SummaryStatus, detalization models.Detalization, platform models.Platform, meta models.PlatformMeta, creatorID uuid.UUID) (*models.Summary, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := p.lockWritable(tx, ID, text); err != nil {
+		return nil, err
+	}
+
 	query := fmt.Sprint(
-		`INSERT INTO summ (id, text, active, platform, detalization)
-		VALUES ($1, $2, $3, $4, $5)
+		`INSERT INTO summ (id, text, status, platform, detalization, creator_id,
+			meeting_url, external_meeting_id, participants, duration_seconds)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		ON CONFLICT (id)
-		DO UPDATE SET text = $2, text_with_role = '', role = ''`, // TODO: check multiple update
+		DO UPDATE SET
+			text = $2,
+			text_with_role = '',
+			role = '',
+			status = CASE WHEN summ.status IN ('recording', 'finishing') THEN EXCLUDED.status ELSE summ.status END,
+			creator_id = COALESCE(summ.creator_id, EXCLUDED.creator_id),
+			meeting_url = COALESCE(EXCLUDED.meeting_url, summ.meeting_url),
+			external_meeting_id = COALESCE(EXCLUDED.external_meeting_id, summ.external_meeting_id),
+			participants = CASE WHEN cardinality(EXCLUDED.participants) > 0 THEN EXCLUDED.participants ELSE summ.participants END,
+			duration_seconds = COALESCE(EXCLUDED.duration_seconds, summ.duration_seconds)
+		RETURNING `, summaryColumns, // TODO: check multiple update
 	)
-	if _, err := p.db.Exec(query, ID, text, active, platform, detalization); err != nil {
+
+	var summary models.Summary
+	if err := tx.Get(&summary, query, ID, text, status, platform, detalization, creatorID,
+		meta.MeetingURL, meta.ExternalMeetingID, participants(meta), meta.DurationSeconds); err != nil {
 		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
 	}
 
-	return &models.Summary{ID: ID, Text: text, Active: active}, nil
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return &summary, nil
 }
 
-func (p *PostgreSQL) FinishSummary(ID uuid.UUID) error {
+// lockWritable locks existing summary and checks that its text may be set to passed one,
+// summary which doesn't exist yet is writable
+func (p *PostgreSQL) lockWritable(tx *sqlx.Tx, ID uuid.UUID, text string) error {
+	query := fmt.Sprint(
+		`SELECT status, COALESCE(text, '') AS text,
+			EXISTS (SELECT 1 FROM summ_chunk WHERE summ_id = summ.id) AS chunked
+		FROM summ
+		WHERE id = $1
+		FOR UPDATE`,
+	)
+
+	var current struct {
+		Status  models.SummaryStatus `db:"status"`
+		Text    string               `db:"text"`
+		Chunked bool                 `db:"chunked"`
+	}
+	if err := tx.Get(&current, query, ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	if !current.Status.IsActive() {
+		return fmt.Errorf("(repo) %w: %s", summary.ErrFinished, current.Status)
+	}
+	if current.Chunked && current.Text != text {
+		return fmt.Errorf("(repo) %w", summary.ErrChunkedText)
+	}
+
+	return nil
+}
+
+// FinishSummary sets final status of active summary, finished summary is left as is
+func (p *PostgreSQL) FinishSummary(ID uuid.UUID, status models.SummaryStatus, reason string) error {
 	query := fmt.Sprint(
 		`UPDATE summ
-		SET active = false
-		WHERE id = $1;`,
+		SET status = $2, finish_reason = $3, finished_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status IN ('recording', 'finishing');`,
+	)
+	res, err := p.db.Exec(query, ID, status, nullableString(reason))
+	if err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	finished, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to get affected rows: %w", err)
+	}
+	if finished > 0 {
+		return nil
+	}
+
+	existsQuery := fmt.Sprint(
+		`SELECT EXISTS (SELECT 1 FROM summ WHERE id = $1)`,
 	)
-	if _, err := p.db.Exec(query, ID); err != nil {
+	var exists bool
+	if err := p.db.Get(&exists, existsQuery, ID); err != nil {
 		return fmt.Errorf("(repo) failed to exec query: %w", err)
 	}
+	if !exists {
+		return fmt.Errorf("(repo) %w", &repository.NotFoundError{ID: ID})
+	}
 
 	return nil
 }
 
+// AbandonNotUpdatedSince finishes active summaries which weren't updated since time with abandoned status
+func (p *PostgreSQL) AbandonNotUpdatedSince(since time.Time, reason string) ([]uuid.UUID, error) {
+	query := fmt.Sprint(
+		`UPDATE summ
+		SET status = 'abandoned', finish_reason = $2, finished_at = CURRENT_TIMESTAMP
+		WHERE status IN ('recording', 'finishing') AND updated_at < $1
+		RETURNING id`,
+	)
+
+	var IDs []uuid.UUID
+	if err := p.db.Select(&IDs, query, since, reason); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return IDs, nil
+}
+
+// participants are never NULL, so they are compared by cardinality on upsert
+func participants(meta models.PlatformMeta) pq.StringArray {
+	if meta.Participants == nil {
+		return pq.StringArray{}
+	}
+
+	return meta.Participants
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
+
+// AppendChunk locks summary, so chunks of one summary are appended strictly one by one.
+// Chunks aren't appended to finished summary, though already appended one is still returned on retry
+func (p *PostgreSQL) AppendChunk(chunk models.SummaryChunk) (*models.SummaryChunk, bool, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, false, fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	lockQuery := fmt.Sprint(
+		`SELECT status FROM summ WHERE id = $1 FOR UPDATE`,
+	)
+	var status models.SummaryStatus
+	if err := tx.Get(&status, lockQuery, chunk.SummaryID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: chunk.SummaryID}, err)
+		}
+
+		return nil, false, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	existingQuery := fmt.Sprint(
+		`SELECT summ_id, seq, text, recorded_at, created_at
+			FROM summ_chunk
+			WHERE summ_id = $1 AND seq = $2`,
+	)
+	var existing models.SummaryChunk
+	err = tx.Get(&existing, existingQuery, chunk.SummaryID, chunk.Seq)
+	if err == nil {
+		if existing.Text != chunk.Text {
+			return nil, false, fmt.Errorf("(repo) %w: seq %d", summary.ErrChunkConflict, chunk.Seq)
+		}
+
+		return &existing, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	if !status.IsActive() {
+		return nil, false, fmt.Errorf("(repo) %w: %s", summary.ErrFinished, status)
+	}
+
+	lastSeqQuery := fmt.Sprint(
+		`SELECT COALESCE(MAX(seq), 0) FROM summ_chunk WHERE summ_id = $1`,
+	)
+	var lastSeq int
+	if err := tx.Get(&lastSeq, lastSeqQuery, chunk.SummaryID); err != nil {
+		return nil, false, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+	if chunk.Seq != lastSeq+1 {
+		return nil, false, fmt.Errorf("(repo) %w: expected seq %d, got %d", summary.ErrChunkOutOfOrder, lastSeq+1, chunk.Seq)
+	}
+
+	insertQuery := fmt.Sprint(
+		`INSERT INTO summ_chunk (summ_id, seq, text, recorded_at)
+			VALUES ($1, $2, $3, $4)
+			RETURNING summ_id, seq, text, recorded_at, created_at`,
+	)
+	var appended models.SummaryChunk
+	if err := tx.Get(&appended, insertQuery, chunk.SummaryID, chunk.Seq, chunk.Text, chunk.RecordedAt); err != nil {
+		return nil, false, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	appendTextQuery := fmt.Sprint(
+		`UPDATE summ
+			SET text = COALESCE(text, '') || $2
+			WHERE id = $1`,
+	)
+	if _, err := tx.Exec(appendTextQuery, chunk.SummaryID, chunk.Text); err != nil {
+		return nil, false, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return &appended, true, nil
+}
+
 func (p *PostgreSQL) GetSummary(ID uuid.UUID) (*models.Summary, error) {
 	query := fmt.Sprint(
-		`SELECT id, text, active, text_with_role, role, platform, started_at, detalization, name
+		`SELECT `, summaryColumns, `
 			FROM summ
 			WHERE id = $1`,
 	)
@@ -85,9 +292,9 @@ func (p *PostgreSQL) GetSummary(ID uuid.UUID) (*models.Summary, error) {
 
 func (p *PostgreSQL) GetActiveSummaries() ([]models.Summary, error) {
 	query := fmt.Sprint(
-		`SELECT id, text, active, text_with_role, role, platform, started_at, detalization, name
+		`SELECT `, summaryColumns, `
 			FROM summ
-			WHERE active = true`,
+			WHERE status IN ('recording', 'finishing')`,
 	)
 
 	var summariesFromQuery []*models.Summary
@@ -103,6 +310,153 @@ func (p *PostgreSQL) GetActiveSummaries() ([]models.Summary, error) {
 	return summaries, nil
 }
 
+func (p *PostgreSQL) ListByCreator(userID uuid.UUID, platform models.Platform) ([]models.Summary, error) {
+	query := fmt.Sprint(
+		`SELECT `, summaryColumns, `
+			FROM summ
+			WHERE creator_id = $1 AND ($2 = '' OR platform = $2)
+			ORDER BY started_at DESC`,
+	)
+
+	var summaries []models.Summary
+	if err := p.db.Select(&summaries, query, userID, platform); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// GetUserAccess resolves user's access to summary through its owner and attached notes, see summ_user_access
+func (p *PostgreSQL) GetUserAccess(ID uuid.UUID, userID uuid.UUID) (models.NoteAccess, error) {
+	query := fmt.Sprint(
+		`SELECT summ_user_access(id, $2)
+			FROM summ
+			WHERE id = $1`,
+	)
+
+	var access string
+	if err := p.db.Get(&access, query, ID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.UndefinedNoteAccess, fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: ID}, err)
+		}
+
+		return models.UndefinedNoteAccess, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return models.NoteAccessFromString(access), nil
+}
+
+// List returns page of summaries user can read, filtered by opts and sorted from the latest.
+// Keyset pagination by (started_at, id) is used
+func (p *PostgreSQL) List(userID uuid.UUID, opts models.SummaryListOptions) ([]models.Summary, error) {
+	args := []any{userID}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// Same as summ_user_access, but owned summaries are matched by index without checking accesses to notes
+	conditions := []string{
+		`(creator_id = $1 OR EXISTS (
+			SELECT 1
+			FROM summ_to_note sn INNER JOIN note n ON sn.note_id = n.id
+			WHERE sn.summ_id = summ.id AND n.deleted_at IS NULL
+				AND access_rank(note_user_access(n.id, $1)) >= access_rank('r')
+		))`,
+	}
+
+	if opts.Platform != "" {
+		conditions = append(conditions, fmt.Sprintf("platform = %s", arg(opts.Platform)))
+	}
+	if opts.Detalization != nil {
+		conditions = append(conditions, fmt.Sprintf("detalization = %s", arg(*opts.Detalization)))
+	}
+	if opts.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("status = %s", arg(opts.Status)))
+	}
+	if opts.StartedFrom != nil {
+		conditions = append(conditions, fmt.Sprintf("started_at >= %s", arg(*opts.StartedFrom)))
+	}
+	if opts.StartedTo != nil {
+		conditions = append(conditions, fmt.Sprintf("started_at < %s", arg(*opts.StartedTo)))
+	}
+	if opts.NameQuery != "" {
+		conditions = append(conditions, fmt.Sprintf("strpos(lower(name), lower(%s)) > 0", arg(opts.NameQuery)))
+	}
+	if opts.NoteID != nil {
+		conditions = append(conditions, fmt.Sprintf(
+			"id IN (SELECT summ_id FROM summ_to_note WHERE note_id = %s)", arg(*opts.NoteID),
+		))
+	}
+	if opts.HasNote != nil {
+		hasNoteCondition := "EXISTS (SELECT 1 FROM summ_to_note sn WHERE sn.summ_id = id)"
+		if !*opts.HasNote {
+			hasNoteCondition = "NOT " + hasNoteCondition
+		}
+		conditions = append(conditions, hasNoteCondition)
+	}
+
+	if opts.Cursor != nil {
+		conditions = append(conditions, fmt.Sprintf(
+			"(started_at, id) < (%s::timestamptz, %s)", arg(opts.Cursor.StartedAt), arg(opts.Cursor.ID),
+		))
+	}
+
+	query := fmt.Sprintf(
+		`SELECT %s
+			FROM summ
+			WHERE %s
+			ORDER BY started_at DESC, id DESC
+			LIMIT %s`,
+		summaryColumns, strings.Join(conditions, " AND "), arg(opts.Limit),
+	)
+
+	var summaries []models.Summary
+	if err := p.db.Select(&summaries, query, args...); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// DeleteByID deletes summary, its links to notes, chunks and versions are deleted by cascade
+func (p *PostgreSQL) DeleteByID(ID uuid.UUID) error {
+	query := fmt.Sprint(
+		`DELETE
+		FROM summ
+		WHERE id = $1`,
+	)
+
+	resExec, err := p.db.Exec(query, ID)
+	if err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+	deleted, err := resExec.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to check RowsAffected: %w", err)
+	}
+
+	if deleted == 0 {
+		return fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: ID})
+	}
+
+	return nil
+}
+
+func (p *PostgreSQL) DetachFromManagedNotes(ID uuid.UUID, userID uuid.UUID) error {
+	query := fmt.Sprint(
+		`DELETE
+		FROM summ_to_note
+		WHERE summ_id = $1 AND note_user_access(note_id, $2) = 'ma'`,
+	)
+
+	if _, err := p.db.Exec(query, ID, userID); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
 func (p *PostgreSQL) UpdateName(ID uuid.UUID, name string) error {
 	query := fmt.Sprint(
 		`UPDATE summ
@@ -115,3 +469,54 @@ func (p *PostgreSQL) UpdateName(ID uuid.UUID, name string) error {
 
 	return nil
 }
+
+func (p *PostgreSQL) ListVersions(ID uuid.UUID) ([]*models.SummaryVersion, error) {
+	query := fmt.Sprint(
+		`SELECT summ_id, version, kind, detalization, role, created_at, updated_at
+			FROM summ_version
+			WHERE summ_id = $1
+			ORDER BY version`,
+	)
+
+	var versions []*models.SummaryVersion
+	if err := p.db.Select(&versions, query, ID); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return versions, nil
+}
+
+func (p *PostgreSQL) GetVersion(ID uuid.UUID, version int) (*models.SummaryVersion, error) {
+	query := fmt.Sprint(
+		`SELECT summ_id, version, kind, detalization, role, text, created_at, updated_at
+			FROM summ_version
+			WHERE summ_id = $1 AND version = $2`,
+	)
+
+	var summVersion models.SummaryVersion
+	if err := p.db.Get(&summVersion, query, ID, version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: version}, err)
+		}
+
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return &summVersion, nil
+}
+
+func (p *PostgreSQL) ListLatestRoleVersions(ID uuid.UUID) ([]*models.SummaryVersion, error) {
+	query := fmt.Sprint(
+		`SELECT DISTINCT ON (role) summ_id, version, kind, detalization, role, text, created_at, updated_at
+			FROM summ_version
+			WHERE summ_id = $1 AND kind = 'role'
+			ORDER BY role, version DESC`,
+	)
+
+	var versions []*models.SummaryVersion
+	if err := p.db.Select(&versions, query, ID); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return versions, nil
+}
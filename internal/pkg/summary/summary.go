@@ -1,24 +1,96 @@
 package summary
 
 import (
+	"errors"
+	"time"
+
 	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/utils"
 	"github.com/yarikTri/archipelago-notes-api/internal/models"
 )
 
+// ErrForbidden is returned when user's access to summary isn't enough for operation
+var ErrForbidden = errors.New("access to summary forbidden")
+
+// ErrInvalidPlatform is returned when platform is unknown or its metadata is invalid
+var ErrInvalidPlatform = errors.New("invalid platform")
+
+// ErrInvalidStatus is returned on attempt to finish summary with status other than done or failed
+var ErrInvalidStatus = errors.New("invalid summary status")
+
+// ErrNotFinished is returned on attempt to convert summary which is still recorded to note
+var ErrNotFinished = errors.New("summary isn't finished")
+
+// ErrInvalidTemplate is returned when note template has unknown placeholder or renders too large content
+var ErrInvalidTemplate = errors.New("invalid note template")
+
+// ErrChunkOutOfOrder is returned when chunk's sequence number isn't next after the last appended one
+var ErrChunkOutOfOrder = errors.New("chunk is out of order")
+
+// ErrChunkConflict is returned when chunk with the same sequence number but another text is already appended
+var ErrChunkConflict = errors.New("chunk with this sequence number already exists")
+
+// ErrFinished is returned on attempt to change text of summary which isn't recorded anymore
+var ErrFinished = errors.New("summary is already finished")
+
+// ErrChunkedText is returned on attempt to replace text of summary which is assembled from chunks
+var ErrChunkedText = errors.New("summary text is assembled from chunks")
+
 type Usecase interface {
-	SaveSummaryText(ID uuid.UUID, text string, active bool, detalization models.Detalization, platform string) (*models.Summary, error)
+	SaveSummaryText(ID uuid.UUID, text string, status models.SummaryStatus, detalization models.Detalization, platform models.Platform, meta models.PlatformMeta, creatorID uuid.UUID) (*models.Summary, error)
 	UpdateSummaryTextRole(ID uuid.UUID, textWithRole, role string) error
-	FinishSummary(ID uuid.UUID) error
-	GetSummary(ID uuid.UUID) (*models.Summary, error)
+	AppendChunk(chunk models.SummaryChunk) (*models.SummaryChunk, bool, error)
+	FinishSummary(ID uuid.UUID, status models.SummaryStatus, reason string) error
+	// AbandonStale finishes summaries which weren't updated longer than timeout, returns number of them
+	AbandonStale(timeout time.Duration) (int, error)
+	GetSummary(ID uuid.UUID, userID uuid.UUID) (*models.Summary, error)
 	GetActiveSummaries() ([]models.Summary, error)
-	UpdateName(ID uuid.UUID, name string) error
+	// ListByCreator returns summaries of user, empty platform matches any
+	ListByCreator(userID uuid.UUID, platform models.Platform) ([]models.Summary, error)
+	// List returns page of summaries user can read and cursor of the next page, which is nil for the last page
+	List(userID uuid.UUID, opts models.SummaryListOptions) ([]models.Summary, *models.SummaryListCursor, error)
+	UpdateName(ID uuid.UUID, name string, userID uuid.UUID) error
+	// DeleteByID deletes summary of its owner, other users managing summary only detach it from notes they manage
+	DeleteByID(ID uuid.UUID, userID uuid.UUID) error
+
+	Subscribe(ID uuid.UUID, userID uuid.UUID) (*models.Summary, <-chan models.SummaryEvent, func(), error)
+
+	ListVersions(ID uuid.UUID, userID uuid.UUID) ([]*models.SummaryVersion, error)
+	GetVersion(ID uuid.UUID, version int, userID uuid.UUID) (*models.SummaryVersion, error)
+	DiffVersions(ID uuid.UUID, fromVersion, toVersion int, userID uuid.UUID) ([]utils.DiffLine, error)
+
+	// ToNote creates note with attached summary, returns it with content for note's document
+	ToNote(ID uuid.UUID, dirID int, automergeURL, template string, userID uuid.UUID) (*models.Note, string, error)
 }
 
 type Repository interface {
-	SaveSummaryText(ID uuid.UUID, text string, active bool, detalization models.Detalization, platform string) (*models.Summary, error)
+	SaveSummaryText(ID uuid.UUID, text string, status models.SummaryStatus, detalization models.Detalization, platform models.Platform, meta models.PlatformMeta, creatorID uuid.UUID) (*models.Summary, error)
 	UpdateSummaryTextRole(ID uuid.UUID, textWithRole, role string) error
-	FinishSummary(ID uuid.UUID) error
+	// AppendChunk appends chunk to summary's text, returns false if the same chunk was already appended
+	AppendChunk(chunk models.SummaryChunk) (*models.SummaryChunk, bool, error)
+	FinishSummary(ID uuid.UUID, status models.SummaryStatus, reason string) error
+	AbandonNotUpdatedSince(since time.Time, reason string) ([]uuid.UUID, error)
 	GetSummary(ID uuid.UUID) (*models.Summary, error)
 	GetActiveSummaries() ([]models.Summary, error)
+	// ListByCreator returns summaries of user, empty platform matches any
+	ListByCreator(userID uuid.UUID, platform models.Platform) ([]models.Summary, error)
+	List(userID uuid.UUID, opts models.SummaryListOptions) ([]models.Summary, error)
 	UpdateName(ID uuid.UUID, name string) error
+	DeleteByID(ID uuid.UUID) error
+	// DetachFromManagedNotes detaches summary from notes user manages access to
+	DetachFromManagedNotes(ID uuid.UUID, userID uuid.UUID) error
+
+	GetUserAccess(ID uuid.UUID, userID uuid.UUID) (models.NoteAccess, error)
+
+	// ListVersions returns versions without text
+	ListVersions(ID uuid.UUID) ([]*models.SummaryVersion, error)
+	GetVersion(ID uuid.UUID, version int) (*models.SummaryVersion, error)
+	// ListLatestRoleVersions returns the latest rewrite for every role
+	ListLatestRoleVersions(ID uuid.UUID) ([]*models.SummaryVersion, error)
+}
+
+// Broker delivers summary events to subscribers, returned func cancels subscription
+type Broker interface {
+	Publish(event models.SummaryEvent) error
+	Subscribe(ID uuid.UUID) (<-chan models.SummaryEvent, func())
 }
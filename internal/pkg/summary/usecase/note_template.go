@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/summary"
+)
+
+const (
+	// noteTitleMaxLength is limited by note.title column
+	noteTitleMaxLength = 64
+	defaultNoteName    = "Встреча"
+	noteTimeLayout     = "02.01.2006 15:04"
+
+	// User's template may repeat placeholders of long texts many times
+	noteContentMaxSize = 1 << 20
+)
+
+var errNoteContentTooLarge = errors.New("note content is too large")
+
+// limitedBuffer fails writes beyond limit
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, errNoteContentTooLarge
+	}
+
+	return b.Buffer.Write(p)
+}
+
+// Note templates are plain text with fixed placeholders, they aren't executed as code,
+// so template of any client is rendered in time linear to its length
+const (
+	titlePlaceholder        = "{{title}}"
+	namePlaceholder         = "{{name}}"
+	platformPlaceholder     = "{{platform}}"
+	startedAtPlaceholder    = "{{started_at}}"
+	detalizationPlaceholder = "{{detalization}}"
+	textPlaceholder         = "{{text}}"
+	// rolesPlaceholder is replaced with section of latest rewrite for every role
+	rolesPlaceholder = "{{roles}}"
+)
+
+var notePlaceholderRegexp = regexp.MustCompile(`{{[^{}]*}}`)
+
+var notePlaceholders = map[string]struct{}{
+	titlePlaceholder:        {},
+	namePlaceholder:         {},
+	platformPlaceholder:     {},
+	startedAtPlaceholder:    {},
+	detalizationPlaceholder: {},
+	textPlaceholder:         {},
+	rolesPlaceholder:        {},
+}
+
+// defaultNoteTemplate renders summary into Markdown content of note
+const defaultNoteTemplate = `# {{title}}
+
+- Платформа: {{platform}}
+- Начало: {{started_at}}
+- Детализация: {{detalization}}
+
+## Саммари
+
+{{text}}
+{{roles}}`
+
+const noteRoleSectionFormat = "\n## Для роли «%s»\n\n%s\n"
+
+func noteTitle(summ *models.Summary) string {
+	name := summ.Name
+	if name == "" {
+		name = defaultNoteName
+	}
+
+	title := fmt.Sprintf("%s %s", name, summ.StartedAt.Format(noteTimeLayout))
+	if utf8.RuneCountInString(title) > noteTitleMaxLength {
+		title = string([]rune(title)[:noteTitleMaxLength])
+	}
+
+	return title
+}
+
+// renderNoteContent fills placeholders of template with summary and its latest rewrite for every role.
+// Placeholders are replaced in one pass, so placeholders in summary texts are kept as is
+func renderNoteContent(tmpl string, summ *models.Summary, roleVersions []*models.SummaryVersion) (string, error) {
+	if tmpl == "" {
+		tmpl = defaultNoteTemplate
+	}
+
+	for _, placeholder := range notePlaceholderRegexp.FindAllString(tmpl, -1) {
+		if _, ok := notePlaceholders[placeholder]; !ok {
+			return "", fmt.Errorf("(usecase) %w: unknown placeholder %s", summary.ErrInvalidTemplate, placeholder)
+		}
+	}
+
+	var roles strings.Builder
+	for _, version := range roleVersions {
+		fmt.Fprintf(&roles, noteRoleSectionFormat, version.Role, version.Text)
+	}
+
+	replacer := strings.NewReplacer(
+		titlePlaceholder, noteTitle(summ),
+		namePlaceholder, summ.Name,
+		platformPlaceholder, summ.Platform.Title(),
+		startedAtPlaceholder, summ.StartedAt.Format(noteTimeLayout),
+		detalizationPlaceholder, summ.Detalization.String(),
+		textPlaceholder, summ.Text,
+		rolesPlaceholder, roles.String(),
+	)
+
+	content := &limitedBuffer{limit: noteContentMaxSize}
+	if _, err := replacer.WriteString(content, tmpl); err != nil {
+		return "", fmt.Errorf("(usecase) %w: %v", summary.ErrInvalidTemplate, err)
+	}
+
+	return content.String(), nil
+}
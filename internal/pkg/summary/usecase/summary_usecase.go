@@ -1,31 +1,82 @@
 package usecase
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/go-park-mail-ru/2023_1_Technokaif/pkg/logger"
 	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/utils"
 	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/dirs"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/notes"
 	"github.com/yarikTri/archipelago-notes-api/internal/pkg/summary"
 )
 
 // Usecase implements notes.Usecase
 type Usecase struct {
-	repo summary.Repository
+	repo         summary.Repository
+	broker       summary.Broker
+	notesUsecase notes.Usecase
+	dirsUsecase  dirs.Usecase
+	logger       logger.Logger
 }
 
-func NewUsecase(rr summary.Repository) *Usecase {
+func NewUsecase(rr summary.Repository, b summary.Broker, nu notes.Usecase, du dirs.Usecase, l logger.Logger) *Usecase {
 	return &Usecase{
-		repo: rr,
+		repo:         rr,
+		broker:       b,
+		notesUsecase: nu,
+		dirsUsecase:  du,
+		logger:       l,
 	}
 }
 
-func (u *Usecase) SaveSummaryText(ID uuid.UUID, text string, active bool, detalization models.Detalization, platform string) (*models.Summary, error) {
-	return u.repo.SaveSummaryText(ID, text, active, detalization, platform)
+func (u *Usecase) SaveSummaryText(ID uuid.UUID, text string, status models.SummaryStatus, detalization models.Detalization, platform models.Platform, meta models.PlatformMeta, creatorID uuid.UUID) (*models.Summary, error) {
+	if err := platform.Validate(meta); err != nil {
+		return nil, fmt.Errorf("(usecase) %w: %v", summary.ErrInvalidPlatform, err)
+	}
+
+	summ, err := u.repo.SaveSummaryText(ID, text, status, detalization, platform, meta, creatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	u.publish(ID, models.SummaryUpdatedEvent)
+
+	return summ, nil
 }
 
 func (u *Usecase) UpdateSummaryTextRole(ID uuid.UUID, textWithRole, role string) error {
-	return u.repo.UpdateSummaryTextRole(ID, textWithRole, role)
+	if err := u.repo.UpdateSummaryTextRole(ID, textWithRole, role); err != nil {
+		return err
+	}
+
+	u.publish(ID, models.SummaryUpdatedEvent)
+
+	return nil
 }
 
-func (u *Usecase) GetSummary(ID uuid.UUID) (*models.Summary, error) {
+// AppendChunk is idempotent: repeated chunk is returned as is without publishing an event
+func (u *Usecase) AppendChunk(chunk models.SummaryChunk) (*models.SummaryChunk, bool, error) {
+	appended, created, err := u.repo.AppendChunk(chunk)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if created {
+		u.publish(chunk.SummaryID, models.SummaryUpdatedEvent)
+	}
+
+	return appended, created, nil
+}
+
+// GetSummary returns summary if user is its owner or has read access to any note it's attached to
+func (u *Usecase) GetSummary(ID uuid.UUID, userID uuid.UUID) (*models.Summary, error) {
+	if err := u.checkAccess(ID, userID, models.ReadNoteAccess); err != nil {
+		return nil, err
+	}
+
 	return u.repo.GetSummary(ID)
 }
 
@@ -33,10 +84,201 @@ func (u *Usecase) GetActiveSummaries() ([]models.Summary, error) {
 	return u.repo.GetActiveSummaries()
 }
 
-func (u *Usecase) FinishSummary(ID uuid.UUID) error {
-	return u.repo.FinishSummary(ID)
+func (u *Usecase) ListByCreator(userID uuid.UUID, platform models.Platform) ([]models.Summary, error) {
+	return u.repo.ListByCreator(userID, platform)
+}
+
+func (u *Usecase) FinishSummary(ID uuid.UUID, status models.SummaryStatus, reason string) error {
+	if status != models.DoneSummaryStatus && status != models.FailedSummaryStatus {
+		return fmt.Errorf("(usecase) %w: %s", summary.ErrInvalidStatus, status)
+	}
+
+	if err := u.repo.FinishSummary(ID, status, reason); err != nil {
+		return err
+	}
+
+	u.publish(ID, models.SummaryFinishedEvent)
+
+	return nil
 }
 
-func (u *Usecase) UpdateName(ID uuid.UUID, name string) error {
+func (u *Usecase) AbandonStale(timeout time.Duration) (int, error) {
+	reason := fmt.Sprintf("no updates for %s", timeout)
+	IDs, err := u.repo.AbandonNotUpdatedSince(time.Now().Add(-timeout), reason)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, ID := range IDs {
+		u.publish(ID, models.SummaryFinishedEvent)
+	}
+
+	return len(IDs), nil
+}
+
+// Subscribe returns current state of summary and channel of its further events.
+// Subscription is made before reading state, so no update is lost in between
+func (u *Usecase) Subscribe(ID uuid.UUID, userID uuid.UUID) (*models.Summary, <-chan models.SummaryEvent, func(), error) {
+	if err := u.checkAccess(ID, userID, models.ReadNoteAccess); err != nil {
+		return nil, nil, nil, err
+	}
+
+	events, unsubscribe := u.broker.Subscribe(ID)
+
+	summ, err := u.repo.GetSummary(ID)
+	if err != nil {
+		unsubscribe()
+		return nil, nil, nil, err
+	}
+
+	return summ, events, unsubscribe, nil
+}
+
+// publish sends current state of summary to its subscribers. It's called after change is saved,
+// so failed publishing is only logged: subscribers get fresh state on reconnect
+func (u *Usecase) publish(ID uuid.UUID, eventType models.SummaryEventType) {
+	summ, err := u.repo.GetSummary(ID)
+	if err != nil {
+		u.logger.Errorf("failed to get summary %s to publish %s event: %v", ID, eventType, err)
+		return
+	}
+
+	if err := u.broker.Publish(models.NewSummaryEvent(eventType, summ)); err != nil {
+		u.logger.Errorf("failed to publish %s event of summary %s: %v", eventType, ID, err)
+	}
+}
+
+// UpdateName renames summary if user is its owner or has write access to any note it's attached to
+func (u *Usecase) UpdateName(ID uuid.UUID, name string, userID uuid.UUID) error {
+	if err := u.checkAccess(ID, userID, models.WriteNoteAccess); err != nil {
+		return err
+	}
+
 	return u.repo.UpdateName(ID, name)
 }
+
+func (u *Usecase) List(userID uuid.UUID, opts models.SummaryListOptions) ([]models.Summary, *models.SummaryListCursor, error) {
+	pageSize := opts.Limit
+	opts.Limit++
+
+	summaries, err := u.repo.List(userID, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(summaries) <= pageSize {
+		return summaries, nil, nil
+	}
+
+	summaries = summaries[:pageSize]
+	return summaries, models.NewSummaryListCursor(&summaries[pageSize-1]), nil
+}
+
+// DeleteByID deletes summary if user is its owner. User managing access to any note summary is attached to
+// only detaches it from notes they manage, so summary stays available to its owner and other notes
+func (u *Usecase) DeleteByID(ID uuid.UUID, userID uuid.UUID) error {
+	if err := u.checkAccess(ID, userID, models.ManageAccessNoteAccess); err != nil {
+		return err
+	}
+
+	summ, err := u.repo.GetSummary(ID)
+	if err != nil {
+		return err
+	}
+
+	if summ.CreatorID != nil && *summ.CreatorID == userID {
+		return u.repo.DeleteByID(ID)
+	}
+
+	return u.repo.DetachFromManagedNotes(ID, userID)
+}
+
+func (u *Usecase) ListVersions(ID uuid.UUID, userID uuid.UUID) ([]*models.SummaryVersion, error) {
+	if err := u.checkAccess(ID, userID, models.ReadNoteAccess); err != nil {
+		return nil, err
+	}
+
+	return u.repo.ListVersions(ID)
+}
+
+func (u *Usecase) GetVersion(ID uuid.UUID, version int, userID uuid.UUID) (*models.SummaryVersion, error) {
+	if err := u.checkAccess(ID, userID, models.ReadNoteAccess); err != nil {
+		return nil, err
+	}
+
+	return u.repo.GetVersion(ID, version)
+}
+
+// DiffVersions returns line diff turning fromVersion's text into toVersion's one
+func (u *Usecase) DiffVersions(ID uuid.UUID, fromVersion, toVersion int, userID uuid.UUID) ([]utils.DiffLine, error) {
+	if err := u.checkAccess(ID, userID, models.ReadNoteAccess); err != nil {
+		return nil, err
+	}
+
+	from, err := u.repo.GetVersion(ID, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := u.repo.GetVersion(ID, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.DiffLines(from.Text, to.Text), nil
+}
+
+// ToNote requires read access to summary and modify access to dir, same as for creating dir in it.
+// Template is rendered before creating note, so invalid one leaves nothing behind
+func (u *Usecase) ToNote(ID uuid.UUID, dirID int, automergeURL, template string, userID uuid.UUID) (*models.Note, string, error) {
+	if err := u.checkAccess(ID, userID, models.ReadNoteAccess); err != nil {
+		return nil, "", err
+	}
+
+	dirAccess, err := u.dirsUsecase.GetUserAccess(dirID, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if dirAccess < models.ModifyNoteAccess {
+		return nil, "", summary.ErrForbidden
+	}
+
+	summ, err := u.repo.GetSummary(ID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !summ.Status.IsFinished() {
+		return nil, "", summary.ErrNotFinished
+	}
+
+	roleVersions, err := u.repo.ListLatestRoleVersions(ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	content, err := renderNoteContent(template, summ, roleVersions)
+	if err != nil {
+		return nil, "", err
+	}
+
+	note, err := u.notesUsecase.CreateFromSummary(ID, dirID, automergeURL, noteTitle(summ), userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return note, content, nil
+}
+
+// checkAccess relies on accesses ordering: e < r < w < m < ma
+func (u *Usecase) checkAccess(ID uuid.UUID, userID uuid.UUID, required models.NoteAccess) error {
+	access, err := u.repo.GetUserAccess(ID, userID)
+	if err != nil {
+		return err
+	}
+
+	if access < required {
+		return summary.ErrForbidden
+	}
+
+	return nil
+}
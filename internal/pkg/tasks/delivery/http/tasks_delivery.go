@@ -0,0 +1,311 @@
+package http
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-park-mail-ru/2023_1_Technokaif/pkg/logger"
+	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/http/auth"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/repository"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/summary"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/tasks"
+)
+
+type Handler struct {
+	tasksUsecase tasks.Usecase
+	logger       logger.Logger
+}
+
+func NewHandler(tu tasks.Usecase, l logger.Logger) *Handler {
+	return &Handler{
+		tasksUsecase: tu,
+		logger:       l,
+	}
+}
+
+// checkAccess returns access of request's user to task if he is allowed to call method on it
+func (h *Handler) checkAccess(c *gin.Context, taskID uuid.UUID, method methodName) *models.NoteAccess {
+	userID, err := auth.GetUserId(c)
+	if err != nil || userID == uuid.Nil {
+		h.logger.Infof("Unathorized request for task %s, method %s", taskID.String(), method)
+		c.JSON(http.StatusUnauthorized, "")
+		return nil
+	}
+
+	access, err := h.tasksUsecase.GetUserAccess(taskID, userID)
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		c.JSON(http.StatusNotFound, "Task not found")
+		return nil
+	}
+	if err != nil {
+		h.logger.Errorf("Error while check access for user with id %s: %w", userID.String(), err)
+		c.JSON(http.StatusInternalServerError, "Can't check access")
+		return nil
+	}
+
+	for _, a := range methodsAccessMap[method] {
+		if a == access {
+			return &access
+		}
+	}
+
+	h.logger.Infof("Access forbidden for user %s, task %s, method %s", userID.String(), taskID.String(), method)
+	c.JSON(http.StatusForbidden, "Forbidden")
+	return nil
+}
+
+func (h *Handler) respondError(c *gin.Context, err error) {
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) || errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, "Not found")
+		return
+	}
+	if errors.Is(err, tasks.ErrSourceForbidden) || errors.Is(err, summary.ErrForbidden) {
+		c.JSON(http.StatusForbidden, err.Error())
+		return
+	}
+
+	h.logger.Errorf("Error: %w", err)
+	c.JSON(http.StatusInternalServerError, err)
+}
+
+// Get
+// @Summary		Get task
+// @Tags		Tasks
+// @Description	Get task by ID
+// @Produce     json
+// @Param		taskID path string true 						"Task ID"
+// @Success		200			{object}	models.TaskTransfer		"Task"
+// @Failure		400			{object}	error					"Incorrect input"
+// @Failure		404			{object}	error					"Task not found"
+// @Failure		500			{object}	error					"Server error"
+// @Router		/api/tasks/{taskID} [get]
+func (h *Handler) Get(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid task id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	access := h.checkAccess(c, id, getMethodName)
+	if access == nil {
+		return
+	}
+
+	task, err := h.tasksUsecase.Get(id)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, task.ToTransfer(getAllowedMethods(*access)))
+}
+
+// ListMine
+// @Summary		List my open tasks
+// @Tags		Tasks
+// @Description	Get open and in progress tasks assigned to current user, the most urgent first
+// @Produce     json
+// @Success		200			{object}	ListTasksResponse	"Tasks"
+// @Failure		500			{object}	error				"Server error"
+// @Router		/api/tasks/mine [get]
+func (h *Handler) ListMine(c *gin.Context) {
+	userID, err := auth.GetUserId(c)
+	if err != nil || userID == uuid.Nil {
+		h.logger.Infof("Unathorized request for listing tasks")
+		c.JSON(http.StatusUnauthorized, "")
+		return
+	}
+
+	openTasks, err := h.tasksUsecase.ListOpenByAssignee(userID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	tasksTransfers := make([]*models.TaskTransfer, 0, len(openTasks))
+	for _, task := range openTasks {
+		access := models.NoteAccessFromString(*task.Access)
+		tasksTransfers = append(tasksTransfers, task.ToTransfer(getAllowedMethods(access)))
+	}
+
+	c.JSON(http.StatusOK, ListTasksResponse{Tasks: tasksTransfers})
+}
+
+// Create
+// @Summary		Create task
+// @Tags		Tasks
+// @Description	Create task, sources must be readable by current user
+// @Accept		json
+// @Produce     json
+// @Param		taskInfo	body		CreateTaskRequest		true	"Task info"
+// @Success		200			{object}	models.TaskTransfer		"Created task"
+// @Failure		400			{object}	error					"Incorrect input"
+// @Failure		403			{object}	error					"Source is forbidden"
+// @Failure		404			{object}	error					"Assignee or source not found"
+// @Failure		500			{object}	error					"Server error"
+// @Router		/api/tasks [post]
+func (h *Handler) Create(c *gin.Context) {
+	userID, err := auth.GetUserId(c)
+	if err != nil || userID == uuid.Nil {
+		h.logger.Infof("Unathorized request for creating task")
+		c.JSON(http.StatusUnauthorized, "")
+		return
+	}
+
+	var req CreateTaskRequest
+	c.BindJSON(&req)
+	if err := req.validate(); err != nil {
+		h.logger.Infof("Invalid create task request: %w", err)
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reqTask, err := req.ToTask()
+	if err != nil {
+		h.logger.Infof("Invalid create task request: %w", err)
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	createdTask, err := h.tasksUsecase.Create(*reqTask, userID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, createdTask.ToTransfer(getAllowedMethods(models.ManageAccessNoteAccess)))
+}
+
+// Update
+// @Summary		Update task
+// @Tags		Tasks
+// @Description	Update passed fields of task
+// @Accept		json
+// @Produce     json
+// @Param		taskID path string true 						"Task ID"
+// @Param		taskInfo	body		UpdateTaskRequest		true	"Task info"
+// @Success		200			{object}	models.TaskTransfer		"Updated task"
+// @Failure		400			{object}	error					"Incorrect input"
+// @Failure		404			{object}	error					"Task or assignee not found"
+// @Failure		500			{object}	error					"Server error"
+// @Router		/api/tasks/{taskID} [post]
+func (h *Handler) Update(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid task id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	access := h.checkAccess(c, id, updateMethodName)
+	if access == nil {
+		return
+	}
+
+	var req UpdateTaskRequest
+	c.BindJSON(&req)
+	if err := req.validate(); err != nil {
+		h.logger.Infof("Invalid update task request: %w", err)
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	task, err := h.tasksUsecase.Get(id)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	reqTask, err := req.Apply(*task)
+	if err != nil {
+		h.logger.Infof("Invalid update task request: %w", err)
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updatedTask, err := h.tasksUsecase.Update(*reqTask)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedTask.ToTransfer(getAllowedMethods(*access)))
+}
+
+// Delete
+// @Summary		Delete task
+// @Tags		Tasks
+// @Description	Delete task by ID
+// @Param		taskID path string true 						"Task ID"
+// @Success		200			"Task deleted"
+// @Failure		400			{object}	error					"Incorrect input"
+// @Failure		404			{object}	error					"Task not found"
+// @Failure		500			{object}	error					"Server error"
+// @Router		/api/tasks/{taskID} [delete]
+func (h *Handler) Delete(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid task id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if access := h.checkAccess(c, id, deleteMethodName); access == nil {
+		return
+	}
+
+	if err := h.tasksUsecase.Delete(id); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// ExtractFromSummary
+// @Summary		Propose tasks from summary
+// @Tags		Tasks
+// @Description	Find checklist items and to-do lines in summary text, proposals aren't saved
+// @Produce     json
+// @Param		summID path string true 							"Summary ID"
+// @Success		200			{object}	ListTaskProposalsResponse	"Proposals"
+// @Failure		400			{object}	error						"Incorrect input"
+// @Failure		403			{object}	error						"Summary is forbidden"
+// @Failure		404			{object}	error						"Summary not found"
+// @Failure		500			{object}	error						"Server error"
+// @Router		/api/tasks/proposals/{summID} [get]
+func (h *Handler) ExtractFromSummary(c *gin.Context) {
+	summID, err := uuid.FromString(c.Param("summID"))
+	if err != nil {
+		h.logger.Infof("Invalid summary id '%s'", c.Param("summID"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	userID, err := auth.GetUserId(c)
+	if err != nil || userID == uuid.Nil {
+		h.logger.Infof("Unathorized request for proposing tasks from summary %s", summID.String())
+		c.JSON(http.StatusUnauthorized, "")
+		return
+	}
+
+	proposals, err := h.tasksUsecase.ExtractFromSummary(summID, userID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	proposalsTransfers := make([]*models.TaskProposalTransfer, 0, len(proposals))
+	for _, proposal := range proposals {
+		proposalsTransfers = append(proposalsTransfers, proposal.ToTransfer())
+	}
+
+	c.JSON(http.StatusOK, ListTaskProposalsResponse{Proposals: proposalsTransfers})
+}
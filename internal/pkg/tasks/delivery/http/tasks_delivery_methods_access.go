@@ -0,0 +1,46 @@
+package http
+
+import "github.com/yarikTri/archipelago-notes-api/internal/models"
+
+type methodName uint8
+
+const (
+	getMethodName methodName = iota
+	updateMethodName
+	deleteMethodName
+)
+
+func (mn *methodName) String() string {
+	switch *mn {
+	case getMethodName:
+		return "get"
+	case updateMethodName:
+		return "update"
+	case deleteMethodName:
+		return "delete"
+	}
+
+	return ""
+}
+
+// Access to task is resolved from its creator, assignee and sources, see task_user_access
+var methodsAccessMap = map[methodName][]models.NoteAccess{
+	getMethodName:    {models.ReadNoteAccess, models.WriteNoteAccess, models.ModifyNoteAccess, models.ManageAccessNoteAccess},
+	updateMethodName: {models.WriteNoteAccess, models.ModifyNoteAccess, models.ManageAccessNoteAccess},
+	deleteMethodName: {models.ModifyNoteAccess, models.ManageAccessNoteAccess},
+}
+
+func getAllowedMethods(access models.NoteAccess) []string {
+	allowedMethods := make([]string, 0)
+
+	for method, accesses := range methodsAccessMap {
+		for _, a := range accesses {
+			if a == access {
+				allowedMethods = append(allowedMethods, method.String())
+				break
+			}
+		}
+	}
+
+	return allowedMethods
+}
@@ -0,0 +1,147 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	valid "github.com/asaskevich/govalidator"
+	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
+
+type CreateTaskRequest struct {
+	Title       string `json:"title" valid:"required"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	AssigneeID  string `json:"assignee_id"`
+	DueDate     string `json:"due_date"`
+	SummaryID   string `json:"summ_id"`
+	NoteID      string `json:"note_id"`
+}
+
+func (ctr *CreateTaskRequest) validate() error {
+	if ctr.Status != "" && !models.TaskStatus(ctr.Status).IsValid() {
+		return errors.New(fmt.Sprintf("Invalid task status: %s", ctr.Status))
+	}
+
+	_, err := valid.ValidateStruct(ctr)
+	return err
+}
+
+func (ctr *CreateTaskRequest) ToTask() (*models.Task, error) {
+	task := models.Task{
+		Title:       ctr.Title,
+		Description: ctr.Description,
+		Status:      models.OpenTaskStatus,
+	}
+	if ctr.Status != "" {
+		task.Status = models.TaskStatus(ctr.Status)
+	}
+
+	var err error
+	if task.Assignee, err = parseAssignee(ctr.AssigneeID); err != nil {
+		return nil, err
+	}
+	if task.DueDate, err = parseDueDate(ctr.DueDate); err != nil {
+		return nil, err
+	}
+	if task.SummaryID, err = parseOptionalUUID(ctr.SummaryID); err != nil {
+		return nil, err
+	}
+	if task.NoteID, err = parseOptionalUUID(ctr.NoteID); err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+// UpdateTaskRequest changes only passed fields, empty assignee_id or due_date clears them
+type UpdateTaskRequest struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	Status      *string `json:"status"`
+	AssigneeID  *string `json:"assignee_id"`
+	DueDate     *string `json:"due_date"`
+}
+
+func (utr *UpdateTaskRequest) validate() error {
+	if utr.Title != nil && *utr.Title == "" {
+		return errors.New("Title can't be empty")
+	}
+	if utr.Status != nil && !models.TaskStatus(*utr.Status).IsValid() {
+		return errors.New(fmt.Sprintf("Invalid task status: %s", *utr.Status))
+	}
+
+	return nil
+}
+
+// Apply returns task with request's changes
+func (utr *UpdateTaskRequest) Apply(task models.Task) (*models.Task, error) {
+	if utr.Title != nil {
+		task.Title = *utr.Title
+	}
+	if utr.Description != nil {
+		task.Description = *utr.Description
+	}
+	if utr.Status != nil {
+		task.Status = models.TaskStatus(*utr.Status)
+	}
+
+	var err error
+	if utr.AssigneeID != nil {
+		if task.Assignee, err = parseAssignee(*utr.AssigneeID); err != nil {
+			return nil, err
+		}
+	}
+	if utr.DueDate != nil {
+		if task.DueDate, err = parseDueDate(*utr.DueDate); err != nil {
+			return nil, err
+		}
+	}
+
+	return &task, nil
+}
+
+type ListTasksResponse struct {
+	Tasks []*models.TaskTransfer `json:"tasks"`
+}
+
+type ListTaskProposalsResponse struct {
+	Proposals []*models.TaskProposalTransfer `json:"proposals"`
+}
+
+func parseOptionalUUID(id string) (*uuid.UUID, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	parsed, err := uuid.FromString(id)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid id: %s", id))
+	}
+
+	return &parsed, nil
+}
+
+func parseAssignee(assigneeID string) (*models.User, error) {
+	id, err := parseOptionalUUID(assigneeID)
+	if err != nil || id == nil {
+		return nil, err
+	}
+
+	return &models.User{ID: *id}, nil
+}
+
+func parseDueDate(dueDate string) (*time.Time, error) {
+	if dueDate == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse(models.TaskDueDateLayout, dueDate)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid due date: %s", dueDate))
+	}
+
+	return &date, nil
+}
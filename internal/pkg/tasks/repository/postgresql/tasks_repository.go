@@ -0,0 +1,218 @@
+package postgresql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/repository"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
+
+// PostgreSQL implements tasks.Repository
+type PostgreSQL struct {
+	db *sqlx.DB
+}
+
+func NewPostgreSQL(db *sqlx.DB) *PostgreSQL {
+	return &PostgreSQL{
+		db: db,
+	}
+}
+
+// taskRow is task joined with its assignee
+type taskRow struct {
+	ID            uuid.UUID  `db:"id"`
+	Title         string     `db:"title"`
+	Description   string     `db:"description"`
+	Status        string     `db:"status"`
+	AssigneeID    *uuid.UUID `db:"assignee_id"`
+	AssigneeEmail *string    `db:"assignee_email"`
+	AssigneeName  *string    `db:"assignee_name"`
+	DueDate       *time.Time `db:"due_date"`
+	SummaryID     *uuid.UUID `db:"summ_id"`
+	NoteID        *uuid.UUID `db:"note_id"`
+	CreatorID     *uuid.UUID `db:"creator_id"`
+	CreatedAt     time.Time  `db:"created_at"`
+	UpdatedAt     time.Time  `db:"updated_at"`
+	Access        *string    `db:"access"`
+}
+
+func (r *taskRow) toTask() *models.Task {
+	var assignee *models.User
+	if r.AssigneeID != nil {
+		assignee = &models.User{ID: *r.AssigneeID}
+		if r.AssigneeEmail != nil {
+			assignee.Email = *r.AssigneeEmail
+		}
+		if r.AssigneeName != nil {
+			assignee.Name = *r.AssigneeName
+		}
+	}
+
+	return &models.Task{
+		ID:          r.ID,
+		Title:       r.Title,
+		Description: r.Description,
+		Status:      models.TaskStatus(r.Status),
+		Assignee:    assignee,
+		DueDate:     r.DueDate,
+		SummaryID:   r.SummaryID,
+		NoteID:      r.NoteID,
+		CreatorID:   r.CreatorID,
+		CreatedAt:   r.CreatedAt,
+		UpdatedAt:   r.UpdatedAt,
+		Access:      r.Access,
+	}
+}
+
+const selectTaskQuery = `SELECT t.id, t.title, t.description, t.status, t.assignee_id, u.email AS assignee_email,
+		u.name AS assignee_name, t.due_date, t.summ_id, t.note_id, t.creator_id, t.created_at, t.updated_at
+	FROM task t
+		LEFT JOIN "user" u ON t.assignee_id = u.id`
+
+func (p *PostgreSQL) GetByID(taskID uuid.UUID) (*models.Task, error) {
+	query := fmt.Sprint(
+		selectTaskQuery,
+		` WHERE t.id = $1`,
+	)
+
+	var row taskRow
+	if err := p.db.Get(&row, query, taskID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: taskID}, err)
+		}
+
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return row.toTask(), nil
+}
+
+func (p *PostgreSQL) Create(task models.Task) (*models.Task, error) {
+	query := fmt.Sprint(
+		`INSERT INTO task (title, description, status, assignee_id, due_date, summ_id, note_id, creator_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id`,
+	)
+
+	var taskID uuid.UUID
+	if err := p.db.Get(&taskID, query, task.Title, task.Description, task.Status, assigneeID(task),
+		task.DueDate, task.SummaryID, task.NoteID, task.CreatorID); err != nil {
+
+		return nil, wrapWriteError(err)
+	}
+
+	return p.GetByID(taskID)
+}
+
+// Update changes everything except task's sources and creator
+func (p *PostgreSQL) Update(task models.Task) (*models.Task, error) {
+	query := fmt.Sprint(
+		`UPDATE task
+			SET title = $2, description = $3, status = $4, assignee_id = $5, due_date = $6, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1`,
+	)
+
+	res, err := p.db.Exec(query, task.ID, task.Title, task.Description, task.Status, assigneeID(task), task.DueDate)
+	if err != nil {
+		return nil, wrapWriteError(err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("(repo) failed to get affected rows: %w", err)
+	}
+	if updated == 0 {
+		return nil, fmt.Errorf("(repo) %w", &repository.NotFoundError{ID: task.ID})
+	}
+
+	return p.GetByID(task.ID)
+}
+
+func (p *PostgreSQL) DeleteByID(taskID uuid.UUID) error {
+	query := fmt.Sprint(
+		`DELETE FROM task WHERE id = $1`,
+	)
+
+	res, err := p.db.Exec(query, taskID)
+	if err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to get affected rows: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("(repo) %w", &repository.NotFoundError{ID: taskID})
+	}
+
+	return nil
+}
+
+// ListOpenByAssignee returns open and in progress tasks with assignee's access to them, the most urgent first
+func (p *PostgreSQL) ListOpenByAssignee(assigneeID uuid.UUID) ([]*models.Task, error) {
+	query := fmt.Sprint(
+		`SELECT tasks.*, task_user_access(tasks.id, $1) AS access
+		FROM (`,
+		selectTaskQuery,
+		` WHERE t.assignee_id = $1 AND t.status IN ('open', 'in_progress')
+		) tasks
+		ORDER BY tasks.due_date NULLS LAST, tasks.created_at`,
+	)
+
+	var rows []taskRow
+	if err := p.db.Select(&rows, query, assigneeID); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	tasks := make([]*models.Task, 0, len(rows))
+	for i := range rows {
+		tasks = append(tasks, rows[i].toTask())
+	}
+
+	return tasks, nil
+}
+
+// GetUserAccess resolves user's access to task through its creator, assignee and sources, see task_user_access
+func (p *PostgreSQL) GetUserAccess(taskID uuid.UUID, userID uuid.UUID) (models.NoteAccess, error) {
+	query := fmt.Sprint(
+		`SELECT task_user_access(id, $2)
+			FROM task
+			WHERE id = $1`,
+	)
+
+	var access string
+	if err := p.db.Get(&access, query, taskID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.UndefinedNoteAccess, fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: taskID}, err)
+		}
+
+		return models.UndefinedNoteAccess, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return models.NoteAccessFromString(access), nil
+}
+
+func assigneeID(task models.Task) *uuid.UUID {
+	if task.Assignee == nil {
+		return nil
+	}
+
+	return &task.Assignee.ID
+}
+
+// wrapWriteError treats unknown assignee, summary or note as not found
+func wrapWriteError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == repository.ForeignKeyViolationCode {
+		return fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: pqErr.Constraint}, err)
+	}
+
+	return fmt.Errorf("(repo) failed to exec query: %w", err)
+}
@@ -0,0 +1,36 @@
+package tasks
+
+import (
+	"errors"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
+
+// ErrSourceForbidden is returned on attempt to link task to note or summary user can't read
+var ErrSourceForbidden = errors.New("access to task's source forbidden")
+
+type Usecase interface {
+	Get(taskID uuid.UUID) (*models.Task, error)
+	Create(task models.Task, creatorID uuid.UUID) (*models.Task, error)
+	Update(task models.Task) (*models.Task, error)
+	Delete(taskID uuid.UUID) error
+	// ListOpenByAssignee sets assignee's access to listed tasks
+	ListOpenByAssignee(assigneeID uuid.UUID) ([]*models.Task, error)
+
+	GetUserAccess(taskID uuid.UUID, userID uuid.UUID) (models.NoteAccess, error)
+
+	// ExtractFromSummary proposes tasks found in summary text
+	ExtractFromSummary(summID uuid.UUID, userID uuid.UUID) ([]*models.TaskProposal, error)
+}
+
+type Repository interface {
+	GetByID(taskID uuid.UUID) (*models.Task, error)
+	Create(task models.Task) (*models.Task, error)
+	Update(task models.Task) (*models.Task, error)
+	DeleteByID(taskID uuid.UUID) error
+	// ListOpenByAssignee sets assignee's access to listed tasks
+	ListOpenByAssignee(assigneeID uuid.UUID) ([]*models.Task, error)
+
+	GetUserAccess(taskID uuid.UUID, userID uuid.UUID) (models.NoteAccess, error)
+}
@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
+
+// taskTitleMaxLength is limited by task.title column
+const taskTitleMaxLength = 256
+
+var (
+	// Unchecked checklist item: "- [ ] text", "* [ ] text", "1. [ ] text", "[ ] text"
+	checklistItemRegexp = regexp.MustCompile(`^(?:[-*+•]|\d+[.)])?\s*\[\s\]\s*(.+)$`)
+	// Line marked as to-do: "TODO: text", "- Сделать: text", "сделать text"
+	todoLineRegexp = regexp.MustCompile(`(?i)^(?:[-*+•]|\d+[.)])?\s*(?:todo|сделать)(?:\s*[:\-—]\s*|\s+)(.+)$`)
+	// Due date in task: "до 15.03.2024", "к 15.03", "deadline 2024-03-15".
+	// Month is two-digit, so numbers like "1.2" or "10.5" aren't taken for dates,
+	// and date isn't matched inside longer numbers like "1.15.03" or "15.03.20245"
+	dueDateRegexp = regexp.MustCompile(`(?:^|[^\d.])(\d{1,2}\.\d{2}(?:\.\d{4})?|\d{4}-\d{2}-\d{2})(?:$|[^\d.]|\.(?:$|\D))`)
+)
+
+// dueDateSearchYears limits search of the nearest 29.02 without year
+const dueDateSearchYears = 8
+
+// extractTasks proposes tasks from checklist items and to-do lines of text
+func extractTasks(text string, now time.Time) []*models.TaskProposal {
+	proposals := make([]*models.TaskProposal, 0)
+
+	for i, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+
+		var title string
+		if match := checklistItemRegexp.FindStringSubmatch(line); match != nil {
+			title = match[1]
+		} else if match := todoLineRegexp.FindStringSubmatch(line); match != nil {
+			title = match[1]
+		}
+
+		title = strings.TrimSpace(title)
+		if title == "" {
+			continue
+		}
+		if runes := []rune(title); len(runes) > taskTitleMaxLength {
+			title = string(runes[:taskTitleMaxLength])
+		}
+
+		proposals = append(proposals, &models.TaskProposal{
+			Title:   title,
+			DueDate: parseDueDate(title, now),
+			Line:    i + 1,
+		})
+	}
+
+	return proposals
+}
+
+// parseDueDate finds the first date in text, date without year is the nearest one not in the past.
+// Date with day or month out of range isn't parsed
+func parseDueDate(text string, now time.Time) *time.Time {
+	match := dueDateRegexp.FindStringSubmatch(text)
+	if match == nil {
+		return nil
+	}
+
+	for _, layout := range []string{"2006-01-02", "2.01.2006"} {
+		if date, err := time.ParseInLocation(layout, match[1], now.Location()); err == nil {
+			return &date
+		}
+	}
+
+	dayStr, monthStr, found := strings.Cut(match[1], ".")
+	if !found || strings.Contains(monthStr, ".") {
+		return nil
+	}
+	day, err := strconv.Atoi(dayStr)
+	if err != nil {
+		return nil
+	}
+	month, err := strconv.Atoi(monthStr)
+	if err != nil {
+		return nil
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for year := now.Year(); year <= now.Year()+dueDateSearchYears; year++ {
+		date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, now.Location())
+		// time.Date normalizes out of range values, e.g. 31.04 to 01.05
+		if date.Day() != day || int(date.Month()) != month {
+			continue
+		}
+		if !date.Before(today) {
+			return &date
+		}
+	}
+
+	return nil
+}
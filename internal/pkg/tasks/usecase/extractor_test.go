@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDueDate(t *testing.T) {
+	now := time.Date(2025, time.March, 10, 15, 30, 0, 0, time.UTC)
+	date := func(year int, month time.Month, day int) *time.Time {
+		d := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want *time.Time
+	}{
+		{name: "no date", text: "подготовить отчёт", want: nil},
+		{name: "day and month ahead", text: "отчёт до 15.03", want: date(2025, time.March, 15)},
+		{name: "day and month today", text: "отчёт к 10.03", want: date(2025, time.March, 10)},
+		{name: "day and month passed", text: "отчёт к 01.03", want: date(2026, time.March, 1)},
+		{name: "one digit day", text: "отчёт до 5.04", want: date(2025, time.April, 5)},
+		{name: "full date", text: "отчёт до 15.03.2026", want: date(2026, time.March, 15)},
+		{name: "full date in the past", text: "отчёт до 15.03.2024", want: date(2024, time.March, 15)},
+		{name: "iso date", text: "deadline 2025-04-01", want: date(2025, time.April, 1)},
+		{name: "date at the end of sentence", text: "сдать до 20.03.", want: date(2025, time.March, 20)},
+		{name: "the first date is taken", text: "с 20.03 по 25.03", want: date(2025, time.March, 20)},
+		{name: "29.02 without year", text: "отчёт до 29.02", want: date(2028, time.February, 29)},
+		{name: "29.02 of non-leap year", text: "отчёт до 29.02.2025", want: nil},
+		{name: "29.02 of leap year", text: "отчёт до 29.02.2028", want: date(2028, time.February, 29)},
+		{name: "day out of month", text: "отчёт до 31.04", want: nil},
+		{name: "day out of range", text: "отчёт до 32.01", want: nil},
+		{name: "month out of range", text: "отчёт до 15.13", want: nil},
+		{name: "one digit month", text: "версия 1.2", want: nil},
+		{name: "version number", text: "обновить до 1.15.03", want: nil},
+		{name: "long year", text: "отчёт до 15.03.20245", want: nil},
+		{name: "invalid iso date", text: "deadline 2025-02-30", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseDueDate(tt.text, now)
+			switch {
+			case got == nil && tt.want == nil:
+			case got == nil || tt.want == nil:
+				t.Errorf("parseDueDate(%q) = %v, want %v", tt.text, got, tt.want)
+			case !got.Equal(*tt.want):
+				t.Errorf("parseDueDate(%q) = %s, want %s", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractTasks(t *testing.T) {
+	now := time.Date(2025, time.March, 10, 15, 30, 0, 0, time.UTC)
+	text := "Итоги встречи\r\n" +
+		"- [ ] Подготовить отчёт до 15.03\n" +
+		"- [x] Созвониться с клиентом\n" +
+		"1. [ ] Обновить презентацию\n" +
+		"TODO: проверить бюджет\n" +
+		"TODO—разослать протокол\n" +
+		"todos на следующую неделю\n" +
+		"Сделать демо к 01.04\n" +
+		"- [ ]   \n"
+
+	type proposal struct {
+		title string
+		line  int
+		due   string
+	}
+	want := []proposal{
+		{title: "Подготовить отчёт до 15.03", line: 2, due: "2025-03-15"},
+		{title: "Обновить презентацию", line: 4},
+		{title: "проверить бюджет", line: 5},
+		{title: "разослать протокол", line: 6},
+		{title: "демо к 01.04", line: 8, due: "2025-04-01"},
+	}
+
+	got := extractTasks(text, now)
+	if len(got) != len(want) {
+		t.Fatalf("extractTasks returned %d proposals, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		g := got[i]
+		due := ""
+		if g.DueDate != nil {
+			due = g.DueDate.Format("2006-01-02")
+		}
+		if g.Title != w.title || g.Line != w.line || due != w.due {
+			t.Errorf("proposal %d = {%q, line %d, due %q}, want {%q, line %d, due %q}",
+				i, g.Title, g.Line, due, w.title, w.line, w.due)
+		}
+	}
+}
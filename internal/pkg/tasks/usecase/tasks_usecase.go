@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/notes"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/summary"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/tasks"
+)
+
+// Usecase implements tasks.Usecase
+type Usecase struct {
+	repo           tasks.Repository
+	notesUsecase   notes.Usecase
+	summaryUsecase summary.Usecase
+}
+
+func NewUsecase(tr tasks.Repository, nu notes.Usecase, su summary.Usecase) *Usecase {
+	return &Usecase{
+		repo:           tr,
+		notesUsecase:   nu,
+		summaryUsecase: su,
+	}
+}
+
+func (u *Usecase) Get(taskID uuid.UUID) (*models.Task, error) {
+	return u.repo.GetByID(taskID)
+}
+
+// Create links task only to sources creator can read,
+// otherwise task would give access to them
+func (u *Usecase) Create(task models.Task, creatorID uuid.UUID) (*models.Task, error) {
+	if !task.Status.IsValid() {
+		return nil, errors.New(fmt.Sprintf("(usecase) Invalid task status %s", task.Status))
+	}
+
+	if task.NoteID != nil {
+		access, err := u.notesUsecase.GetUserAccess(*task.NoteID, creatorID)
+		if err != nil {
+			return nil, err
+		}
+		if access < models.ReadNoteAccess {
+			return nil, tasks.ErrSourceForbidden
+		}
+	}
+
+	if task.SummaryID != nil {
+		if _, err := u.summaryUsecase.GetSummary(*task.SummaryID, creatorID); err != nil {
+			if errors.Is(err, summary.ErrForbidden) {
+				return nil, tasks.ErrSourceForbidden
+			}
+
+			return nil, err
+		}
+	}
+
+	task.CreatorID = &creatorID
+	return u.repo.Create(task)
+}
+
+func (u *Usecase) Update(task models.Task) (*models.Task, error) {
+	if !task.Status.IsValid() {
+		return nil, errors.New(fmt.Sprintf("(usecase) Invalid task status %s", task.Status))
+	}
+
+	return u.repo.Update(task)
+}
+
+func (u *Usecase) Delete(taskID uuid.UUID) error {
+	return u.repo.DeleteByID(taskID)
+}
+
+func (u *Usecase) ListOpenByAssignee(assigneeID uuid.UUID) ([]*models.Task, error) {
+	return u.repo.ListOpenByAssignee(assigneeID)
+}
+
+func (u *Usecase) GetUserAccess(taskID uuid.UUID, userID uuid.UUID) (models.NoteAccess, error) {
+	return u.repo.GetUserAccess(taskID, userID)
+}
+
+func (u *Usecase) ExtractFromSummary(summID uuid.UUID, userID uuid.UUID) ([]*models.TaskProposal, error) {
+	summ, err := u.summaryUsecase.GetSummary(summID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return extractTasks(summ.Text, time.Now()), nil
+}
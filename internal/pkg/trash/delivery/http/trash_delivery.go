@@ -0,0 +1,243 @@
+package http
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-park-mail-ru/2023_1_Technokaif/pkg/logger"
+	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/http/auth"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/repository"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/trash"
+)
+
+type Handler struct {
+	trashUsecase trash.Usecase
+	logger       logger.Logger
+}
+
+func NewHandler(tu trash.Usecase, l logger.Logger) *Handler {
+	return &Handler{
+		trashUsecase: tu,
+		logger:       l,
+	}
+}
+
+// checkAccess checks access to item in trash, getAccess resolves user's access to it.
+// Returns id of allowed user
+func (h *Handler) checkAccess(c *gin.Context, itemID string, method methodName,
+	getAccess func(userID uuid.UUID) (models.NoteAccess, error)) (uuid.UUID, bool) {
+
+	userID, err := auth.GetUserId(c)
+	if err != nil {
+		h.logger.Infof("Unathorized request for trash item %s, method %s", itemID, method)
+		c.JSON(http.StatusUnauthorized, "")
+		return uuid.Nil, false
+	}
+
+	access, err := getAccess(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, "Item not found in trash")
+		return uuid.Nil, false
+	}
+	if err != nil {
+		h.logger.Errorf("Error while check access for user with id %s: %w", userID.String(), err)
+		c.JSON(http.StatusInternalServerError, "Can't check access")
+		return uuid.Nil, false
+	}
+
+	if !isAllowed(method, access) {
+		h.logger.Infof("Access forbidden for user %s, trash item %s, method %s", userID.String(), itemID, method)
+		c.JSON(http.StatusForbidden, "Forbidden")
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
+
+func (h *Handler) respondError(c *gin.Context, err error) {
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		c.JSON(http.StatusNotFound, "Item not found in trash")
+		return
+	}
+	if errors.Is(err, trash.ErrAncestorForbidden) {
+		c.JSON(http.StatusForbidden, "Forbidden to restore deleted parent dir")
+		return
+	}
+
+	h.logger.Errorf("Error: %w", err)
+	c.JSON(http.StatusInternalServerError, err)
+}
+
+// List
+// @Summary		List trash
+// @Tags		Trash
+// @Description	Get notes and dirs in trash user is allowed to restore
+// @Produce     json
+// @Success		200			{object}	ListTrashResponse	"Deleted notes and dirs"
+// @Failure		500			{object}	error				"Server error"
+// @Router		/api/trash [get]
+func (h *Handler) List(c *gin.Context) {
+	userID, err := auth.GetUserId(c)
+	if err != nil {
+		h.logger.Infof("Unathorized request for listing trash")
+		c.JSON(http.StatusUnauthorized, "")
+		return
+	}
+
+	notes, dirs, err := h.trashUsecase.List(userID)
+	if err != nil {
+		h.logger.Errorf("Error while listing trash: %w", err)
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	notesTransfers := make([]*models.NoteTransfer, 0, len(notes))
+	for _, note := range notes {
+		access := models.NoteAccessFromString(*note.Access)
+		notesTransfers = append(notesTransfers, note.ToTransfer(getAllowedMethods(access)))
+	}
+
+	if dirs == nil {
+		dirs = make([]*models.Dir, 0)
+	}
+
+	c.JSON(http.StatusOK, ListTrashResponse{Notes: notesTransfers, Dirs: dirs})
+}
+
+// RestoreNote
+// @Summary		Restore note
+// @Tags		Trash
+// @Description	Restore note from trash to its original dir, deleted parent dirs are restored too
+// @Param		noteID path string true 		"Note ID"
+// @Success		200								"Note restored"
+// @Failure		400			{object}	error	"Incorrect input"
+// @Failure		403			{object}	error	"Deleted parent dir can't be restored by user"
+// @Failure		500			{object}	error	"Server error"
+// @Router		/api/trash/notes/{noteID}/restore [post]
+func (h *Handler) RestoreNote(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid note id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	getAccess := func(userID uuid.UUID) (models.NoteAccess, error) {
+		return h.trashUsecase.GetNoteUserAccess(id, userID)
+	}
+	userID, ok := h.checkAccess(c, id.String(), restoreMethodName, getAccess)
+	if !ok {
+		return
+	}
+
+	if err := h.trashUsecase.RestoreNote(id, userID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// RestoreDir
+// @Summary		Restore dir
+// @Tags		Trash
+// @Description	Restore dir with subtree deleted together with it to its original path
+// @Param		dirID path int true 			"Dir ID"
+// @Success		200								"Dir restored"
+// @Failure		400			{object}	error	"Incorrect input"
+// @Failure		403			{object}	error	"Deleted parent dir can't be restored by user"
+// @Failure		500			{object}	error	"Server error"
+// @Router		/api/trash/dirs/{dirID}/restore [post]
+func (h *Handler) RestoreDir(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid dir id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	getAccess := func(userID uuid.UUID) (models.NoteAccess, error) {
+		return h.trashUsecase.GetDirUserAccess(id, userID)
+	}
+	userID, ok := h.checkAccess(c, c.Param("id"), restoreMethodName, getAccess)
+	if !ok {
+		return
+	}
+
+	if err := h.trashUsecase.RestoreDir(id, userID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// PurgeNote
+// @Summary		Purge note
+// @Tags		Trash
+// @Description	Permanently delete note from trash
+// @Param		noteID path string true 		"Note ID"
+// @Success		200								"Note purged"
+// @Failure		400			{object}	error	"Incorrect input"
+// @Failure		500			{object}	error	"Server error"
+// @Router		/api/trash/notes/{noteID} [delete]
+func (h *Handler) PurgeNote(c *gin.Context) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid note id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	getAccess := func(userID uuid.UUID) (models.NoteAccess, error) {
+		return h.trashUsecase.GetNoteUserAccess(id, userID)
+	}
+	if _, ok := h.checkAccess(c, id.String(), purgeMethodName, getAccess); !ok {
+		return
+	}
+
+	if err := h.trashUsecase.PurgeNote(id); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// PurgeDir
+// @Summary		Purge dir
+// @Tags		Trash
+// @Description	Permanently delete dir with its subtree and notes from trash
+// @Param		dirID path int true 			"Dir ID"
+// @Success		200								"Dir purged"
+// @Failure		400			{object}	error	"Incorrect input"
+// @Failure		500			{object}	error	"Server error"
+// @Router		/api/trash/dirs/{dirID} [delete]
+func (h *Handler) PurgeDir(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Infof("Invalid dir id '%s'", c.Param("id"))
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	getAccess := func(userID uuid.UUID) (models.NoteAccess, error) {
+		return h.trashUsecase.GetDirUserAccess(id, userID)
+	}
+	if _, ok := h.checkAccess(c, c.Param("id"), purgeMethodName, getAccess); !ok {
+		return
+	}
+
+	if err := h.trashUsecase.PurgeDir(id); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,51 @@
+package http
+
+import "github.com/yarikTri/archipelago-notes-api/internal/models"
+
+type methodName uint8
+
+const (
+	restoreMethodName methodName = iota
+	purgeMethodName
+)
+
+func (mn *methodName) String() string {
+	switch *mn {
+	case restoreMethodName:
+		return "restore"
+	case purgeMethodName:
+		return "purge"
+	}
+
+	return ""
+}
+
+var methodsAccessMap = map[methodName][]models.NoteAccess{
+	restoreMethodName: {models.ModifyNoteAccess, models.ManageAccessNoteAccess},
+	purgeMethodName:   {models.ModifyNoteAccess, models.ManageAccessNoteAccess},
+}
+
+func getAllowedMethods(access models.NoteAccess) []string {
+	allowedMethods := make([]string, 0)
+
+	for method, accesses := range methodsAccessMap {
+		for _, a := range accesses {
+			if a == access {
+				allowedMethods = append(allowedMethods, method.String())
+				break
+			}
+		}
+	}
+
+	return allowedMethods
+}
+
+func isAllowed(method methodName, access models.NoteAccess) bool {
+	for _, a := range methodsAccessMap[method] {
+		if a == access {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,8 @@
+package http
+
+import "github.com/yarikTri/archipelago-notes-api/internal/models"
+
+type ListTrashResponse struct {
+	Notes []*models.NoteTransfer `json:"notes"`
+	Dirs  []*models.Dir          `json:"dirs"`
+}
@@ -0,0 +1,404 @@
+package postgresql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/repository"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/trash"
+)
+
+// PostgreSQL implements trash.Repository
+type PostgreSQL struct {
+	db *sqlx.DB
+}
+
+func NewPostgreSQL(db *sqlx.DB) *PostgreSQL {
+	return &PostgreSQL{
+		db: db,
+	}
+}
+
+// ListNotes returns notes deleted on their own (not together with their dir),
+// which user is allowed to restore
+func (p *PostgreSQL) ListNotes(userID uuid.UUID) ([]*models.Note, error) {
+	query := fmt.Sprint(
+		`SELECT id, dir_id, automerge_url, title, creator_id, default_access, access, created_at, updated_at, deleted_at, deleted_by
+			FROM (
+				SELECT
+					n.id as id,
+					n.dir_id as dir_id,
+					n.automerge_url as automerge_url,
+					n.title as title,
+					n.creator_id as creator_id,
+					n.default_access as default_access,
+					note_user_access(n.id, $1) as access,
+					n.created_at as created_at,
+					n.updated_at as updated_at,
+					n.deleted_at as deleted_at,
+					n.deleted_by as deleted_by
+				FROM note n
+					INNER JOIN dir d ON n.dir_id = d.id
+				WHERE n.deleted_at IS NOT NULL AND (d.deleted_at IS NULL OR d.deleted_at <> n.deleted_at)
+			) deleted
+			WHERE access IN ('m', 'ma')
+			ORDER BY deleted_at DESC`,
+	)
+
+	var notes []*models.Note
+	if err := p.db.Select(&notes, query, userID.String()); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return notes, nil
+}
+
+// ListDirs returns dirs deleted on their own (not together with their parent),
+// which user is allowed to restore
+func (p *PostgreSQL) ListDirs(userID uuid.UUID) ([]*models.Dir, error) {
+	query := fmt.Sprint(
+		`SELECT d.id, d.name, SUBPATH(d.path, 0, -1) as subpath, d.creator_id, d.default_access, d.deleted_at, d.deleted_by
+			FROM dir d
+				LEFT JOIN dir pd ON pd.path = SUBPATH(d.path, 0, -1)
+			WHERE d.deleted_at IS NOT NULL
+				AND (pd.deleted_at IS NULL OR pd.deleted_at <> d.deleted_at)
+				AND dir_user_access(d.id, $1) IN ('m', 'ma')
+			ORDER BY d.deleted_at DESC`,
+	)
+
+	var dirs []*models.Dir
+	if err := p.db.Select(&dirs, query, userID.String()); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return dirs, nil
+}
+
+func (p *PostgreSQL) GetNoteUserAccess(noteID uuid.UUID, userID uuid.UUID) (models.NoteAccess, error) {
+	query := fmt.Sprint(
+		`SELECT note_user_access(id, $2)
+			FROM note
+			WHERE id = $1 AND deleted_at IS NOT NULL`,
+	)
+
+	var access string
+	if err := p.db.Get(&access, query, noteID.String(), userID.String()); err != nil {
+		return models.UndefinedNoteAccess, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return models.NoteAccessFromString(access), nil
+}
+
+func (p *PostgreSQL) GetDirUserAccess(dirID int, userID uuid.UUID) (models.NoteAccess, error) {
+	query := fmt.Sprint(
+		`SELECT dir_user_access(id, $2)
+			FROM dir
+			WHERE id = $1 AND deleted_at IS NOT NULL`,
+	)
+
+	var access string
+	if err := p.db.Get(&access, query, dirID, userID.String()); err != nil {
+		return models.UndefinedNoteAccess, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return models.NoteAccessFromString(access), nil
+}
+
+// restoreAncestorDirs restores deleted dirs on the path to dir, so restored item gets back to its original place.
+// User must be allowed to restore each of them, otherwise trash.ErrAncestorForbidden is returned
+func restoreAncestorDirs(tx *sqlx.Tx, dirID int, userID uuid.UUID) error {
+	checkQuery := fmt.Sprint(
+		`SELECT EXISTS (
+			SELECT 1
+			FROM dir
+			WHERE path @> (SELECT path FROM dir WHERE id = $1) AND deleted_at IS NOT NULL
+				AND dir_user_access(id, $2) NOT IN ('m', 'ma')
+		)`,
+	)
+
+	var forbidden bool
+	if err := tx.Get(&forbidden, checkQuery, dirID, userID.String()); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+	if forbidden {
+		return trash.ErrAncestorForbidden
+	}
+
+	query := fmt.Sprint(
+		`UPDATE dir
+		SET deleted_at = NULL, deleted_by = NULL
+		WHERE path @> (SELECT path FROM dir WHERE id = $1) AND deleted_at IS NOT NULL`,
+	)
+
+	if _, err := tx.Exec(query, dirID); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p *PostgreSQL) RestoreNote(noteID uuid.UUID, userID uuid.UUID) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprint(
+		`UPDATE note
+		SET deleted_at = NULL, deleted_by = NULL
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING dir_id`,
+	)
+
+	var dirID int
+	if err := tx.Get(&dirID, query, noteID.String()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: noteID}, err)
+		}
+
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	if err := restoreAncestorDirs(tx, dirID, userID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+type deletedDirInfo struct {
+	Path      string    `db:"path"`
+	DeletedAt time.Time `db:"deleted_at"`
+}
+
+// RestoreDir restores dir with subdirs and notes deleted together with it
+func (p *PostgreSQL) RestoreDir(dirID int, userID uuid.UUID) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	infoQuery := fmt.Sprint(
+		`SELECT path::text as path, deleted_at
+			FROM dir
+			WHERE id = $1 AND deleted_at IS NOT NULL`,
+	)
+
+	var info deletedDirInfo
+	if err := tx.Get(&info, infoQuery, dirID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: dirID}, err)
+		}
+
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	notesQuery := fmt.Sprint(
+		`UPDATE note
+		SET deleted_at = NULL, deleted_by = NULL
+		WHERE dir_id IN (SELECT id FROM dir WHERE path <@ $1::ltree) AND deleted_at = $2`,
+	)
+
+	if _, err := tx.Exec(notesQuery, info.Path, info.DeletedAt); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	dirsQuery := fmt.Sprint(
+		`UPDATE dir
+		SET deleted_at = NULL, deleted_by = NULL
+		WHERE path <@ $1::ltree AND deleted_at = $2`,
+	)
+
+	if _, err := tx.Exec(dirsQuery, info.Path, info.DeletedAt); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	if err := restoreAncestorDirs(tx, dirID, userID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (p *PostgreSQL) PurgeNote(noteID uuid.UUID) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	summQuery := fmt.Sprint(
+		`DELETE
+		FROM summ_to_note
+		WHERE note_id = (SELECT id FROM note WHERE id = $1 AND deleted_at IS NOT NULL)`,
+	)
+
+	if _, err := tx.Exec(summQuery, noteID.String()); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	query := fmt.Sprint(
+		`DELETE
+		FROM note
+		WHERE id = $1 AND deleted_at IS NOT NULL`,
+	)
+
+	resExec, err := tx.Exec(query, noteID.String())
+	if err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+	deleted, err := resExec.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to check RowsAffected: %w", err)
+	}
+
+	if deleted == 0 {
+		return fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: noteID})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeDir permanently deletes trashed notes and dirs of dir's subtree. Like PurgeDeletedBefore,
+// dir is deleted only with entirely purged subtree, so notes and dirs restored or moved under it
+// aren't deleted by cascade
+func (p *PostgreSQL) PurgeDir(dirID int) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	pathQuery := fmt.Sprint(
+		`SELECT path
+		FROM dir
+		WHERE id = $1 AND deleted_at IS NOT NULL`,
+	)
+
+	var path string
+	if err := tx.Get(&path, pathQuery, dirID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("(repo): %w", &repository.NotFoundError{ID: dirID})
+		}
+
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	summQuery := fmt.Sprint(
+		`DELETE
+		FROM summ_to_note
+		WHERE note_id IN (
+			SELECT n.id
+			FROM note n INNER JOIN dir d ON n.dir_id = d.id
+			WHERE d.path <@ $1::ltree AND n.deleted_at IS NOT NULL
+		)`,
+	)
+
+	if _, err := tx.Exec(summQuery, path); err != nil {
+		return fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	for _, query := range []string{
+		`DELETE
+		FROM note
+		WHERE dir_id IN (SELECT id FROM dir WHERE path <@ $1::ltree) AND deleted_at IS NOT NULL`,
+		`DELETE
+		FROM dir d
+		WHERE d.path <@ $1::ltree AND d.deleted_at IS NOT NULL
+			AND NOT EXISTS (
+				SELECT 1
+				FROM dir sd
+				WHERE sd.path <@ d.path AND sd.deleted_at IS NULL
+			)
+			AND NOT EXISTS (
+				SELECT 1
+				FROM note n INNER JOIN dir sd ON n.dir_id = sd.id
+				WHERE sd.path <@ d.path
+			)`,
+	} {
+		if _, err := tx.Exec(query, path); err != nil {
+			return fmt.Errorf("(repo) failed to exec query: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeDeletedBefore permanently deletes notes and dirs moved to trash before deletedBefore,
+// returns number of deleted rows. Dir is deleted only with entirely purged subtree,
+// so notes and dirs restored or deleted later aren't deleted by cascade
+func (p *PostgreSQL) PurgeDeletedBefore(deletedBefore time.Time) (int64, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	summQuery := fmt.Sprint(
+		`DELETE
+		FROM summ_to_note
+		WHERE note_id IN (SELECT id FROM note WHERE deleted_at < $1)`,
+	)
+
+	if _, err := tx.Exec(summQuery, deletedBefore); err != nil {
+		return 0, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	var purged int64
+	for _, query := range []string{
+		`DELETE FROM note WHERE deleted_at < $1`,
+		`DELETE
+		FROM dir d
+		WHERE d.deleted_at < $1
+			AND NOT EXISTS (
+				SELECT 1
+				FROM dir sd
+				WHERE sd.path <@ d.path AND (sd.deleted_at IS NULL OR sd.deleted_at >= $1)
+			)
+			AND NOT EXISTS (
+				SELECT 1
+				FROM note n INNER JOIN dir sd ON n.dir_id = sd.id
+				WHERE sd.path <@ d.path
+			)`,
+	} {
+		resExec, err := tx.Exec(query, deletedBefore)
+		if err != nil {
+			return 0, fmt.Errorf("(repo) failed to exec query: %w", err)
+		}
+		deleted, err := resExec.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("(repo) failed to check RowsAffected: %w", err)
+		}
+		purged += deleted
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return purged, nil
+}
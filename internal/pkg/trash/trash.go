@@ -0,0 +1,40 @@
+package trash
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
+
+// ErrAncestorForbidden is returned when restored item's deleted parent dir can't be restored by user
+var ErrAncestorForbidden = errors.New("restore of deleted parent dir forbidden")
+
+type Usecase interface {
+	List(userID uuid.UUID) ([]*models.Note, []*models.Dir, error)
+
+	GetNoteUserAccess(noteID uuid.UUID, userID uuid.UUID) (models.NoteAccess, error)
+	GetDirUserAccess(dirID int, userID uuid.UUID) (models.NoteAccess, error)
+
+	// RestoreNote and RestoreDir also restore deleted parent dirs, if user is allowed to
+	RestoreNote(noteID uuid.UUID, userID uuid.UUID) error
+	RestoreDir(dirID int, userID uuid.UUID) error
+	PurgeNote(noteID uuid.UUID) error
+	PurgeDir(dirID int) error
+	PurgeExpired(retention time.Duration) (int64, error)
+}
+
+type Repository interface {
+	ListNotes(userID uuid.UUID) ([]*models.Note, error)
+	ListDirs(userID uuid.UUID) ([]*models.Dir, error)
+
+	GetNoteUserAccess(noteID uuid.UUID, userID uuid.UUID) (models.NoteAccess, error)
+	GetDirUserAccess(dirID int, userID uuid.UUID) (models.NoteAccess, error)
+
+	RestoreNote(noteID uuid.UUID, userID uuid.UUID) error
+	RestoreDir(dirID int, userID uuid.UUID) error
+	PurgeNote(noteID uuid.UUID) error
+	PurgeDir(dirID int) error
+	PurgeDeletedBefore(deletedBefore time.Time) (int64, error)
+}
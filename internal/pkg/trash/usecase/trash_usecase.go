@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/trash"
+)
+
+// Usecase implements trash.Usecase
+type Usecase struct {
+	repo trash.Repository
+}
+
+func NewUsecase(tr trash.Repository) *Usecase {
+	return &Usecase{
+		repo: tr,
+	}
+}
+
+func (u *Usecase) List(userID uuid.UUID) ([]*models.Note, []*models.Dir, error) {
+	notes, err := u.repo.ListNotes(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dirs, err := u.repo.ListDirs(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return notes, dirs, nil
+}
+
+func (u *Usecase) GetNoteUserAccess(noteID uuid.UUID, userID uuid.UUID) (models.NoteAccess, error) {
+	return u.repo.GetNoteUserAccess(noteID, userID)
+}
+
+func (u *Usecase) GetDirUserAccess(dirID int, userID uuid.UUID) (models.NoteAccess, error) {
+	return u.repo.GetDirUserAccess(dirID, userID)
+}
+
+func (u *Usecase) RestoreNote(noteID uuid.UUID, userID uuid.UUID) error {
+	return u.repo.RestoreNote(noteID, userID)
+}
+
+func (u *Usecase) RestoreDir(dirID int, userID uuid.UUID) error {
+	return u.repo.RestoreDir(dirID, userID)
+}
+
+func (u *Usecase) PurgeNote(noteID uuid.UUID) error {
+	return u.repo.PurgeNote(noteID)
+}
+
+func (u *Usecase) PurgeDir(dirID int) error {
+	return u.repo.PurgeDir(dirID)
+}
+
+// PurgeExpired permanently deletes notes and dirs staying in trash longer than retention
+func (u *Usecase) PurgeExpired(retention time.Duration) (int64, error) {
+	return u.repo.PurgeDeletedBefore(time.Now().Add(-retention))
+}
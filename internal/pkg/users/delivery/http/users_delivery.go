@@ -1,27 +1,59 @@
 package http
 
 import (
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
 	"github.com/gin-gonic/gin"
 	"github.com/go-park-mail-ru/2023_1_Technokaif/pkg/logger"
 	"github.com/gofrs/uuid/v5"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/http/auth"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/repository"
 	"github.com/yarikTri/archipelago-notes-api/internal/models"
+	"github.com/yarikTri/archipelago-notes-api/internal/pkg/dirs"
 	"github.com/yarikTri/archipelago-notes-api/internal/pkg/users"
-	"net/http"
-	"strconv"
 )
 
 type Handler struct {
 	usersUsecase users.Usecase
+	dirsUsecase  dirs.Usecase
 	logger       logger.Logger
 }
 
-func NewHandler(uu users.Usecase, l logger.Logger) *Handler {
+func NewHandler(uu users.Usecase, du dirs.Usecase, l logger.Logger) *Handler {
 	return &Handler{
 		usersUsecase: uu,
+		dirsUsecase:  du,
 		logger:       l,
 	}
 }
 
+// checkAccess returns id of request's user if he is allowed to call method on user with targetUserID
+func (h *Handler) checkAccess(c *gin.Context, targetUserID uuid.UUID, method methodName) *uuid.UUID {
+	userID, err := auth.GetUserId(c)
+	if err != nil || userID == uuid.Nil {
+		h.logger.Infof("Unathorized request for user %s, method %s", targetUserID.String(), method)
+		c.JSON(http.StatusUnauthorized, "")
+		return nil
+	}
+
+	if selfOnlyMethodsMap[method] && userID != targetUserID {
+		h.logger.Infof("Access forbidden for user %s, target user %s, method %s", userID.String(), targetUserID.String(), method)
+		c.JSON(http.StatusForbidden, "Forbidden")
+		return nil
+	}
+
+	return &userID
+}
+
+func isNotFound(err error) bool {
+	var notFoundErr *repository.NotFoundError
+	return errors.As(err, &notFoundErr) || errors.Is(err, sql.ErrNoRows)
+}
+
 // Get
 // @Summary		Get user
 // @Tags		Users
@@ -35,12 +67,20 @@ func NewHandler(uu users.Usecase, l logger.Logger) *Handler {
 func (h *Handler) Get(c *gin.Context) {
 	id, err := uuid.FromString(c.Param("id"))
 	if err != nil {
-		h.logger.Infof("Invalid user id '%s'", id)
+		h.logger.Infof("Invalid user id '%s'", c.Param("id"))
 		c.JSON(http.StatusBadRequest, err)
 		return
 	}
 
+	if callerID := h.checkAccess(c, id, getMethodName); callerID == nil {
+		return
+	}
+
 	user, err := h.usersUsecase.GetByID(id)
+	if isNotFound(err) {
+		c.JSON(http.StatusNotFound, "User not found")
+		return
+	}
 	if err != nil {
 		h.logger.Errorf("Error while getting user with id %d: %w", id, err)
 		c.JSON(http.StatusInternalServerError, err)
@@ -61,6 +101,10 @@ func (h *Handler) Get(c *gin.Context) {
 // @Failure		500			{object}	error					"Server error"
 // @Router		/api/users/ [get]
 func (h *Handler) Search(c *gin.Context) {
+	if callerID := h.checkAccess(c, uuid.Nil, searchMethodName); callerID == nil {
+		return
+	}
+
 	query := c.Query("q")
 	users, err := h.usersUsecase.Search(query)
 	if err != nil {
@@ -90,18 +134,38 @@ func (h *Handler) Search(c *gin.Context) {
 func (h *Handler) SetRootDirID(c *gin.Context) {
 	userID, err := uuid.FromString(c.Param("userID"))
 	if err != nil {
-		h.logger.Infof("Invalid user id '%s'", userID)
+		h.logger.Infof("Invalid user id '%s'", c.Param("userID"))
 		c.JSON(http.StatusBadRequest, err)
 		return
 	}
 
 	rootDirID, err := strconv.Atoi(c.Param("rootDirID"))
 	if err != nil {
-		h.logger.Infof("Invalid root dir id '%s'", userID)
+		h.logger.Infof("Invalid root dir id '%s'", c.Param("rootDirID"))
 		c.JSON(http.StatusBadRequest, err)
 		return
 	}
 
+	if callerID := h.checkAccess(c, userID, setRootDirMethodName); callerID == nil {
+		return
+	}
+
+	dirAccess, err := h.dirsUsecase.GetUserAccess(rootDirID, userID)
+	if isNotFound(err) {
+		c.JSON(http.StatusNotFound, "Dir not found")
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("Error while check access for user with id %s: %w", userID.String(), err)
+		c.JSON(http.StatusInternalServerError, "Can't check access")
+		return
+	}
+	if dirAccess != models.ManageAccessNoteAccess {
+		h.logger.Infof("Access forbidden for user %s to set root dir %d", userID.String(), rootDirID)
+		c.JSON(http.StatusForbidden, "Forbidden")
+		return
+	}
+
 	if err := h.usersUsecase.SetRootDirByID(userID, rootDirID); err != nil {
 		h.logger.Errorf("Error: %w", err)
 		c.JSON(http.StatusInternalServerError, err)
@@ -114,21 +178,42 @@ func (h *Handler) SetRootDirID(c *gin.Context) {
 // SendEmailConfirmation
 // @Summary		Send email confirmation
 // @Tags		Users
-// @Description	Send user's email confirmation
+// @Description	Send user's email confirmation, can be repeated after cooldown
 // @Param		userID path string true 								"User ID"
 // @Success		200			{object}	string							"Mail sent"
 // @Failure		400			{object}	error							"Incorrect input"
+// @Failure		409			{object}	string							"Email is already confirmed"
+// @Failure		429			{object}	string							"Confirmation was sent recently"
 // @Failure		500			{object}	error							"Server error"
 // @Router		/api/users/{userID}/send_email_confirmation [post]
 func (h *Handler) SendEmailConfirmation(c *gin.Context) {
 	userID, err := uuid.FromString(c.Param("userID"))
 	if err != nil {
-		h.logger.Infof("Invalid user id '%s'", userID)
+		h.logger.Infof("Invalid user id '%s'", c.Param("userID"))
 		c.JSON(http.StatusBadRequest, err)
 		return
 	}
 
-	if err := h.usersUsecase.SendEmailConfirmation(userID); err != nil {
+	if callerID := h.checkAccess(c, userID, sendEmailConfirmationMethodName); callerID == nil {
+		return
+	}
+
+	err = h.usersUsecase.SendEmailConfirmation(userID)
+	var cooldownErr *users.ConfirmationCooldownError
+	if errors.As(err, &cooldownErr) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(cooldownErr.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, cooldownErr.Error())
+		return
+	}
+	if errors.Is(err, users.ErrEmailAlreadyConfirmed) {
+		c.JSON(http.StatusConflict, err.Error())
+		return
+	}
+	if isNotFound(err) {
+		c.JSON(http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
 		h.logger.Errorf("Error: %w", err)
 		c.JSON(http.StatusInternalServerError, err)
 		return
@@ -140,25 +225,37 @@ func (h *Handler) SendEmailConfirmation(c *gin.Context) {
 // ConfirmEmail
 // @Summary		Confirm email
 // @Tags		Users
-// @Description	Confirm user's email
-// @Param		userID path string true 								"User ID"
+// @Description	Confirm email by token from confirmation link, token can be used once
+// @Accept		json
+// @Param		token	body		ConfirmEmailRequest			true	"Confirmation token"
 // @Success		200			{object}	string							"Email confirmed"
-// @Failure		400			{object}	error							"Incorrect input"
+// @Failure		400			{object}	error							"Incorrect input or invalid token"
 // @Failure		500			{object}	error							"Server error"
-// @Router		/api/users/{userID}/confirm_email [post]
+// @Router		/api/users/confirm_email [post]
 func (h *Handler) ConfirmEmail(c *gin.Context) {
-	userID, err := uuid.FromString(c.Param("userID"))
-	if err != nil {
-		h.logger.Infof("Invalid user id '%s'", userID)
+	var req ConfirmEmailRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.logger.Infof("Invalid confirm email request: %w", err)
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		h.logger.Infof("Invalid confirm email request: %w", err)
 		c.JSON(http.StatusBadRequest, err)
 		return
 	}
 
-	if err := h.usersUsecase.ConfirmEmail(userID); err != nil {
+	userID, err := h.usersUsecase.ConfirmEmail(req.Token)
+	if errors.Is(err, users.ErrInvalidConfirmationToken) {
+		c.JSON(http.StatusBadRequest, "Invalid or expired token")
+		return
+	}
+	if err != nil {
 		h.logger.Errorf("Error: %w", err)
 		c.JSON(http.StatusInternalServerError, err)
 		return
 	}
 
+	h.logger.Infof("Email of user %s confirmed", userID.String())
 	c.JSON(http.StatusOK, "")
 }
@@ -0,0 +1,34 @@
+package http
+
+type methodName uint8
+
+const (
+	getMethodName methodName = iota
+	searchMethodName
+	setRootDirMethodName
+	sendEmailConfirmationMethodName
+)
+
+func (mn *methodName) String() string {
+	switch *mn {
+	case getMethodName:
+		return "get"
+	case searchMethodName:
+		return "search"
+	case setRootDirMethodName:
+		return "set_root_dir"
+	case sendEmailConfirmationMethodName:
+		return "send_email_confirmation"
+	}
+
+	return ""
+}
+
+// selfOnlyMethodsMap contains methods user is allowed to call only on himself,
+// other methods are available for any authorized user
+var selfOnlyMethodsMap = map[methodName]bool{
+	getMethodName:                   false,
+	searchMethodName:                false,
+	setRootDirMethodName:            true,
+	sendEmailConfirmationMethodName: true,
+}
@@ -1,7 +1,19 @@
 package http
 
-import "github.com/yarikTri/archipelago-notes-api/internal/models"
+import (
+	valid "github.com/asaskevich/govalidator"
+	"github.com/yarikTri/archipelago-notes-api/internal/models"
+)
 
 type SearchUsersResponse struct {
 	Users []*models.UserTransfer `json:"users"`
 }
+
+type ConfirmEmailRequest struct {
+	Token string `json:"token" valid:"required"`
+}
+
+func (cer *ConfirmEmailRequest) validate() error {
+	_, err := valid.ValidateStruct(cer)
+	return err
+}
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
+
 	"github.com/gofrs/uuid/v5"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
@@ -75,13 +77,102 @@ func (p *PostgreSQL) SetRootDirByID(userID uuid.UUID, rootID int) error {
 	return err
 }
 
-func (p *PostgreSQL) ConfirmEmail(userID uuid.UUID) error {
+// CreateEmailConfirmation locks user, so concurrent requests can't both pass the check of last confirmation time
+func (p *PostgreSQL) CreateEmailConfirmation(userID uuid.UUID, tokenHash string, expiresAt, notCreatedSince time.Time) (*time.Time, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	lockQuery := fmt.Sprint(
+		`SELECT id FROM "user" WHERE id = $1 FOR UPDATE`,
+	)
+
+	var lockedID uuid.UUID
+	if err := tx.Get(&lockedID, lockQuery, userID.String()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: userID}, err)
+		}
+
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	lastQuery := fmt.Sprint(
+		`SELECT MAX(created_at)
+			FROM email_confirmation
+			WHERE user_id = $1`,
+	)
+
+	var lastCreatedAt *time.Time
+	if err := tx.Get(&lastCreatedAt, lastQuery, userID.String()); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+	if lastCreatedAt != nil && lastCreatedAt.After(notCreatedSince) {
+		return lastCreatedAt, nil
+	}
+
+	deleteQuery := fmt.Sprint(
+		`DELETE
+		FROM email_confirmation
+		WHERE user_id = $1 AND used_at IS NULL`,
+	)
+
+	if _, err := tx.Exec(deleteQuery, userID.String()); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	query := fmt.Sprint(
+		`INSERT INTO email_confirmation (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)`,
+	)
+
+	if _, err := tx.Exec(query, userID.String(), tokenHash, expiresAt); err != nil {
+		return nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return nil, nil
+}
+
+// ConfirmEmailByTokenHash marks token used, so it can't be used twice even concurrently
+func (p *PostgreSQL) ConfirmEmailByTokenHash(tokenHash string) (uuid.UUID, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("(repo) failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	useQuery := fmt.Sprint(
+		`UPDATE email_confirmation
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		RETURNING user_id`,
+	)
+
+	var userID uuid.UUID
+	if err := tx.Get(&userID, useQuery, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, fmt.Errorf("(repo) %w: %v", &repository.NotFoundError{ID: "confirmation token"}, err)
+		}
+
+		return uuid.Nil, fmt.Errorf("(repo) failed to exec query: %w", err)
+	}
+
 	query := fmt.Sprint(
 		`UPDATE "user" SET email_confirmed = true WHERE id = $1`,
 	)
 
-	if _, err := p.db.Exec(query, userID.String()); err != nil {
-		return fmt.Errorf("(repo) failed to exec query: %s", err)
+	if _, err := tx.Exec(query, userID.String()); err != nil {
+		return uuid.Nil, fmt.Errorf("(repo) failed to exec query: %w", err)
 	}
-	return nil
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, fmt.Errorf("(repo) failed to commit transaction: %w", err)
+	}
+
+	return userID, nil
 }
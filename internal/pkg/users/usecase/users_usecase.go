@@ -1,12 +1,26 @@
 package usecase
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/gofrs/uuid/v5"
 	"github.com/yarikTri/archipelago-notes-api/internal/clients/invitations/email"
+	"github.com/yarikTri/archipelago-notes-api/internal/common/repository"
 	"github.com/yarikTri/archipelago-notes-api/internal/models"
 	"github.com/yarikTri/archipelago-notes-api/internal/pkg/users"
 )
 
+const (
+	confirmationTokenLength = 32
+	confirmationTokenTTL    = 24 * time.Hour
+	confirmationCooldown    = time.Minute
+)
+
 // Usecase implements users.Usecase
 type Usecase struct {
 	repo                    users.Repository
@@ -32,15 +46,57 @@ func (u *Usecase) SetRootDirByID(userID uuid.UUID, dirID int) error {
 	return u.repo.SetRootDirByID(userID, dirID)
 }
 
+// SendEmailConfirmation is allowed once in confirmationCooldown, so mailbox can't be flooded
 func (u *Usecase) SendEmailConfirmation(userID uuid.UUID) error {
 	user, err := u.repo.GetByID(userID)
 	if err != nil {
 		return err
 	}
 
-	return u.emailConfirmationClient.SendConfirmation(user.Email, user.ID.String())
+	if user.EmailConfirmed {
+		return users.ErrEmailAlreadyConfirmed
+	}
+
+	token, err := generateConfirmationToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	lastSentAt, err := u.repo.CreateEmailConfirmation(userID, hashConfirmationToken(token),
+		now.Add(confirmationTokenTTL), now.Add(-confirmationCooldown))
+	if err != nil {
+		return err
+	}
+	if lastSentAt != nil {
+		return &users.ConfirmationCooldownError{RetryAfter: lastSentAt.Add(confirmationCooldown).Sub(now)}
+	}
+
+	return u.emailConfirmationClient.SendConfirmation(user.Email, token)
+}
+
+func (u *Usecase) ConfirmEmail(token string) (uuid.UUID, error) {
+	userID, err := u.repo.ConfirmEmailByTokenHash(hashConfirmationToken(token))
+	var notFoundErr *repository.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return uuid.Nil, users.ErrInvalidConfirmationToken
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return userID, nil
+}
+
+func generateConfirmationToken() (string, error) {
+	b := make([]byte, confirmationTokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("(usecase) failed to generate confirmation token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
 }
 
-func (u *Usecase) ConfirmEmail(userID uuid.UUID) error {
-	return u.repo.ConfirmEmail(userID)
+func hashConfirmationToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
 }
@@ -1,21 +1,47 @@
 package users
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/gofrs/uuid/v5"
 	"github.com/yarikTri/archipelago-notes-api/internal/models"
 )
 
+// ErrEmailAlreadyConfirmed is returned on attempt to send confirmation of confirmed email
+var ErrEmailAlreadyConfirmed = errors.New("email is already confirmed")
+
+// ErrInvalidConfirmationToken is returned when confirmation token is unknown, used or expired
+var ErrInvalidConfirmationToken = errors.New("invalid email confirmation token")
+
+// ConfirmationCooldownError is returned when confirmation is requested again too soon
+type ConfirmationCooldownError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ConfirmationCooldownError) Error() string {
+	return fmt.Sprintf("email confirmation may be requested again in %s", e.RetryAfter)
+}
+
 type Usecase interface {
 	GetByID(userID uuid.UUID) (*models.User, error)
 	Search(query string) ([]*models.User, error)
 	SetRootDirByID(userID uuid.UUID, dirID int) error
+	// SendEmailConfirmation emails link with new confirmation token, previous tokens stop working
 	SendEmailConfirmation(userID uuid.UUID) error
-	ConfirmEmail(userID uuid.UUID) error
+	// ConfirmEmail confirms email of token's user, returns his id
+	ConfirmEmail(token string) (uuid.UUID, error)
 }
 
 type Repository interface {
 	GetByID(userID uuid.UUID) (*models.User, error)
 	Search(query string) ([]*models.User, error)
 	SetRootDirByID(userID uuid.UUID, dirID int) error
-	ConfirmEmail(userID uuid.UUID) error
+
+	// CreateEmailConfirmation replaces user's unused confirmation tokens with new one,
+	// if no confirmation was created after notCreatedSince. Otherwise the last creation time is returned
+	CreateEmailConfirmation(userID uuid.UUID, tokenHash string, expiresAt, notCreatedSince time.Time) (*time.Time, error)
+	// ConfirmEmailByTokenHash uses token and confirms email of its user, returns NotFoundError for invalid token
+	ConfirmEmailByTokenHash(tokenHash string) (uuid.UUID, error)
 }